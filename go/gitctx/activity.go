@@ -0,0 +1,65 @@
+package gitctx
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/user/git-context/internal/model"
+)
+
+// Activity is one line of the timeline across memories, tasks and
+// locks.
+type Activity = model.Activity
+
+// Activity returns the timeline of everything in loc, oldest first.
+// Locks are taken from the lock journal; a lock from before the journal
+// shows only its current state.
+func (c *Client) Activity(ctx context.Context, loc Location) ([]Activity, error) {
+	var items []Activity
+	for _, s := range c.storages(loc) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		shared := s.loc == Shared
+
+		memories, err := s.ListMemories()
+		if err != nil {
+			return nil, fmt.Errorf("failed to list %s memories: %w", s.loc, err)
+		}
+		for _, m := range memories {
+			m.Shared = shared
+			items = append(items, model.MemoryActivity(m)...)
+		}
+
+		tasks, err := s.ListTasks()
+		if err != nil {
+			return nil, fmt.Errorf("failed to list %s tasks: %w", s.loc, err)
+		}
+		for _, t := range tasks {
+			t.Shared = shared
+			items = append(items, model.TaskActivity(t)...)
+		}
+
+		events, err := s.ListLockEvents("")
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s lock history: %w", s.loc, err)
+		}
+		journaled := make(map[string]bool)
+		for _, e := range events {
+			items = append(items, model.LockActivity(e, shared))
+			journaled[e.Target] = true
+		}
+		locks, err := s.ListLocks()
+		if err != nil {
+			return nil, fmt.Errorf("failed to list %s locks: %w", s.loc, err)
+		}
+		for _, l := range locks {
+			if !journaled[l.Target] {
+				items = append(items, model.CurrentLockActivity(l, shared))
+			}
+		}
+	}
+
+	model.SortActivity(items)
+	return items, nil
+}
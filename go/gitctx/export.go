@@ -0,0 +1,262 @@
+package gitctx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/user/git-context/internal/export"
+	"github.com/user/git-context/internal/model"
+	"github.com/user/git-context/internal/storage"
+)
+
+// Snapshot is the full content of local and shared storage, as written
+// by export and read by import.
+type Snapshot = export.Snapshot
+
+// Export reads everything in loc, keeping IDs and timestamps, including
+// archived entries and expired locks.
+func (c *Client) Export(ctx context.Context, loc Location) (*Snapshot, error) {
+	snap := &Snapshot{}
+	for _, s := range c.storages(loc) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		set, err := export.Collect(s.Storage, s.loc == Shared)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", s.loc, err)
+		}
+		if s.loc == Shared {
+			snap.Shared = set
+		} else {
+			snap.Local = set
+		}
+	}
+	return snap, nil
+}
+
+// ConflictPolicy says what Import does with an ID that already exists.
+type ConflictPolicy string
+
+const (
+	// ConflictSkip keeps the existing entry.
+	ConflictSkip ConflictPolicy = "skip"
+	// ConflictOverwrite replaces it with the imported one.
+	ConflictOverwrite ConflictPolicy = "overwrite"
+	// ConflictRename imports under a new ID and updates references to
+	// it among the imported entries. Locks cannot be renamed and are
+	// skipped.
+	ConflictRename ConflictPolicy = "rename"
+)
+
+// ParseConflictPolicy validates a conflict policy name.
+func ParseConflictPolicy(s string) (ConflictPolicy, error) {
+	switch p := ConflictPolicy(s); p {
+	case ConflictSkip, ConflictOverwrite, ConflictRename:
+		return p, nil
+	}
+	return "", fmt.Errorf("invalid conflict policy %q (valid: skip, overwrite, rename)", s)
+}
+
+// ImportOptions control Import.
+type ImportOptions struct {
+	OnConflict ConflictPolicy // default ConflictSkip
+	DryRun     bool           // report without writing
+}
+
+// ImportAction is what Import did with one entry that already existed.
+type ImportAction struct {
+	Entity   string   // memory, task or lock
+	Location Location // where it was imported to
+	ID       string   // lock target for locks
+	NewID    string   // set when renamed
+	Skipped  bool
+}
+
+// ImportResult summarizes an import.
+type ImportResult struct {
+	Imported    int
+	Skipped     int
+	Overwritten int
+	Renamed     int
+	Conflicts   []ImportAction
+}
+
+// Import writes a snapshot back into the storage each side came from,
+// keeping IDs and timestamps.
+func (c *Client) Import(ctx context.Context, snap *Snapshot, opts ImportOptions) (*ImportResult, error) {
+	if opts.OnConflict == "" {
+		opts.OnConflict = ConflictSkip
+	}
+	imp := &importer{opts: opts, result: &ImportResult{}, newID: c.newID}
+	sides := []struct {
+		set *export.Set
+		loc Location
+	}{{&snap.Local, Local}, {&snap.Shared, Shared}}
+
+	// Renames are planned up front, so references between imported
+	// entries can be rewritten before anything is written. Each storage
+	// gets its own, since an ID can conflict in one and not the other.
+	renamed := make(map[Location]renames)
+	for _, side := range sides {
+		renamed[side.loc] = imp.planRenames(side.set, c.storage(side.loc))
+	}
+	for _, side := range sides {
+		if err := ctx.Err(); err != nil {
+			return imp.result, err
+		}
+		if err := imp.apply(side.set, c.storage(side.loc), side.loc, renamed[side.loc]); err != nil {
+			return imp.result, err
+		}
+	}
+	return imp.result, nil
+}
+
+// importer writes snapshot sets according to a conflict policy.
+type importer struct {
+	opts   ImportOptions
+	result *ImportResult
+	newID  func() string
+}
+
+// renames maps old IDs to new IDs within one storage.
+type renames map[string]string
+
+func (r renames) rename(id string) string {
+	if newID, ok := r[id]; ok {
+		return newID
+	}
+	return id
+}
+
+// planRenames picks new IDs for entries whose IDs are taken.
+func (imp *importer) planRenames(set *export.Set, s storage.Storage) renames {
+	renamed := make(renames)
+	if imp.opts.OnConflict != ConflictRename {
+		return renamed
+	}
+	for _, m := range set.Memories {
+		if _, err := s.ReadMemory(m.ID); err == nil {
+			renamed[m.ID] = imp.newID()
+		}
+	}
+	for _, t := range set.Tasks {
+		if _, err := s.ReadTask(t.ID); err == nil {
+			renamed[t.ID] = "task-" + imp.newID()
+		}
+	}
+	return renamed
+}
+
+func (imp *importer) apply(set *export.Set, s storage.Storage, loc Location, renamed renames) error {
+	for _, m := range set.Memories {
+		_, err := s.ReadMemory(m.ID)
+		if err != nil && !errors.Is(err, ErrNotFound) {
+			return fmt.Errorf("failed to read %s: %w", m.ID, err)
+		}
+		exists := err == nil
+
+		oldID := m.ID
+		m.ID = renamed.rename(m.ID)
+		m.SupersededBy = renamed.rename(m.SupersededBy)
+		m.Content = model.RewriteInlineRefs(m.Content, renamed.rename)
+		for i := range m.Links {
+			m.Links[i].Target = renamed.rename(m.Links[i].Target)
+		}
+
+		if !imp.decide(exists, model.EntityMemory, loc, oldID, m.ID) {
+			continue
+		}
+		if !imp.opts.DryRun {
+			if err := s.WriteMemory(m); err != nil {
+				return fmt.Errorf("failed to import %s: %w", m.ID, err)
+			}
+		}
+	}
+
+	for _, t := range set.Tasks {
+		_, err := s.ReadTask(t.ID)
+		if err != nil && !errors.Is(err, ErrNotFound) {
+			return fmt.Errorf("failed to read %s: %w", t.ID, err)
+		}
+		exists := err == nil
+
+		oldID := t.ID
+		t.ID = renamed.rename(t.ID)
+		t.Description = model.RewriteInlineRefs(t.Description, renamed.rename)
+		for i := range t.Links {
+			t.Links[i].Target = renamed.rename(t.Links[i].Target)
+		}
+		for i := range t.BlockedBy {
+			t.BlockedBy[i] = renamed.rename(t.BlockedBy[i])
+		}
+		for i := range t.Blocks {
+			t.Blocks[i] = renamed.rename(t.Blocks[i])
+		}
+
+		if !imp.decide(exists, model.EntityTask, loc, oldID, t.ID) {
+			continue
+		}
+		if !imp.opts.DryRun {
+			if err := s.WriteTask(t); err != nil {
+				return fmt.Errorf("failed to import %s: %w", t.ID, err)
+			}
+		}
+	}
+
+	for _, l := range set.Locks {
+		_, err := s.ReadLock(l.Target)
+		if err != nil && !errors.Is(err, ErrNotFound) {
+			return fmt.Errorf("failed to read lock on %s: %w", l.Target, err)
+		}
+		exists := err == nil
+
+		// A lock is identified by its target, so it cannot be renamed
+		if exists && imp.opts.OnConflict != ConflictOverwrite {
+			imp.result.Skipped++
+			imp.result.Conflicts = append(imp.result.Conflicts, ImportAction{
+				Entity: model.EntityLock, Location: loc, ID: l.Target, Skipped: true,
+			})
+			continue
+		}
+		if exists {
+			imp.result.Overwritten++
+		} else {
+			imp.result.Imported++
+		}
+		if !imp.opts.DryRun {
+			if err := s.WriteLock(l); err != nil {
+				return fmt.Errorf("failed to import lock %s: %w", l.Target, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// decide applies the conflict policy to one entity and updates the
+// counts. Returns false if the entity should not be written.
+func (imp *importer) decide(exists bool, entity string, loc Location, oldID, id string) bool {
+	if !exists {
+		imp.result.Imported++
+		return true
+	}
+
+	action := ImportAction{Entity: entity, Location: loc, ID: oldID}
+	write := true
+	switch imp.opts.OnConflict {
+	case ConflictOverwrite:
+		imp.result.Overwritten++
+	case ConflictRename:
+		// ID was already rewritten by planRenames
+		imp.result.Imported++
+		imp.result.Renamed++
+		action.NewID = id
+	default:
+		imp.result.Skipped++
+		action.Skipped = true
+		write = false
+	}
+	imp.result.Conflicts = append(imp.result.Conflicts, action)
+	return write
+}
@@ -0,0 +1,183 @@
+// Package gitctx is the Go API for git-context: memories, tasks and
+// locks stored in a git repository.
+//
+//	c, err := gitctx.Open(".")
+//	if err != nil { ... }
+//	t, err := c.ClaimTask(ctx, "task-abc123")
+//	if errors.Is(err, gitctx.ErrAlreadyClaimed) { ... }
+//
+// Entries are local (private to the clone) or shared (synced with
+// push/pull). Lookups by ID search local first, then shared.
+package gitctx
+
+import (
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/user/git-context/internal/config"
+	"github.com/user/git-context/internal/model"
+	"github.com/user/git-context/internal/storage"
+)
+
+// Data types.
+type (
+	Config     = config.Config
+	Memory     = model.Memory
+	MemoryKind = model.MemoryKind
+	Task       = model.Task
+	TaskStatus = model.TaskStatus
+	Comment    = model.Comment
+	Lock       = model.Lock
+	LockMode   = model.LockMode
+	LockEvent  = model.LockEvent
+	Link       = model.Link
+	Event      = model.Event
+
+	Workflow       = model.Workflow
+	WorkflowStatus = model.WorkflowStatus
+)
+
+// Task statuses.
+const (
+	TaskOpen    = model.TaskOpen
+	TaskClaimed = model.TaskClaimed
+	TaskReview  = model.TaskReview
+	TaskDone    = model.TaskDone
+)
+
+// Lock modes.
+const (
+	LockExclusive = model.LockExclusive
+	LockShared    = model.LockShared
+)
+
+// Errors returned by Client methods. Use errors.Is to check for them;
+// the returned errors add the ID or owner involved. ErrAlreadyClaimed,
+// ErrNotOwner, ErrNotReviewer, ErrExists and ErrNotAllowed are also
+// ErrConflict.
+var (
+	ErrNotFound = storage.ErrNotFound
+	ErrConflict = storage.ErrConflict
+	ErrLocked   = storage.ErrLocked
+
+	ErrAlreadyClaimed error = conflictError("already claimed")
+	ErrNotOwner       error = conflictError("not owned by you")
+	ErrNotReviewer    error = conflictError("not the reviewer")
+	ErrExists         error = conflictError("already exists")
+	ErrNotAllowed     error = conflictError("not allowed by the workflow")
+)
+
+// conflictError is a specific kind of ErrConflict.
+type conflictError string
+
+func (e conflictError) Error() string { return string(e) }
+
+func (e conflictError) Unwrap() error { return ErrConflict }
+
+// Location says which storage an entry lives in.
+type Location string
+
+const (
+	// Local entries stay in this clone.
+	Local Location = "local"
+	// Shared entries sync with push/pull.
+	Shared Location = "shared"
+	// All reads from both; only valid for listing.
+	All Location = ""
+)
+
+// Client reads and writes the context of one repository, acting as
+// one author.
+type Client struct {
+	store  *storage.MultiStorage
+	cfg    *Config
+	author string
+}
+
+// Open opens the context of the repository containing path, with its
+// settings. The author defaults to the repository's git user.
+func Open(path string) (*Client, error) {
+	if path == "" {
+		path = "."
+	}
+	out, err := exec.Command("git", "-C", path, "rev-parse", "--git-dir").Output()
+	if err != nil {
+		return nil, fmt.Errorf("not a git repository")
+	}
+	gitDir := strings.TrimSpace(string(out))
+	if !filepath.IsAbs(gitDir) {
+		gitDir = filepath.Join(path, gitDir)
+	}
+
+	cfg, err := config.Load(path)
+	if err != nil {
+		return nil, err
+	}
+	store, err := storage.NewMultiStorage(gitDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize storage: %w", err)
+	}
+	return &Client{store: store, cfg: cfg, author: gitUser(path)}, nil
+}
+
+// gitUser returns the git user name of the repository containing path.
+func gitUser(path string) string {
+	out, err := exec.Command("git", "-C", path, "config", "--get", "user.name").Output()
+	name := strings.TrimSpace(string(out))
+	if err != nil || name == "" {
+		return "Unknown"
+	}
+	return name
+}
+
+// Author returns who the client acts as.
+func (c *Client) Author() string {
+	return c.author
+}
+
+// Config returns the repository's settings, which the client follows
+// for lock expiry, claim leases and new IDs.
+func (c *Client) Config() *Config {
+	return c.cfg
+}
+
+// WithAuthor returns a client that acts as author on the same storage.
+func (c *Client) WithAuthor(author string) *Client {
+	return &Client{store: c.store, cfg: c.cfg, author: author}
+}
+
+// newID returns a random hex ID as long as the id.length setting says.
+func (c *Client) newID() string {
+	return model.RandomHex(c.cfg.IDLength)
+}
+
+// storage returns the storage for a location.
+func (c *Client) storage(loc Location) storage.Storage {
+	if loc == Shared {
+		return c.store.Shared
+	}
+	return c.store.Local
+}
+
+// storages returns the storages to read for a location, in lookup
+// order.
+func (c *Client) storages(loc Location) []locatedStorage {
+	switch loc {
+	case Local:
+		return []locatedStorage{{Local, c.store.Local}}
+	case Shared:
+		return []locatedStorage{{Shared, c.store.Shared}}
+	}
+	return []locatedStorage{{Local, c.store.Local}, {Shared, c.store.Shared}}
+}
+
+type locatedStorage struct {
+	loc Location
+	storage.Storage
+}
+
+func notFound(id string) error {
+	return fmt.Errorf("%w: %s", ErrNotFound, id)
+}
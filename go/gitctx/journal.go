@@ -0,0 +1,110 @@
+package gitctx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/user/git-context/internal/model"
+)
+
+// BreakLock removes the lock on target whoever holds it, for a lock left
+// behind by an agent that died. A reason is required; it is recorded in
+// the lock journal with whose lock was broken. The lock passes to the
+// next waiter, if there is one. It returns the lock as it was.
+func (c *Client) BreakLock(ctx context.Context, target, reason string) (*Lock, error) {
+	if strings.TrimSpace(reason) == "" {
+		return nil, fmt.Errorf("a reason is required to break a lock")
+	}
+
+	_, loc, err := c.findLock(ctx, target)
+	if err != nil {
+		return nil, err
+	}
+
+	var broken Lock
+	var handed *Lock
+	err = c.storage(loc).UpdateLock(target, func(l *Lock) (*Lock, error) {
+		handed = nil
+		if l == nil || l.IsExpired() {
+			return nil, notLockedError(target)
+		}
+		broken = *l
+		l.Holders = nil
+		if l.HandOff() {
+			c.renewLock(l)
+			handed = l
+			return l, nil
+		}
+		return nil, nil
+	})
+	if errors.Is(err, ErrNotFound) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to break lock: %w", err)
+	}
+	holders := strings.Join(broken.HeldBy(), ", ")
+
+	e := model.NewLockEvent(target, model.EventBroken, c.author)
+	e.Mode, e.Holder, e.Reason = broken.Mode, holders, reason
+	if err := c.storage(loc).AppendLockEvent(e); err != nil {
+		return &broken, fmt.Errorf("lock broken, but failed to record it: %w", err)
+	}
+	if handed != nil {
+		if err := c.recordHandOff(loc, handed); err != nil {
+			return &broken, err
+		}
+	}
+	return &broken, nil
+}
+
+// LockHistory returns the lock journal of a location, oldest first:
+// every acquire, renewal, release and break. An empty target returns
+// every target's events.
+func (c *Client) LockHistory(ctx context.Context, target string, loc Location) ([]*LockEvent, error) {
+	var result []*LockEvent
+	for _, s := range c.storages(loc) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		events, err := s.ListLockEvents(target)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s lock history: %w", s.loc, err)
+		}
+		result = append(result, events...)
+	}
+	model.SortLockEvents(result)
+	return result, nil
+}
+
+// record journals an event by author on l, kept in loc.
+func (c *Client) record(loc Location, l *Lock, typ model.EventType, author, reason string) error {
+	e := model.NewLockEvent(l.Target, typ, author)
+	e.Mode, e.Reason = l.Mode, reason
+	if err := c.storage(loc).AppendLockEvent(e); err != nil {
+		return fmt.Errorf("lock %s, but failed to record it: %w", typ, err)
+	}
+	return nil
+}
+
+// recordRelease journals holder letting go of the lock on target.
+func (c *Client) recordRelease(loc Location, target string, mode LockMode, holder string) error {
+	e := model.NewLockEvent(target, model.EventReleased, holder)
+	e.Mode = mode
+	if err := c.storage(loc).AppendLockEvent(e); err != nil {
+		return fmt.Errorf("lock released, but failed to record it: %w", err)
+	}
+	return nil
+}
+
+// recordHandOff journals the waiters l was just handed to acquiring it.
+func (c *Client) recordHandOff(loc Location, l *Lock) error {
+	for _, holder := range l.HeldBy() {
+		if err := c.record(loc, l, model.EventAcquired, holder, l.Reason); err != nil {
+			return err
+		}
+	}
+	return nil
+}
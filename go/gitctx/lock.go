@@ -0,0 +1,422 @@
+package gitctx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/user/git-context/internal/model"
+)
+
+// DefaultWaitInterval is how often WaitLock polls.
+const DefaultWaitInterval = 5 * time.Second
+
+// LockOptions are the optional settings of a lock.
+type LockOptions struct {
+	Mode   LockMode // exclusive if empty
+	Reason string   // why, shown to those the lock keeps out
+	Shared bool     // keep the lock in shared storage
+}
+
+// WaitOptions control WaitLock.
+type WaitOptions struct {
+	LockOptions
+	// Interval between polls; DefaultWaitInterval if zero.
+	Interval time.Duration
+	// Refresh runs before each poll, and Publish after each change to the
+	// lock, so shared locks can be pulled from and pushed to a remote.
+	Refresh func() error
+	Publish func() error
+	// Waiting is told why each poll failed to get the lock.
+	Waiting func(err error)
+}
+
+// Lock takes an exclusive lock on a task ID or file path. A live lock
+// held by anyone else is ErrLocked, as is a lapsed lock with others
+// queued for it, or someone else's lock on an overlapping path: a
+// directory above it or a file inside it. Locking a target you already
+// hold renews the lock. It returns where the lock is kept: a live lock,
+// or a lapsed one with a queue, stays where it is whatever shared says.
+func (c *Client) Lock(ctx context.Context, target string, shared bool) (*Lock, Location, error) {
+	return c.LockWith(ctx, target, LockOptions{Shared: shared})
+}
+
+// LockWith locks target as Lock does, with options. Any number of agents
+// can hold a shared-mode lock at once, but none while someone holds it
+// exclusively, and a shared-mode lock keeps out exclusive ones. The sole
+// holder of a shared-mode lock can make it exclusive. Agents that only
+// read the target should use shared mode so they do not block each
+// other. Shared-mode locks on overlapping paths do not conflict either.
+func (c *Client) LockWith(ctx context.Context, target string, opts LockOptions) (*Lock, Location, error) {
+	l, loc, _, err := c.tryLock(ctx, target, opts, false)
+	return l, loc, err
+}
+
+// WaitLock locks target as LockWith does, but if it is taken it joins
+// the lock's queue and polls until the lock is handed to it or lapses
+// with no one ahead. It gives up with ErrLocked when ctx is done,
+// leaving the queue. With a Refresh, a lock it gets is only reported
+// once a further refresh shows no other clone took it first.
+func (c *Client) WaitLock(ctx context.Context, target string, opts WaitOptions) (*Lock, Location, error) {
+	interval := opts.Interval
+	if interval <= 0 {
+		interval = DefaultWaitInterval
+	}
+
+	for {
+		if opts.Refresh != nil {
+			if err := opts.Refresh(); err != nil {
+				return nil, "", err
+			}
+		}
+
+		l, loc, changed, err := c.tryLock(ctx, target, opts.LockOptions, true)
+		if changed && opts.Publish != nil {
+			if perr := opts.Publish(); perr != nil && err == nil {
+				return l, loc, perr
+			}
+		}
+		if err == nil && opts.Refresh != nil {
+			l, loc, err = c.confirmLock(ctx, target, opts.Mode, opts.Refresh)
+		}
+		if !errors.Is(err, ErrLocked) {
+			return l, loc, err
+		}
+		if opts.Waiting != nil {
+			opts.Waiting(err)
+		}
+
+		select {
+		case <-ctx.Done():
+			if c.leaveQueue(target) && opts.Publish != nil {
+				opts.Publish()
+			}
+			return nil, "", fmt.Errorf("gave up waiting: %w", err)
+		case <-time.After(interval):
+		}
+	}
+}
+
+// confirmLock refreshes again after WaitLock got the lock, and checks
+// it is still ours: if another clone took it first, pulling gives it
+// back to the holder who acquired it first, and the wait goes on.
+func (c *Client) confirmLock(ctx context.Context, target string, mode LockMode, refresh func() error) (*Lock, Location, error) {
+	if mode == "" {
+		mode = LockExclusive
+	}
+	if err := refresh(); err != nil {
+		return nil, "", err
+	}
+	l, loc, err := c.findLock(ctx, target)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return nil, "", err
+	}
+	if err != nil || !isHeldAs(l, c.author, mode) {
+		return nil, "", fmt.Errorf("%w: %s was taken in another clone first", ErrLocked, target)
+	}
+	return l, loc, nil
+}
+
+// tryLock takes the lock on target if no one is ahead in its queue and
+// it is free, or in shared mode and wanted so. Otherwise it fails with
+// ErrLocked, after joining the queue if queue is set. When queueing, the
+// author already holding the lock in the wanted mode counts as success,
+// as the lock was handed to them; otherwise it renews the lock. It
+// reports where the lock is kept and whether it wrote the lock.
+//
+// The decision is made and written in one storage update, so two agents
+// racing for a free lock cannot both get it.
+func (c *Client) tryLock(ctx context.Context, target string, opts LockOptions, queue bool) (*Lock, Location, bool, error) {
+	mode := opts.Mode
+	if mode == "" {
+		mode = LockExclusive
+	}
+
+	// A live lock, or a lapsed one with a queue to carry over, stays
+	// where it is; otherwise the lock goes where asked
+	loc := locationOf(opts.Shared)
+	existing, at, err := c.lockRecord(ctx, target)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return nil, "", false, err
+	}
+	if err == nil {
+		existing.PruneQueue()
+		if !existing.IsExpired() || len(existing.Queue) > 0 {
+			loc = at
+		}
+	}
+	if err != nil || !isHeldAs(existing, c.author, mode) {
+		if err := c.checkNearby(ctx, target, mode); err != nil {
+			return nil, "", false, err
+		}
+	}
+
+	var (
+		result    *Lock
+		event     model.EventType
+		reason    string
+		lockedErr error
+	)
+	err = c.storage(loc).UpdateLock(target, func(l *Lock) (*Lock, error) {
+		result, lockedErr = nil, nil
+		event, reason = model.EventAcquired, opts.Reason
+
+		if l == nil {
+			l = model.NewLock(target, c.author)
+			l.Take(c.author, mode, opts.Reason)
+			c.renewLock(l)
+			result = l
+			return l, nil
+		}
+
+		held := !l.IsExpired()
+		mine := isHeldAs(l, c.author, mode)
+		if mine && queue {
+			result = l
+			return nil, errNoChange
+		}
+
+		l.PruneQueue()
+		first := len(l.Queue) == 0 || l.Queue[0].Agent == c.author
+		switch {
+		case mine:
+			l.Renew(opts.Reason)
+			event, reason = model.EventRenewed, l.Reason
+		case !held && first:
+			l.Take(c.author, mode, opts.Reason)
+		case held && first && l.IsShared() && mode == LockShared && !l.IsOwnedBy(c.author):
+			l.Join(c.author)
+		case held && first && l.IsShared() && mode == LockExclusive && !l.IsHeldByOthers(c.author):
+			// The sole reader upgrades
+			l.Take(c.author, mode, opts.Reason)
+		default:
+			lockedErr = lockedError(l, c.author)
+			if !queue {
+				return nil, lockedErr
+			}
+			pos := l.Enqueue(c.author, mode, opts.Reason)
+			lockedErr = fmt.Errorf("%w; %s", lockedErr, queuePosition(pos))
+			return l, nil
+		}
+		c.renewLock(l)
+		result = l
+		return l, nil
+	})
+	switch {
+	case errors.Is(err, errNoChange):
+		return result, loc, false, nil
+	case lockedErr != nil && err == lockedErr:
+		return nil, "", false, lockedErr
+	case err != nil:
+		return nil, "", false, fmt.Errorf("failed to lock: %w", err)
+	case lockedErr != nil:
+		// Queued
+		return nil, "", true, lockedErr
+	}
+	return result, loc, true, c.record(loc, result, event, c.author, reason)
+}
+
+// renewLock starts l's expiry afresh, to last as long as the lock.ttl
+// setting says.
+func (c *Client) renewLock(l *Lock) {
+	l.ExpiresAt = time.Now().UTC().Add(c.cfg.LockTTL)
+}
+
+// errNoChange stops a lock update that has nothing to write.
+var errNoChange = errors.New("no change")
+
+// isHeldAs reports whether l is live and held by agent in mode.
+func isHeldAs(l *Lock, agent string, mode LockMode) bool {
+	return !l.IsExpired() && l.IsOwnedBy(agent) && l.IsShared() == (mode == LockShared)
+}
+
+// checkNearby fails with ErrLocked if someone else holds a lock on a
+// path overlapping target, other than target itself, whose own lock
+// decides.
+func (c *Client) checkNearby(ctx context.Context, target string, mode LockMode) error {
+	locks, err := c.ListLocks(ctx, All)
+	if err != nil {
+		return err
+	}
+	var nearby []*Lock
+	for _, l := range locks {
+		if l.Target != target {
+			nearby = append(nearby, l)
+		}
+	}
+	return c.checkOverlap(nearby, target, mode)
+}
+
+// lockedError explains why agent cannot have l.
+func lockedError(l *Lock, agent string) error {
+	if l.IsExpired() {
+		return fmt.Errorf("%w: %s is next in the queue", ErrLocked, l.Queue[0].Agent)
+	}
+	detail := "expires: " + l.ExpiresAt.Format("15:04")
+	if !l.IsOwnedBy(agent) && len(l.Queue) > 0 {
+		// Readers are kept out too while a writer waits
+		detail = fmt.Sprintf("%d waiting, %s", len(l.Queue), detail)
+	}
+	if l.IsShared() {
+		detail = "shared, " + detail
+	}
+	err := fmt.Errorf("%w by %s (%s)", ErrLocked, strings.Join(l.HeldBy(), ", "), detail)
+	if l.Reason != "" {
+		err = fmt.Errorf("%w: %s", err, l.Reason)
+	}
+	return err
+}
+
+// leaveQueue removes the client's author from target's queue. It is best
+// effort and reports whether it changed anything.
+func (c *Client) leaveQueue(target string) bool {
+	_, loc, err := c.lockRecord(context.Background(), target)
+	if err != nil {
+		return false
+	}
+	err = c.storage(loc).UpdateLock(target, func(l *Lock) (*Lock, error) {
+		if l == nil || l.Position(c.author) < 0 {
+			return nil, errNoChange
+		}
+		l.Dequeue(c.author)
+		return l, nil
+	})
+	return err == nil
+}
+
+func queuePosition(pos int) string {
+	if pos == 0 {
+		return "you are next"
+	}
+	return fmt.Sprintf("%d ahead of you", pos)
+}
+
+// Unlock releases the client's author's hold on a lock and returns where
+// it was. The last holder to let go hands it to the next waiter, if
+// there is one.
+func (c *Client) Unlock(ctx context.Context, target string) (Location, error) {
+	_, loc, err := c.findLock(ctx, target)
+	if err != nil {
+		return "", err
+	}
+	err = c.release(loc, target, c.author)
+	if errors.Is(err, ErrNotOwner) || errors.Is(err, ErrNotFound) {
+		return "", err
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to unlock: %w", err)
+	}
+	return loc, nil
+}
+
+// release removes holder from the lock on target in loc. Once no one
+// holds it, it passes to its next waiter, or is deleted if no one waits.
+// A lock holder does not hold is ErrNotOwner.
+func (c *Client) release(loc Location, target, holder string) error {
+	var mode LockMode
+	var handed *Lock
+	err := c.storage(loc).UpdateLock(target, func(l *Lock) (*Lock, error) {
+		handed = nil
+		if l == nil {
+			return nil, notLockedError(target)
+		}
+		if !l.IsOwnedBy(holder) {
+			return nil, fmt.Errorf("%w (owner: %s)", ErrNotOwner, strings.Join(l.HeldBy(), ", "))
+		}
+		mode = l.Mode
+		if l.Leave(holder) {
+			return l, nil
+		}
+		if l.HandOff() {
+			c.renewLock(l)
+			handed = l
+			return l, nil
+		}
+		return nil, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := c.recordRelease(loc, target, mode, holder); err != nil {
+		return err
+	}
+	if handed != nil {
+		return c.recordHandOff(loc, handed)
+	}
+	return nil
+}
+
+// GetLock returns the live lock on target.
+func (c *Client) GetLock(ctx context.Context, target string) (*Lock, error) {
+	l, _, err := c.findLock(ctx, target)
+	return l, err
+}
+
+// ListLocks returns the live locks in a location.
+func (c *Client) ListLocks(ctx context.Context, loc Location) ([]*Lock, error) {
+	var result []*Lock
+	for _, s := range c.storages(loc) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		locks, err := s.ListLocks()
+		if err != nil {
+			return nil, fmt.Errorf("failed to list %s locks: %w", s.loc, err)
+		}
+		for _, l := range locks {
+			if !l.IsExpired() {
+				result = append(result, l)
+			}
+		}
+	}
+	return result, nil
+}
+
+// lockRecord returns the lock on target even if it has lapsed, since a
+// lapsed lock may still hold a queue. A live lock wins over a lapsed one.
+func (c *Client) lockRecord(ctx context.Context, target string) (*Lock, Location, error) {
+	l, loc, err := c.findLock(ctx, target)
+	if !errors.Is(err, ErrNotFound) {
+		return l, loc, err
+	}
+	for _, s := range c.storages(All) {
+		l, err := s.ReadLock(target)
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to read lock on %s: %w", target, err)
+		}
+		return l, s.loc, nil
+	}
+	return nil, "", notLockedError(target)
+}
+
+func (c *Client) findLock(ctx context.Context, target string) (*Lock, Location, error) {
+	for _, s := range c.storages(All) {
+		if err := ctx.Err(); err != nil {
+			return nil, "", err
+		}
+		l, err := s.ReadLock(target)
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to read lock on %s: %w", target, err)
+		}
+		if !l.IsExpired() {
+			return l, s.loc, nil
+		}
+	}
+	return nil, "", notLockedError(target)
+}
+
+// notLockedError is ErrNotFound for locks, with the familiar message.
+type notLockedError string
+
+func (e notLockedError) Error() string { return "not locked: " + string(e) }
+
+func (e notLockedError) Is(target error) bool { return target == ErrNotFound }
@@ -0,0 +1,400 @@
+package gitctx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/user/git-context/internal/config"
+	"github.com/user/git-context/internal/model"
+	"github.com/user/git-context/internal/storage"
+)
+
+// newAgents returns a client per author, all on the same in-memory
+// storage, as agents sharing one clone would be.
+func newAgents(authors ...string) []*Client {
+	store := &storage.MultiStorage{
+		Local:  storage.NewInMemoryStorage(),
+		Shared: storage.NewInMemoryStorage(),
+	}
+	clients := make([]*Client, len(authors))
+	for i, a := range authors {
+		clients[i] = &Client{store: store, cfg: config.Default(), author: a}
+	}
+	return clients
+}
+
+// queue has c join the queue for target, expecting to wait.
+func queue(t *testing.T, c *Client, target string, mode LockMode) {
+	t.Helper()
+	_, _, _, err := c.tryLock(context.Background(), target, LockOptions{Mode: mode}, true)
+	if !errors.Is(err, ErrLocked) {
+		t.Fatalf("%s queueing for %s: got %v, want ErrLocked", c.author, target, err)
+	}
+}
+
+// holders returns who holds the live lock on target.
+func holders(t *testing.T, c *Client, target string) []string {
+	t.Helper()
+	l, err := c.GetLock(context.Background(), target)
+	if err != nil {
+		t.Fatalf("GetLock %s: %v", target, err)
+	}
+	return l.HeldBy()
+}
+
+func assertHolders(t *testing.T, c *Client, target string, want ...string) {
+	t.Helper()
+	got := holders(t, c, target)
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("%s held by %v, want %v", target, got, want)
+	}
+}
+
+func unlock(t *testing.T, c *Client, target string) {
+	t.Helper()
+	if _, err := c.Unlock(context.Background(), target); err != nil {
+		t.Fatalf("%s unlocking %s: %v", c.author, target, err)
+	}
+}
+
+func TestLockQueueIsFIFO(t *testing.T) {
+	ctx := context.Background()
+	agents := newAgents("alice", "bob", "carol", "dave")
+	alice, bob, carol, dave := agents[0], agents[1], agents[2], agents[3]
+
+	if _, _, err := alice.Lock(ctx, "src/a.go", false); err != nil {
+		t.Fatal(err)
+	}
+	queue(t, bob, "src/a.go", LockExclusive)
+	queue(t, carol, "src/a.go", LockExclusive)
+	queue(t, dave, "src/a.go", LockExclusive)
+
+	// Someone not queued cannot jump ahead, even once the lock is free
+	unlock(t, alice, "src/a.go")
+	assertHolders(t, alice, "src/a.go", "bob")
+	if _, _, err := alice.Lock(ctx, "src/a.go", false); !errors.Is(err, ErrLocked) {
+		t.Errorf("lock by alice after handoff: got %v, want ErrLocked", err)
+	}
+
+	unlock(t, bob, "src/a.go")
+	assertHolders(t, bob, "src/a.go", "carol")
+	unlock(t, carol, "src/a.go")
+	assertHolders(t, carol, "src/a.go", "dave")
+
+	unlock(t, dave, "src/a.go")
+	if _, err := dave.GetLock(ctx, "src/a.go"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("lock after last unlock: got %v, want ErrNotFound", err)
+	}
+}
+
+func TestLockSharedAndExclusive(t *testing.T) {
+	ctx := context.Background()
+	agents := newAgents("alice", "bob", "carol")
+	alice, bob, carol := agents[0], agents[1], agents[2]
+	shared := LockOptions{Mode: LockShared}
+
+	// Readers share
+	if _, _, err := alice.LockWith(ctx, "src/", shared); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := bob.LockWith(ctx, "src/", shared); err != nil {
+		t.Fatalf("second reader: %v", err)
+	}
+	assertHolders(t, alice, "src/", "alice", "bob")
+
+	// A writer is kept out, and so is a reader of a path inside
+	if _, _, err := carol.Lock(ctx, "src/", false); !errors.Is(err, ErrLocked) {
+		t.Errorf("writer among readers: got %v, want ErrLocked", err)
+	}
+	if _, _, err := carol.Lock(ctx, "src/a.go", false); !errors.Is(err, ErrLocked) {
+		t.Errorf("writer inside a read-locked directory: got %v, want ErrLocked", err)
+	}
+	if _, _, err := carol.LockWith(ctx, "src/a.go", shared); err != nil {
+		t.Errorf("reader inside a read-locked directory: %v", err)
+	}
+	unlock(t, carol, "src/a.go")
+
+	// A reader cannot upgrade while others read; the sole reader can
+	if _, _, err := alice.Lock(ctx, "src/", false); !errors.Is(err, ErrLocked) {
+		t.Errorf("upgrade with another reader: got %v, want ErrLocked", err)
+	}
+	unlock(t, bob, "src/")
+	if _, _, err := alice.Lock(ctx, "src/", false); err != nil {
+		t.Errorf("upgrade by the sole reader: %v", err)
+	}
+	assertHolders(t, alice, "src/", "alice")
+
+	// Readers are kept out of an exclusive lock
+	if _, _, err := bob.LockWith(ctx, "src/", shared); !errors.Is(err, ErrLocked) {
+		t.Errorf("reader of an exclusive lock: got %v, want ErrLocked", err)
+	}
+}
+
+func TestUnlockHandsOffToQueuedReaders(t *testing.T) {
+	ctx := context.Background()
+	agents := newAgents("alice", "bob", "carol", "dave")
+	alice, bob, carol, dave := agents[0], agents[1], agents[2], agents[3]
+
+	if _, _, err := alice.Lock(ctx, "src/", false); err != nil {
+		t.Fatal(err)
+	}
+	queue(t, bob, "src/", LockShared)
+	queue(t, carol, "src/", LockShared)
+	queue(t, dave, "src/", LockExclusive)
+
+	// The readers at the front get it together; the writer waits
+	unlock(t, alice, "src/")
+	assertHolders(t, alice, "src/", "bob", "carol")
+
+	unlock(t, bob, "src/")
+	assertHolders(t, alice, "src/", "carol")
+	unlock(t, carol, "src/")
+	assertHolders(t, alice, "src/", "dave")
+
+	// A handed-off waiter polling again finds the lock is theirs
+	l, _, changed, err := dave.tryLock(ctx, "src/", LockOptions{}, true)
+	if err != nil || l == nil || changed {
+		t.Errorf("poll after handoff = %v, %v, %v; want the lock, unchanged", l, changed, err)
+	}
+}
+
+func TestWaiterTimeout(t *testing.T) {
+	ctx := context.Background()
+	agents := newAgents("alice", "bob", "carol")
+	alice, bob, carol := agents[0], agents[1], agents[2]
+
+	if _, _, err := alice.Lock(ctx, "src/a.go", false); err != nil {
+		t.Fatal(err)
+	}
+
+	// WaitLock gives up when its context ends, leaving the queue
+	waitCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	_, _, err := bob.WaitLock(waitCtx, "src/a.go", WaitOptions{Interval: 10 * time.Millisecond})
+	if !errors.Is(err, ErrLocked) {
+		t.Fatalf("WaitLock after timeout: got %v, want ErrLocked", err)
+	}
+	l, err := alice.GetLock(ctx, "src/a.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l.Position("bob") >= 0 {
+		t.Errorf("bob still queued after giving up: %+v", l.Queue)
+	}
+
+	// A waiter that stops polling is skipped at handoff
+	old := model.WaiterTimeout
+	model.WaiterTimeout = 20 * time.Millisecond
+	defer func() { model.WaiterTimeout = old }()
+
+	queue(t, carol, "src/a.go", LockExclusive)
+	time.Sleep(40 * time.Millisecond)
+	unlock(t, alice, "src/a.go")
+	if _, err := alice.GetLock(ctx, "src/a.go"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("lock after unlock with a lapsed waiter: got %v, want ErrNotFound", err)
+	}
+}
+
+func TestWaitLockConfirmsAfterRefresh(t *testing.T) {
+	ctx := context.Background()
+	bob := newAgents("bob")[0]
+
+	// The lock is free here, but the next pull brings in alice's lock,
+	// taken earlier in another clone
+	refreshes := 0
+	opts := WaitOptions{
+		Interval: 10 * time.Millisecond,
+		Refresh: func() error {
+			refreshes++
+			if refreshes == 2 {
+				return bob.store.Local.WriteLock(model.NewLock("src/a.go", "alice"))
+			}
+			return nil
+		},
+	}
+	waitCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	if l, _, err := bob.WaitLock(waitCtx, "src/a.go", opts); !errors.Is(err, ErrLocked) {
+		t.Fatalf("WaitLock = %+v, %v; want ErrLocked once the refresh shows alice's lock", l, err)
+	}
+	assertHolders(t, bob, "src/a.go", "alice")
+}
+
+func TestLockRace(t *testing.T) {
+	const n = 8
+	authors := make([]string, n)
+	for i := range authors {
+		authors[i] = fmt.Sprintf("agent-%d", i)
+	}
+	agents := newAgents(authors...)
+
+	var wg sync.WaitGroup
+	results := make(chan error, n)
+	for _, c := range agents {
+		c := c
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _, err := c.Lock(context.Background(), "src/a.go", false)
+			results <- err
+		}()
+	}
+	wg.Wait()
+	close(results)
+
+	won := 0
+	for err := range results {
+		switch {
+		case err == nil:
+			won++
+		case !errors.Is(err, ErrLocked):
+			t.Errorf("Lock: %v", err)
+		}
+	}
+	if won != 1 {
+		t.Errorf("%d agents got the lock, want 1", won)
+	}
+}
+
+func TestClaimRace(t *testing.T) {
+	const n = 8
+	authors := make([]string, n)
+	for i := range authors {
+		authors[i] = fmt.Sprintf("agent-%d", i)
+	}
+	agents := newAgents(authors...)
+	task, err := agents[0].AddTask(context.Background(), "contended", TaskOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	results := make(chan error, n)
+	for _, c := range agents {
+		c := c
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := c.ClaimTask(context.Background(), task.ID)
+			results <- err
+		}()
+	}
+	wg.Wait()
+	close(results)
+
+	won := 0
+	for err := range results {
+		switch {
+		case err == nil:
+			won++
+		case !errors.Is(err, ErrAlreadyClaimed):
+			t.Errorf("ClaimTask: %v", err)
+		}
+	}
+	if won != 1 {
+		t.Errorf("%d agents claimed the task, want 1", won)
+	}
+}
+
+func TestAssignAndMoveLockPaths(t *testing.T) {
+	ctx := context.Background()
+	agents := newAgents("alice", "bob", "carol")
+	alice, bob, carol := agents[0], agents[1], agents[2]
+	task, err := alice.ClaimTaskAndLock(ctx, mustAddTask(t, alice), []string{"src/a.go"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := alice.DropTask(ctx, task.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	// Someone else's lock keeps the task from being assigned or claimed
+	if _, _, err := carol.Lock(ctx, "src", false); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := alice.AssignTask(ctx, task.ID, "bob"); !errors.Is(err, ErrLocked) {
+		t.Errorf("AssignTask over carol's lock: got %v, want ErrLocked", err)
+	}
+	if _, err := bob.MoveTask(ctx, task.ID, TaskClaimed); !errors.Is(err, ErrLocked) {
+		t.Errorf("MoveTask over carol's lock: got %v, want ErrLocked", err)
+	}
+	if got, _ := alice.GetTask(ctx, task.ID); got.Owner != "" {
+		t.Errorf("task owned by %q after failed claims, want no owner", got.Owner)
+	}
+	unlock(t, carol, "src")
+
+	// Assigning locks the paths for the assignee, and reassigning passes
+	// them on
+	if _, err := alice.AssignTask(ctx, task.ID, "bob"); err != nil {
+		t.Fatal(err)
+	}
+	assertHolders(t, alice, "src/a.go", "bob")
+	if _, err := alice.AssignTask(ctx, task.ID, "carol"); err != nil {
+		t.Fatal(err)
+	}
+	assertHolders(t, alice, "src/a.go", "carol")
+
+	if _, err := carol.MoveTask(ctx, task.ID, TaskOpen); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := bob.MoveTask(ctx, task.ID, TaskClaimed); err != nil {
+		t.Fatal(err)
+	}
+	assertHolders(t, alice, "src/a.go", "bob")
+}
+
+func mustAddTask(t *testing.T, c *Client) string {
+	t.Helper()
+	task, err := c.AddTask(context.Background(), "edit a.go", TaskOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return task.ID
+}
+
+func TestClientFollowsItsConfig(t *testing.T) {
+	ctx := context.Background()
+	c := newAgents("alice")[0]
+	cfg := *c.cfg
+	cfg.LockTTL, cfg.ClaimLease, cfg.IDLength = time.Minute, 2*time.Minute, 12
+	c.cfg = &cfg
+
+	l, _, err := c.Lock(ctx, "src/a.go", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ttl := time.Until(l.ExpiresAt); ttl <= 0 || ttl > time.Minute {
+		t.Errorf("lock expires in %s, want lock.ttl of 1m", ttl)
+	}
+
+	task, err := c.AddTask(ctx, "edit a.go", TaskOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(task.ID) != len("task-")+12 {
+		t.Errorf("task ID %s, want 12 hex digits", task.ID)
+	}
+	task, err = c.ClaimTask(ctx, task.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lease := time.Until(*task.LeaseExpiresAt); lease <= time.Minute || lease > 2*time.Minute {
+		t.Errorf("claim lapses in %s, want task.lease of 2m", lease)
+	}
+}
+
+func TestLockReportsWhereItIsKept(t *testing.T) {
+	ctx := context.Background()
+	alice := newAgents("alice")[0]
+	if _, loc, err := alice.Lock(ctx, "src/a.go", true); err != nil || loc != Shared {
+		t.Fatalf("Lock shared: got %q, %v; want shared", loc, err)
+	}
+	// Renewing without --shared leaves the live lock where it is
+	if _, loc, err := alice.Lock(ctx, "src/a.go", false); err != nil || loc != Shared {
+		t.Errorf("renewing a shared lock: got %q, %v; want shared", loc, err)
+	}
+}
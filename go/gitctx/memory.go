@@ -0,0 +1,149 @@
+package gitctx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/user/git-context/internal/model"
+)
+
+// MemoryOptions are the optional fields of a new memory.
+type MemoryOptions struct {
+	Kind   MemoryKind
+	Tags   []string
+	Shared bool
+}
+
+// ListOptions select which entries to list.
+type ListOptions struct {
+	Location        Location
+	IncludeArchived bool
+}
+
+// AddMemory creates a memory.
+func (c *Client) AddMemory(ctx context.Context, title, content string, opts MemoryOptions) (*Memory, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if title == "" {
+		return nil, fmt.Errorf("title is required")
+	}
+
+	m := model.NewMemory(title, content, c.author, opts.Shared)
+	m.ID = c.newID()
+	if opts.Kind != "" {
+		m.Kind = opts.Kind
+	}
+	m.Tags = opts.Tags
+
+	if err := c.CreateMemory(ctx, m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// CreateMemory saves a memory the caller has filled in, such as one
+// edited as front matter. m.Shared says where it goes.
+func (c *Client) CreateMemory(ctx context.Context, m *Memory) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if m.Title == "" {
+		return fmt.Errorf("title is required")
+	}
+	if err := c.storage(locationOf(m.Shared)).WriteMemory(m); err != nil {
+		return fmt.Errorf("failed to save: %w", err)
+	}
+	return nil
+}
+
+// GetMemory returns the memory with the given ID. Its Shared field says
+// where it was found.
+func (c *Client) GetMemory(ctx context.Context, id string) (*Memory, error) {
+	m, _, err := c.findMemory(ctx, id)
+	return m, err
+}
+
+// ListMemories returns memories, leaving out archived ones unless asked.
+func (c *Client) ListMemories(ctx context.Context, opts ListOptions) ([]*Memory, error) {
+	return c.SearchMemories(ctx, "", opts)
+}
+
+// SearchMemories returns memories whose title or content contains
+// query. An empty query matches everything.
+func (c *Client) SearchMemories(ctx context.Context, query string, opts ListOptions) ([]*Memory, error) {
+	var result []*Memory
+	for _, s := range c.storages(opts.Location) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		memories, err := s.ListMemories()
+		if err != nil {
+			return nil, fmt.Errorf("failed to list %s memories: %w", s.loc, err)
+		}
+		for _, m := range memories {
+			if m.IsArchived() && !opts.IncludeArchived {
+				continue
+			}
+			if query != "" && !m.MatchesSearch(query) {
+				continue
+			}
+			m.Shared = s.loc == Shared
+			result = append(result, m)
+		}
+	}
+	return result, nil
+}
+
+// UpdateMemory applies fn to a memory and saves it as an edit by the
+// client's author. Nothing is saved if fn returns an error.
+func (c *Client) UpdateMemory(ctx context.Context, id string, fn func(*Memory) error) (*Memory, error) {
+	m, loc, err := c.findMemory(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := fn(m); err != nil {
+		return nil, err
+	}
+	m.Touch(c.author)
+
+	if err := c.storage(loc).WriteMemory(m); err != nil {
+		return nil, fmt.Errorf("failed to save: %w", err)
+	}
+	return m, nil
+}
+
+// DeleteMemory removes a memory.
+func (c *Client) DeleteMemory(ctx context.Context, id string) error {
+	_, loc, err := c.findMemory(ctx, id)
+	if err != nil {
+		return err
+	}
+	return c.storage(loc).DeleteMemory(id)
+}
+
+func (c *Client) findMemory(ctx context.Context, id string) (*Memory, Location, error) {
+	for _, s := range c.storages(All) {
+		if err := ctx.Err(); err != nil {
+			return nil, "", err
+		}
+		m, err := s.ReadMemory(id)
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to read %s: %w", id, err)
+		}
+		m.Shared = s.loc == Shared
+		return m, s.loc, nil
+	}
+	return nil, "", notFound(id)
+}
+
+func locationOf(shared bool) Location {
+	if shared {
+		return Shared
+	}
+	return Local
+}
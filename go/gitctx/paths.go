@@ -0,0 +1,251 @@
+package gitctx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/user/git-context/internal/model"
+)
+
+// ClaimTaskAndLock claims a task and locks the paths it touches: its
+// Paths plus paths, which are added to them. It is all or nothing: if
+// someone else holds a lock overlapping any of the paths the claim fails
+// with ErrLocked, and locks taken before a failure are released. The
+// locks are released again when the task is dropped or finished.
+func (c *Client) ClaimTaskAndLock(ctx context.Context, id string, paths []string) (*Task, error) {
+	w, err := c.Workflow(ctx)
+	if err != nil {
+		return nil, err
+	}
+	t, loc, err := c.findTask(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.checkClaim(w, t); err != nil {
+		return nil, err
+	}
+
+	// A lapsed claim's locks go with it
+	if t.IsStale() {
+		if err := c.releasePaths(ctx, t.Paths, t.Owner); err != nil {
+			return nil, err
+		}
+	}
+
+	all := mergePaths(t.Paths, paths)
+	acquired, err := c.lockTaskPaths(ctx, t.ID, all, "", loc == Shared)
+	if err != nil {
+		return nil, err
+	}
+
+	claimed, err := c.UpdateTask(ctx, id, func(t *Task) error {
+		if err := c.claim(w, t); err != nil {
+			return err
+		}
+		t.Paths = all
+		return nil
+	})
+	if err != nil {
+		c.unlockAcquired(acquired)
+		return nil, err
+	}
+	return claimed, nil
+}
+
+// acquiredLock is a lock taken by lockPaths, to undo on failure.
+type acquiredLock struct {
+	target string
+	loc    Location
+}
+
+// lockTaskPaths checks and then locks paths for task id before the
+// client's author takes it, and returns the locks it took. It is all or
+// nothing: locks taken before a failure are released. Locks from, the
+// task's previous owner, holds on the paths are left for transferPaths
+// once the task has changed hands.
+func (c *Client) lockTaskPaths(ctx context.Context, id string, paths []string, from string, shared bool) ([]acquiredLock, error) {
+	if err := c.checkPaths(ctx, paths, from); err != nil {
+		return nil, err
+	}
+	acquired, err := c.lockPaths(ctx, id, paths, from, shared)
+	if err != nil {
+		c.unlockAcquired(acquired)
+		return nil, err
+	}
+	return acquired, nil
+}
+
+// checkPaths fails with ErrLocked if anyone else holds a live lock
+// overlapping one of paths. Exclusive locks held by from do not count.
+func (c *Client) checkPaths(ctx context.Context, paths []string, from string) error {
+	if len(paths) == 0 {
+		return nil
+	}
+	all, err := c.ListLocks(ctx, All)
+	if err != nil {
+		return err
+	}
+	var locks []*Lock
+	for _, l := range all {
+		if !isPassedOn(l, from) {
+			locks = append(locks, l)
+		}
+	}
+	for _, p := range paths {
+		if err := c.checkOverlap(locks, p, LockExclusive); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkOverlap fails with ErrLocked if anyone else holds one of locks on
+// a path overlapping target, unless both that lock and mode are shared.
+func (c *Client) checkOverlap(locks []*Lock, target string, mode LockMode) error {
+	for _, l := range locks {
+		if !l.IsHeldByOthers(c.author) || !model.PathsOverlap(l.Target, target) {
+			continue
+		}
+		if mode == LockShared && l.IsShared() {
+			continue
+		}
+		return fmt.Errorf("%w: %s overlaps %s, locked by %s (expires: %s)", ErrLocked,
+			target, l.Target, strings.Join(l.HeldBy(), ", "), l.ExpiresAt.Format("15:04"))
+	}
+	return nil
+}
+
+// isPassedOn reports whether l is a live exclusive lock held by from,
+// which transferPaths passes to a task's new owner.
+func isPassedOn(l *Lock, from string) bool {
+	return from != "" && l.LockedBy == from && !l.IsShared() && !l.IsExpired()
+}
+
+// lockPaths locks each path exclusively for task id, unless the client's
+// author already holds it so or it is from's to pass on, and returns the
+// locks it took.
+func (c *Client) lockPaths(ctx context.Context, id string, paths []string, from string, shared bool) ([]acquiredLock, error) {
+	var acquired []acquiredLock
+	for _, p := range paths {
+		existing, _, err := c.findLock(ctx, p)
+		if err == nil && (existing.IsOwnedBy(c.author) && !existing.IsShared() || isPassedOn(existing, from)) {
+			continue
+		}
+		if err != nil && !errors.Is(err, ErrNotFound) {
+			return acquired, err
+		}
+		_, loc, err := c.LockWith(ctx, p, LockOptions{Reason: "working on " + id, Shared: shared})
+		if err != nil {
+			return acquired, err
+		}
+		acquired = append(acquired, acquiredLock{p, loc})
+	}
+	return acquired, nil
+}
+
+// unlockAcquired rolls back locks taken by lockPaths, handing them on to
+// anyone who queued meanwhile. It is best effort: a lock it cannot
+// release still expires.
+func (c *Client) unlockAcquired(acquired []acquiredLock) {
+	for _, a := range acquired {
+		c.release(a.loc, a.target, c.author)
+	}
+}
+
+// releasePaths releases owner's locks on paths.
+func (c *Client) releasePaths(ctx context.Context, paths []string, owner string) error {
+	for _, p := range paths {
+		l, loc, err := c.findLock(ctx, p)
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if !l.IsOwnedBy(owner) {
+			continue
+		}
+		// It may have been released or broken meanwhile
+		err = c.release(loc, p, owner)
+		if err != nil && !errors.Is(err, ErrNotFound) && !errors.Is(err, ErrNotOwner) {
+			return fmt.Errorf("failed to release lock on %s: %w", p, err)
+		}
+	}
+	return nil
+}
+
+// transferPaths passes from's exclusive locks on paths to to, keeping
+// anyone queued for them waiting.
+func (c *Client) transferPaths(ctx context.Context, paths []string, from, to string) error {
+	for _, p := range paths {
+		_, loc, err := c.findLock(ctx, p)
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		var l *Lock
+		err = c.storage(loc).UpdateLock(p, func(current *Lock) (*Lock, error) {
+			if current == nil || current.IsExpired() || current.IsShared() || current.LockedBy != from {
+				return nil, errNoChange
+			}
+			current.Take(to, LockExclusive, current.Reason)
+			c.renewLock(current)
+			l = current
+			return current, nil
+		})
+		if errors.Is(err, errNoChange) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to pass lock on %s to %s: %w", p, to, err)
+		}
+		if err := c.recordRelease(loc, p, LockExclusive, from); err != nil {
+			return err
+		}
+		if err := c.record(loc, l, model.EventAcquired, to, l.Reason); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// releaseTask releases owner's locks on a task's paths once the task is
+// no longer being worked on: back to open, or finished.
+func (c *Client) releaseTask(ctx context.Context, t *Task, owner string) (*Task, error) {
+	if len(t.Paths) == 0 || owner == "" {
+		return t, nil
+	}
+	w, err := c.Workflow(ctx)
+	if err != nil {
+		return t, err
+	}
+	if t.Status != TaskOpen && !w.IsDone(t.Status) {
+		return t, nil
+	}
+	if err := c.releasePaths(ctx, t.Paths, owner); err != nil {
+		return t, fmt.Errorf("%s updated, but %w", t.ID, err)
+	}
+	return t, nil
+}
+
+// mergePaths returns a followed by the paths of b it does not have.
+func mergePaths(a, b []string) []string {
+	result := append([]string(nil), a...)
+	for _, p := range b {
+		found := false
+		for _, q := range result {
+			if q == p {
+				found = true
+				break
+			}
+		}
+		if !found {
+			result = append(result, p)
+		}
+	}
+	return result
+}
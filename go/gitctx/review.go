@@ -0,0 +1,134 @@
+package gitctx
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// AssignTask gives a task to agent, claiming it on their behalf and
+// locking its paths for them, as ClaimTaskAndLock does. Tasks that are
+// already claimed are reassigned, and the previous owner's locks on the
+// task's paths pass to agent; finished ones cannot be.
+func (c *Client) AssignTask(ctx context.Context, id, agent string) (*Task, error) {
+	if strings.TrimSpace(agent) == "" {
+		return nil, fmt.Errorf("agent is required")
+	}
+	w, err := c.Workflow(ctx)
+	if err != nil {
+		return nil, err
+	}
+	t, loc, err := c.findTask(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if t.Status != TaskClaimed {
+		if err := checkMove(w, t, TaskClaimed); err != nil {
+			return nil, err
+		}
+	}
+
+	as := c.WithAuthor(agent)
+	acquired, err := as.lockTaskPaths(ctx, t.ID, t.Paths, t.Owner, loc == Shared)
+	if err != nil {
+		return nil, err
+	}
+
+	var owner string
+	t, err = c.UpdateTask(ctx, id, func(t *Task) error {
+		if t.Status != TaskClaimed {
+			if err := checkMove(w, t, TaskClaimed); err != nil {
+				return err
+			}
+		}
+		owner = t.Owner
+		t.Assign(agent, c.author)
+		return nil
+	})
+	if err != nil {
+		as.unlockAcquired(acquired)
+		return nil, err
+	}
+	if owner == "" || owner == agent {
+		return t, nil
+	}
+	if err := c.transferPaths(ctx, t.Paths, owner, agent); err != nil {
+		return t, fmt.Errorf("%s assigned, but %w", id, err)
+	}
+	return t, nil
+}
+
+// RequestReview moves a task the client's author owns into review by
+// reviewer. A task in review without a reviewer, as older versions
+// allowed, gets one.
+func (c *Client) RequestReview(ctx context.Context, id, reviewer string) (*Task, error) {
+	if strings.TrimSpace(reviewer) == "" {
+		return nil, fmt.Errorf("reviewer is required")
+	}
+	w, err := c.Workflow(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if w.Status(TaskReview) == nil {
+		return nil, fmt.Errorf("the workflow has no %s status", TaskReview)
+	}
+
+	return c.UpdateTask(ctx, id, func(t *Task) error {
+		if t.Owner != c.author {
+			return fmt.Errorf("%w (owner: %s)", ErrNotOwner, t.Owner)
+		}
+		if t.Status != TaskReview || t.Reviewer != "" {
+			if err := checkWorkflow(w, t, TaskReview); err != nil {
+				return err
+			}
+		}
+		t.RequestReview(reviewer, c.author)
+		return nil
+	})
+}
+
+// ApproveTask accepts a task in review and marks it done. Only its
+// reviewer can approve it, so a task without one cannot be, and the
+// comment saying why is required.
+func (c *Client) ApproveTask(ctx context.Context, id, comment string) (*Task, error) {
+	return c.review(ctx, id, comment, "Approved", TaskDone, (*Task).Approve)
+}
+
+// RejectTask sends a task in review back to its owner. Only its
+// reviewer can reject it, and the comment saying why is required.
+func (c *Client) RejectTask(ctx context.Context, id, comment string) (*Task, error) {
+	return c.review(ctx, id, comment, "Changes requested", TaskClaimed, (*Task).Reject)
+}
+
+// review concludes a review with a verdict comment.
+func (c *Client) review(ctx context.Context, id, comment, verdict string, to TaskStatus, conclude func(*Task, string)) (*Task, error) {
+	if strings.TrimSpace(comment) == "" {
+		return nil, fmt.Errorf("comment is required")
+	}
+	w, err := c.Workflow(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	t, err := c.UpdateTask(ctx, id, func(t *Task) error {
+		if t.Status != TaskReview {
+			return fmt.Errorf("%w: %s is %s, not in review", ErrNotAllowed, id, t.Status)
+		}
+		if t.Reviewer == "" {
+			return fmt.Errorf("%w: %s has no reviewer; its owner must request a review", ErrNotReviewer, id)
+		}
+		if t.Reviewer != c.author {
+			return fmt.Errorf("%w (reviewer: %s)", ErrNotReviewer, t.Reviewer)
+		}
+		if err := checkWorkflow(w, t, to); err != nil {
+			return err
+		}
+		conclude(t, c.author)
+		t.AddComment(c.author, verdict+": "+comment)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return c.releaseTask(ctx, t, t.Owner)
+}
@@ -0,0 +1,76 @@
+package gitctx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Share moves a local memory or task to shared storage. The ID stays
+// the same, so links to it keep working.
+func (c *Client) Share(ctx context.Context, id string) error {
+	return c.move(ctx, id, Local, Shared)
+}
+
+// Unshare moves a shared memory or task back to local storage. After
+// the next push it is gone for everyone else; its history stays in the
+// shared refs.
+func (c *Client) Unshare(ctx context.Context, id string) error {
+	return c.move(ctx, id, Shared, Local)
+}
+
+// move copies an entry to the other storage, then deletes the original.
+// It refuses if the ID is already taken on the other side.
+func (c *Client) move(ctx context.Context, id string, fromLoc, toLoc Location) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	from, to := c.storage(fromLoc), c.storage(toLoc)
+
+	if strings.HasPrefix(id, "task-") {
+		t, err := from.ReadTask(id)
+		if errors.Is(err, ErrNotFound) {
+			if _, err := to.ReadTask(id); err == nil {
+				return fmt.Errorf("%s is already %s", id, toLoc)
+			}
+		}
+		if err != nil {
+			return err
+		}
+		if _, err := to.ReadTask(id); err == nil {
+			return fmt.Errorf("%w: %s in %s storage", ErrExists, id, toLoc)
+		}
+
+		t.Shared = toLoc == Shared
+		if err := to.WriteTask(t); err != nil {
+			return fmt.Errorf("failed to write %s task: %w", toLoc, err)
+		}
+		if err := from.DeleteTask(id); err != nil {
+			return fmt.Errorf("copied to %s but failed to remove %s copy: %w", toLoc, fromLoc, err)
+		}
+		return nil
+	}
+
+	m, err := from.ReadMemory(id)
+	if errors.Is(err, ErrNotFound) {
+		if _, err := to.ReadMemory(id); err == nil {
+			return fmt.Errorf("%s is already %s", id, toLoc)
+		}
+	}
+	if err != nil {
+		return err
+	}
+	if _, err := to.ReadMemory(id); err == nil {
+		return fmt.Errorf("%w: %s in %s storage", ErrExists, id, toLoc)
+	}
+
+	m.Shared = toLoc == Shared
+	if err := to.WriteMemory(m); err != nil {
+		return fmt.Errorf("failed to write %s entry: %w", toLoc, err)
+	}
+	if err := from.DeleteMemory(id); err != nil {
+		return fmt.Errorf("copied to %s but failed to remove %s copy: %w", toLoc, fromLoc, err)
+	}
+	return nil
+}
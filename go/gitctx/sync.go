@@ -0,0 +1,93 @@
+package gitctx
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/user/git-context/internal/storage"
+)
+
+// Sync results.
+type (
+	MergeResult     = storage.MergeResult
+	PushResult      = storage.PushResult
+	NamespaceStatus = storage.NamespaceStatus
+	EntryChange     = storage.EntryChange
+)
+
+// DefaultRemote is the remote used when none is configured.
+const DefaultRemote = storage.DefaultRemote
+
+// Fetch downloads a remote's shared context without merging it.
+func (c *Client) Fetch(ctx context.Context, remote string) error {
+	s, err := c.syncer(ctx)
+	if err != nil {
+		return err
+	}
+	return s.Fetch(remote)
+}
+
+// Pull fetches a remote's shared context and merges it: the newest
+// change to an entry wins, and task comments and links from both sides
+// are kept.
+func (c *Client) Pull(ctx context.Context, remote string) (*MergeResult, error) {
+	s, err := c.syncer(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.Pull(remote)
+}
+
+// Push sends shared context to a remote. Entries the remote has changed
+// since the last pull are rejected rather than overwritten.
+func (c *Client) Push(ctx context.Context, remote string) (*PushResult, error) {
+	s, err := c.syncer(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.Push(remote)
+}
+
+// SyncStatus compares shared context with a remote's, as of the last
+// fetch.
+func (c *Client) SyncStatus(ctx context.Context, remote string) ([]NamespaceStatus, error) {
+	s, err := c.syncer(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.SyncStatus(remote)
+}
+
+// CreateBundle writes shared context to a git bundle file. With since,
+// only changes made after that earlier bundle are included. It returns
+// the number of entries bundled; zero means no file was written.
+func (c *Client) CreateBundle(ctx context.Context, file, since string) (int, error) {
+	s, err := c.syncer(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return s.CreateBundle(file, since)
+}
+
+// ApplyBundle merges a bundle written by CreateBundle, the same way Pull
+// merges a remote.
+func (c *Client) ApplyBundle(ctx context.Context, file string) (*MergeResult, error) {
+	s, err := c.syncer(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.ApplyBundle(file)
+}
+
+// syncer returns the git-backed shared storage, the only kind that can
+// sync.
+func (c *Client) syncer(ctx context.Context) (*storage.SharedStorage, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s, ok := c.store.Shared.(*storage.SharedStorage)
+	if !ok {
+		return nil, fmt.Errorf("shared storage does not sync")
+	}
+	return s, nil
+}
@@ -0,0 +1,307 @@
+package gitctx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/user/git-context/internal/model"
+)
+
+// TaskOptions are the optional fields of a new task.
+type TaskOptions struct {
+	Description string
+	BlockedBy   []string
+	Shared      bool
+}
+
+// TaskFilter selects which tasks to list. Empty fields match all.
+type TaskFilter struct {
+	Location Location
+	// Status also matches open for a task whose claim has lapsed, since
+	// anyone can claim it.
+	Status TaskStatus
+	Owner  string
+}
+
+// AddTask creates an open task.
+func (c *Client) AddTask(ctx context.Context, title string, opts TaskOptions) (*Task, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if title == "" {
+		return nil, fmt.Errorf("title is required")
+	}
+
+	t := model.NewTask(title, opts.Description, c.author, opts.Shared)
+	t.ID = "task-" + c.newID()
+	t.BlockedBy = opts.BlockedBy
+
+	if err := c.storage(locationOf(opts.Shared)).WriteTask(t); err != nil {
+		return nil, fmt.Errorf("failed to create task: %w", err)
+	}
+	return t, nil
+}
+
+// GetTask returns the task with the given ID. Its Shared field says
+// where it was found.
+func (c *Client) GetTask(ctx context.Context, id string) (*Task, error) {
+	t, _, err := c.findTask(ctx, id)
+	return t, err
+}
+
+// ListTasks returns the tasks matching filter.
+func (c *Client) ListTasks(ctx context.Context, filter TaskFilter) ([]*Task, error) {
+	var result []*Task
+	for _, s := range c.storages(filter.Location) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		tasks, err := s.ListTasks()
+		if err != nil {
+			return nil, fmt.Errorf("failed to list %s tasks: %w", s.loc, err)
+		}
+		for _, t := range tasks {
+			if filter.Status != "" && !hasStatus(t, filter.Status) {
+				continue
+			}
+			if filter.Owner != "" && t.Owner != filter.Owner {
+				continue
+			}
+			t.Shared = s.loc == Shared
+			result = append(result, t)
+		}
+	}
+	return result, nil
+}
+
+// hasStatus reports whether t has status, counting a stale claim as
+// open too.
+func hasStatus(t *Task, status TaskStatus) bool {
+	return t.Status == status || (status == TaskOpen && t.IsStale())
+}
+
+// UpdateTask applies fn to a task and saves it. Nothing is saved if fn
+// returns an error. The task is read, changed and written in one storage
+// update, so fn's checks hold when it is saved: if another agent changes
+// the task meanwhile, fn runs again on their version. fn must therefore
+// only decide and change the task, not act.
+func (c *Client) UpdateTask(ctx context.Context, id string, fn func(*Task) error) (*Task, error) {
+	_, loc, err := c.findTask(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	// result is only set once fn has accepted the change, so an error
+	// with it set came from saving
+	var result *Task
+	err = c.storage(loc).UpdateTask(id, func(t *Task) error {
+		result = nil
+		t.Shared = loc == Shared
+		lease := t.LeaseExpiresAt
+		if err := fn(t); err != nil {
+			return err
+		}
+		if t.LeaseExpiresAt != lease && t.LeaseExpiresAt != nil {
+			c.renewLease(t)
+		}
+		result = t
+		return nil
+	})
+	if err != nil && result != nil {
+		return nil, fmt.Errorf("failed to save %s: %w", id, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// renewLease restarts a claim's lease from the task's last change, for
+// as long as the task.lease setting says.
+func (c *Client) renewLease(t *Task) {
+	expires := t.UpdatedAt.Add(c.cfg.ClaimLease)
+	t.LeaseExpiresAt = &expires
+}
+
+// ClaimTask takes ownership of a task and locks its Paths, as
+// ClaimTaskAndLock does. Claiming a task you already own is not an
+// error and renews the lease; one claimed by someone else is
+// ErrAlreadyClaimed, unless their lease has lapsed.
+func (c *Client) ClaimTask(ctx context.Context, id string) (*Task, error) {
+	return c.ClaimTaskAndLock(ctx, id, nil)
+}
+
+// checkClaim fails if someone else holds a live claim on t, or the
+// workflow does not let it be claimed.
+func (c *Client) checkClaim(w *Workflow, t *Task) error {
+	if t.Status != TaskClaimed {
+		return checkMove(w, t, TaskClaimed)
+	}
+	if t.Owner != c.author && !t.IsStale() {
+		return fmt.Errorf("%w by %s", ErrAlreadyClaimed, t.Owner)
+	}
+	return nil
+}
+
+// claim makes the client's author the owner of t, taking over a lapsed
+// claim.
+func (c *Client) claim(w *Workflow, t *Task) error {
+	if err := c.checkClaim(w, t); err != nil {
+		return err
+	}
+	if t.Status == TaskClaimed && t.Owner != c.author {
+		comment := staleComment(t)
+		t.Reap(c.author)
+		t.AddComment(c.author, comment)
+	}
+	t.Claim(c.author)
+	return nil
+}
+
+// HeartbeatTask renews the lease on a task the client's author has
+// claimed.
+func (c *Client) HeartbeatTask(ctx context.Context, id string) (*Task, error) {
+	return c.UpdateTask(ctx, id, func(t *Task) error {
+		if t.Status != TaskClaimed {
+			return fmt.Errorf("%w: %s is %s, not claimed", ErrConflict, id, t.Status)
+		}
+		if t.Owner != c.author {
+			return fmt.Errorf("%w (owner: %s)", ErrNotOwner, t.Owner)
+		}
+		t.Heartbeat()
+		return nil
+	})
+}
+
+// ReapTasks reopens every task whose claim has lapsed, with a comment
+// recording whose claim it was and when it expired, and releases the
+// lapsed owner's locks on its paths. It returns the reopened tasks.
+func (c *Client) ReapTasks(ctx context.Context, loc Location) ([]*Task, error) {
+	tasks, err := c.ListTasks(ctx, TaskFilter{Location: loc, Status: TaskClaimed})
+	if err != nil {
+		return nil, err
+	}
+
+	var reaped []*Task
+	for _, stale := range tasks {
+		if !stale.IsStale() {
+			continue
+		}
+		var owner string
+		t, err := c.UpdateTask(ctx, stale.ID, func(t *Task) error {
+			if !t.IsStale() {
+				return errRenewed
+			}
+			owner = t.Owner
+			comment := staleComment(t)
+			t.Reap(c.author)
+			t.AddComment(c.author, comment)
+			return nil
+		})
+		if errors.Is(err, errRenewed) {
+			continue
+		}
+		if err != nil {
+			return reaped, err
+		}
+		reaped = append(reaped, t)
+		if _, err := c.releaseTask(ctx, t, owner); err != nil {
+			return reaped, err
+		}
+	}
+	return reaped, nil
+}
+
+// errRenewed stops a reap when the lease was renewed meanwhile.
+var errRenewed = errors.New("lease renewed")
+
+// staleComment explains why a stale claim was released. Call it before
+// the claim is cleared.
+func staleComment(t *Task) string {
+	return fmt.Sprintf("Claim by %s expired %s without a heartbeat; reopened",
+		t.Owner, t.LeaseExpiresAt.Local().Format("2006-01-02 15:04"))
+}
+
+// DropTask releases a task the client's author owns, and their locks on
+// its paths.
+func (c *Client) DropTask(ctx context.Context, id string) (*Task, error) {
+	w, err := c.Workflow(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	t, err := c.UpdateTask(ctx, id, func(t *Task) error {
+		if t.Owner != c.author {
+			return fmt.Errorf("%w (owner: %s)", ErrNotOwner, t.Owner)
+		}
+		if err := checkMove(w, t, TaskOpen); err != nil {
+			return err
+		}
+		t.Drop(c.author)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return c.releaseTask(ctx, t, c.author)
+}
+
+// CompleteTask marks a task done and releases its owner's locks on its
+// paths. Tasks the workflow does not let move to done are ErrNotAllowed.
+func (c *Client) CompleteTask(ctx context.Context, id string) (*Task, error) {
+	w, err := c.Workflow(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	t, err := c.UpdateTask(ctx, id, func(t *Task) error {
+		if err := checkMove(w, t, TaskDone); err != nil {
+			return err
+		}
+		t.Done(c.author)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return c.releaseTask(ctx, t, t.Owner)
+}
+
+// CommentTask adds a comment to a task.
+func (c *Client) CommentTask(ctx context.Context, id, content string) (*Task, error) {
+	if content == "" {
+		return nil, fmt.Errorf("comment is empty")
+	}
+	return c.UpdateTask(ctx, id, func(t *Task) error {
+		t.AddComment(c.author, content)
+		return nil
+	})
+}
+
+// DeleteTask removes a task.
+func (c *Client) DeleteTask(ctx context.Context, id string) error {
+	_, loc, err := c.findTask(ctx, id)
+	if err != nil {
+		return err
+	}
+	return c.storage(loc).DeleteTask(id)
+}
+
+func (c *Client) findTask(ctx context.Context, id string) (*Task, Location, error) {
+	for _, s := range c.storages(All) {
+		if err := ctx.Err(); err != nil {
+			return nil, "", err
+		}
+		t, err := s.ReadTask(id)
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to read %s: %w", id, err)
+		}
+		t.Shared = s.loc == Shared
+		return t, s.loc, nil
+	}
+	return nil, "", notFound(id)
+}
@@ -0,0 +1,74 @@
+package gitctx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/user/git-context/internal/model"
+)
+
+// Template returns the editor template for a memory kind and where it
+// came from. Local overrides win over shared ones, which win over the
+// built-in template; the location is empty for the built-in one.
+func (c *Client) Template(ctx context.Context, kind MemoryKind) (string, Location, error) {
+	kind, err := model.ParseKind(string(kind))
+	if err != nil {
+		return "", "", err
+	}
+	for _, s := range c.storages(All) {
+		if err := ctx.Err(); err != nil {
+			return "", "", err
+		}
+		text, err := s.ReadTemplate(string(kind))
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return "", "", fmt.Errorf("failed to read %s template: %w", s.loc, err)
+		}
+		if text != "" {
+			return text, s.loc, nil
+		}
+	}
+	return model.DefaultTemplates[kind], "", nil
+}
+
+// SetTemplate saves an override of the template for a kind. Templates
+// that do not render are refused, as are unknown kinds.
+func (c *Client) SetTemplate(ctx context.Context, kind MemoryKind, text string, loc Location) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	kind, err := model.ParseKind(string(kind))
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(text) == "" {
+		return fmt.Errorf("empty template")
+	}
+	if _, err := model.RenderTemplate(text, kind, "Title", "Author"); err != nil {
+		return err
+	}
+	if err := c.storage(loc).WriteTemplate(string(kind), text); err != nil {
+		return fmt.Errorf("failed to save: %w", err)
+	}
+	return nil
+}
+
+// ResetTemplate removes the override of the template for a kind.
+func (c *Client) ResetTemplate(ctx context.Context, kind MemoryKind, loc Location) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	kind, err := model.ParseKind(string(kind))
+	if err != nil {
+		return err
+	}
+	err = c.storage(loc).DeleteTemplate(string(kind))
+	if errors.Is(err, ErrNotFound) {
+		return fmt.Errorf("no override for %s: %w", kind, ErrNotFound)
+	}
+	return err
+}
@@ -0,0 +1,16 @@
+package gitctx
+
+import "github.com/user/git-context/internal/storage"
+
+// Watching for changes.
+type (
+	Watcher = storage.Watcher
+	Change  = storage.Change
+)
+
+// Watch returns a watcher that reports changes to local and shared
+// context made since the call, by this client, another process or a
+// pull.
+func (c *Client) Watch() (*Watcher, error) {
+	return storage.NewWatcher(c.store)
+}
@@ -0,0 +1,144 @@
+package gitctx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/user/git-context/internal/model"
+)
+
+// Workflow returns the task workflow, which is kept in shared storage
+// so the whole team uses the same one. Without one, it is the default:
+// open, claimed, review and done.
+func (c *Client) Workflow(ctx context.Context) (*Workflow, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	w, err := c.store.Shared.ReadWorkflow()
+	if errors.Is(err, ErrNotFound) {
+		return model.DefaultWorkflow(), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read workflow: %w", err)
+	}
+	return w, nil
+}
+
+// SetWorkflow validates and saves the task workflow.
+func (c *Client) SetWorkflow(ctx context.Context, w *Workflow) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := w.Validate(); err != nil {
+		return fmt.Errorf("invalid workflow: %w", err)
+	}
+	if err := c.store.Shared.WriteWorkflow(w); err != nil {
+		return fmt.Errorf("failed to save workflow: %w", err)
+	}
+	return nil
+}
+
+// ResetWorkflow goes back to the default workflow.
+func (c *Client) ResetWorkflow(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	err := c.store.Shared.DeleteWorkflow()
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return fmt.Errorf("failed to reset workflow: %w", err)
+	}
+	return nil
+}
+
+// MoveTask moves a task to another status of the workflow. Moves the
+// workflow does not allow are ErrNotAllowed, as are moves into and out
+// of review, which go through RequestReview and the reviewer's verdict.
+// Moving to claimed takes ownership and locks the task's paths, as
+// ClaimTaskAndLock does; moving to open or a done status releases the
+// owner's locks on them.
+func (c *Client) MoveTask(ctx context.Context, id string, to TaskStatus) (*Task, error) {
+	w, err := c.Workflow(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if w.Status(to) == nil {
+		return nil, fmt.Errorf("unknown status %s (workflow: %s)", to, statusList(w))
+	}
+
+	var acquired []acquiredLock
+	if to == TaskClaimed {
+		t, loc, err := c.findTask(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if t.Owner != "" && t.Owner != c.author {
+			return nil, fmt.Errorf("%w by %s", ErrAlreadyClaimed, t.Owner)
+		}
+		if t.Status != to {
+			if err := checkMove(w, t, to); err != nil {
+				return nil, err
+			}
+			acquired, err = c.lockTaskPaths(ctx, t.ID, t.Paths, "", loc == Shared)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	var owner string
+	t, err := c.UpdateTask(ctx, id, func(t *Task) error {
+		owner = t.Owner
+		if t.Status == to {
+			return fmt.Errorf("%s is already %s", id, to)
+		}
+		if err := checkMove(w, t, to); err != nil {
+			return err
+		}
+		if to == TaskClaimed {
+			if t.Owner != "" && t.Owner != c.author {
+				return fmt.Errorf("%w by %s", ErrAlreadyClaimed, t.Owner)
+			}
+			t.Owner = c.author
+		}
+		t.Move(to, c.author, w)
+		return nil
+	})
+	if err != nil {
+		c.unlockAcquired(acquired)
+		return nil, err
+	}
+	return c.releaseTask(ctx, t, owner)
+}
+
+// checkMove fails with ErrNotAllowed if t may not move to status to.
+// Review is gated: a task enters it only with a reviewer, through
+// RequestReview, and leaves it only by that reviewer's approval or
+// rejection.
+func checkMove(w *Workflow, t *Task, to TaskStatus) error {
+	if t.Status == TaskReview {
+		return fmt.Errorf("%w: %s is in review; only its reviewer can approve or reject it", ErrNotAllowed, t.ID)
+	}
+	if to == TaskReview {
+		return fmt.Errorf("%w: %s needs a reviewer to move to review; request a review instead", ErrNotAllowed, t.ID)
+	}
+	return checkWorkflow(w, t, to)
+}
+
+// checkWorkflow fails with ErrNotAllowed if the workflow does not let t
+// move to status to.
+func checkWorkflow(w *Workflow, t *Task, to TaskStatus) error {
+	if !w.CanMove(t.Status, to) {
+		return fmt.Errorf("%w: %s cannot move from %s to %s", ErrNotAllowed, t.ID, t.Status, to)
+	}
+	return nil
+}
+
+func statusList(w *Workflow) string {
+	names := make([]string, len(w.Statuses))
+	for i, s := range w.Statuses {
+		names[i] = string(s.Name)
+	}
+	return strings.Join(names, ", ")
+}
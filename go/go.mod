@@ -2,9 +2,15 @@ module github.com/user/git-context
 
 go 1.17
 
-require github.com/spf13/cobra v1.6.0
+require (
+	github.com/BurntSushi/toml v1.2.1
+	github.com/spf13/cobra v1.6.0
+	golang.org/x/term v0.7.0
+	gopkg.in/yaml.v3 v3.0.1
+)
 
 require (
 	github.com/inconshreveable/mousetrap v1.0.1 // indirect
 	github.com/spf13/pflag v1.0.5 // indirect
+	golang.org/x/sys v0.7.0 // indirect
 )
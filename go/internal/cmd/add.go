@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"context"
 	"bufio"
 	"fmt"
 	"io"
@@ -16,6 +17,7 @@ var (
 	addTitle   string
 	addMessage string
 	addTags    []string
+	addKind    string
 )
 
 var addCmd = &cobra.Command{
@@ -24,13 +26,19 @@ var addCmd = &cobra.Command{
 	Long: `Add a new context entry to git.
 
 Opens an editor if no --message is provided. Content can also be piped via stdin.
+The editor shows a YAML front-matter block (title, kind, tags, links) above
+the content, so all fields can be filled in there.
 
 Examples:
   git ctx add "Why JWT for auth"
   git ctx add --title "Decision" --message "We chose X because..."
   git ctx add --shared "Team standards"
   echo "content" | git ctx add --title "Note"
-  git ctx add --title "Auth" --tag=security --tag=backend`,
+  git ctx add --title "Auth" --tag=security --tag=backend
+  git ctx add --kind decision "Use PostgreSQL"   # ADR template
+
+Kinds: note (default), decision, plan, handoff, bug. Each kind opens its
+own editor template; see "git ctx template" to customize them.`,
 	RunE: runAdd,
 }
 
@@ -38,6 +46,7 @@ func init() {
 	addCmd.Flags().StringVarP(&addTitle, "title", "t", "", "Entry title")
 	addCmd.Flags().StringVarP(&addMessage, "message", "m", "", "Entry content (skips editor)")
 	addCmd.Flags().StringArrayVar(&addTags, "tag", nil, "Tags for categorization")
+	addCmd.Flags().StringVarP(&addKind, "kind", "k", "", "Entry kind (note, decision, plan, handoff, bug)")
 }
 
 func runAdd(cmd *cobra.Command, args []string) error {
@@ -50,10 +59,18 @@ func runAdd(cmd *cobra.Command, args []string) error {
 		title = "Untitled"
 	}
 	
-	// Get content from message flag, stdin, or editor
-	content := addMessage
+	kind, err := model.ParseKind(addKind)
+	if err != nil {
+		return err
+	}
 	
-	if content == "" {
+	// Create memory entry
+	m := model.NewMemory(title, addMessage, client.Author(), flagShared)
+	m.Kind = kind
+	m.Tags = addTags
+	
+	// Get content from message flag, stdin, or editor
+	if m.Content == "" {
 		// Check if stdin has data
 		stat, _ := os.Stdin.Stat()
 		if (stat.Mode() & os.ModeCharDevice) == 0 {
@@ -62,42 +79,48 @@ func runAdd(cmd *cobra.Command, args []string) error {
 			if err != nil {
 				return fmt.Errorf("failed to read stdin: %w", err)
 			}
-			content = string(data)
+			m.Content = string(data)
 		} else {
-			// Open editor
-			var err error
-			content, err = openEditor(title)
-			if err != nil {
-				return fmt.Errorf("editor failed: %w", err)
+			// Open editor on front matter plus the kind's template
+			if err := openEditor(m); err != nil {
+				return err
 			}
 		}
 	}
 	
 	// Trim whitespace
-	content = strings.TrimSpace(content)
-	
-	// Create memory entry
-	author := model.GetAuthorShort()
-	m := model.NewMemory(title, content, author, flagShared)
-	m.Tags = addTags
+	m.Content = strings.TrimSpace(m.Content)
 	
 	// Save
-	storage := getStorage()
-	if err := storage.WriteMemory(m); err != nil {
-		return fmt.Errorf("failed to save: %w", err)
+	if err := client.CreateMemory(context.Background(), m); err != nil {
+		return err
 	}
 	
 	// Output
-	storageType := "local"
-	if flagShared {
-		storageType = "shared"
-	}
-	fmt.Printf("Created (%s): %s\n", storageType, m.ID)
+	fmt.Printf("Created (%s): %s\n", storageLabel(m.Shared), m.ID)
 	
 	return nil
 }
 
-func openEditor(title string) (string, error) {
+// openEditor seeds the memory with the template for its kind, unless
+// templates are turned off, and lets the user edit front matter and
+// content together.
+func openEditor(m *model.Memory) error {
+	if cfg.Templates {
+		text, _ := loadTemplate(m.Kind)
+		body, err := model.RenderTemplate(text, m.Kind, m.Title, m.Author)
+		if err != nil {
+			return err
+		}
+		m.Content = body
+	}
+	
+	return editMemory(m)
+}
+
+// editText opens the user's editor on a temp file seeded with initial
+// and returns the saved content.
+func editText(initial string) (string, error) {
 	// Create temp file
 	tmpfile, err := os.CreateTemp("", "git-ctx-*.md")
 	if err != nil {
@@ -105,18 +128,21 @@ func openEditor(title string) (string, error) {
 	}
 	defer os.Remove(tmpfile.Name())
 	
-	// Write template
-	template := fmt.Sprintf("# %s\n\n", title)
-	tmpfile.WriteString(template)
+	tmpfile.WriteString(initial)
 	tmpfile.Close()
 	
-	// Open editor
-	editor := os.Getenv("EDITOR")
+	// Open editor: the editor setting, then $EDITOR, then vim
+	editor := cfg.Editor
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
 	if editor == "" {
 		editor = "vim"
 	}
 	
-	cmd := exec.Command(editor, tmpfile.Name())
+	// The editor may carry arguments, such as "code --wait"
+	editorArgs := append(strings.Fields(editor), tmpfile.Name())
+	cmd := exec.Command(editorArgs[0], editorArgs[1:]...)
 	cmd.Stdin = os.Stdin
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
@@ -140,5 +166,3 @@ func openEditor(title string) (string, error) {
 	
 	return strings.Join(lines, "\n"), nil
 }
-
-
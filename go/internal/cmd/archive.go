@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/user/git-context/internal/model"
+)
+
+var archiveRestore bool
+
+var supersedeCmd = &cobra.Command{
+	Use:   "supersede <old> <new>",
+	Short: "Replace an entry with a newer one",
+	Long: `Mark an entry as superseded by a newer entry.
+
+The old entry is archived and hidden from list and search, and show
+points at its replacement. The new entry gets a supersedes link.
+Prefer this over rm for decisions that changed, so the history of
+why things are the way they are is kept.
+
+Examples:
+  git ctx supersede abc12345 def67890`,
+	Args: cobra.ExactArgs(2),
+	RunE: runSupersede,
+}
+
+var archiveCmd = &cobra.Command{
+	Use:   "archive <id>",
+	Short: "Archive a context entry",
+	Long: `Archive a context entry without deleting it.
+
+Archived entries are hidden from list and search unless --archived is
+given.
+
+Examples:
+  git ctx archive abc12345
+  git ctx archive abc12345 --restore   # Bring it back`,
+	Args: cobra.ExactArgs(1),
+	RunE: runArchive,
+}
+
+func init() {
+	archiveCmd.Flags().BoolVar(&archiveRestore, "restore", false, "Restore an archived or superseded entry")
+}
+
+func runSupersede(cmd *cobra.Command, args []string) error {
+	oldID, newID := args[0], args[1]
+	if oldID == newID {
+		return fmt.Errorf("an entry cannot supersede itself")
+	}
+
+	ctx := context.Background()
+	if _, err := client.GetMemory(ctx, oldID); err != nil {
+		return err
+	}
+	_, err := client.UpdateMemory(ctx, newID, func(m *model.Memory) error {
+		m.AddLink(model.Link{Type: model.LinkSupersedes, Target: oldID})
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	_, err = client.UpdateMemory(ctx, oldID, func(m *model.Memory) error {
+		m.Supersede(newID)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save %s: %w", oldID, err)
+	}
+
+	fmt.Printf("Superseded: %s -> %s\n", oldID, newID)
+	return nil
+}
+
+func runArchive(cmd *cobra.Command, args []string) error {
+	id := args[0]
+
+	_, err := client.UpdateMemory(context.Background(), id, func(m *model.Memory) error {
+		if archiveRestore {
+			if !m.IsArchived() {
+				return fmt.Errorf("not archived: %s", id)
+			}
+			m.Restore()
+		} else {
+			if m.IsArchived() {
+				return fmt.Errorf("already archived: %s", id)
+			}
+			m.Archive()
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if archiveRestore {
+		fmt.Printf("Restored: %s\n", id)
+	} else {
+		fmt.Printf("Archived: %s\n", id)
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+var bundleSince string
+
+var bundleCmd = &cobra.Command{
+	Use:   "bundle",
+	Short: "Sync shared context through bundle files",
+	Long: `Move shared context between machines without a network connection.
+
+A bundle is a single file holding refs/context/* as a git bundle. Copy
+it over however you like and apply it on the other side.
+
+Examples:
+  git ctx bundle create ctx.bundle
+  git ctx bundle create --since ctx.bundle ctx-2.bundle
+  git ctx bundle apply ctx.bundle`,
+}
+
+var bundleCreateCmd = &cobra.Command{
+	Use:   "create <file>",
+	Short: "Write shared context to a bundle",
+	Long: `Write shared context to a git bundle file.
+
+With --since, only changes made after the given (earlier) bundle are
+included, so the receiving side must have applied that bundle first.
+
+Examples:
+  git ctx bundle create ctx.bundle
+  git ctx bundle create --since ctx.bundle ctx-2.bundle`,
+	Args: cobra.ExactArgs(1),
+	RunE: runBundleCreate,
+}
+
+var bundleApplyCmd = &cobra.Command{
+	Use:   "apply <file>",
+	Short: "Merge a bundle into shared context",
+	Long: `Verify a bundle and merge its shared context.
+
+Entries are merged exactly as pull merges them from a remote.
+
+Examples:
+  git ctx bundle apply ctx.bundle`,
+	Args: cobra.ExactArgs(1),
+	RunE: runBundleApply,
+}
+
+func init() {
+	bundleCreateCmd.Flags().StringVar(&bundleSince, "since", "", "Only include changes since this bundle")
+
+	bundleCmd.AddCommand(bundleCreateCmd)
+	bundleCmd.AddCommand(bundleApplyCmd)
+}
+
+func runBundleCreate(cmd *cobra.Command, args []string) error {
+	count, err := client.CreateBundle(context.Background(), args[0], bundleSince)
+	if err != nil {
+		return fmt.Errorf("failed to create bundle: %w", err)
+	}
+
+	if count == 0 {
+		fmt.Println("Nothing to bundle")
+		return nil
+	}
+	fmt.Printf("Bundled %d entries to %s\n", count, args[0])
+	return nil
+}
+
+func runBundleApply(cmd *cobra.Command, args []string) error {
+	result, err := client.ApplyBundle(context.Background(), args[0])
+	if err != nil {
+		return fmt.Errorf("failed to apply bundle: %w", err)
+	}
+
+	printMergeResult(result)
+	return nil
+}
@@ -0,0 +1,278 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/user/git-context/gitctx"
+	"github.com/user/git-context/internal/model"
+)
+
+var (
+	scanMaxCount int
+	scanQuiet    bool
+	hooksForce   bool
+)
+
+// hookMarker identifies hooks written by git-ctx.
+const hookMarker = "# installed by git-ctx"
+
+const postCommitHook = `#!/bin/sh
+` + hookMarker + `
+# Links the new commit to tasks named in its trailers (Task:, Closes:).
+git-ctx scan-commits --quiet -n 1 HEAD
+`
+
+var scanCommitsCmd = &cobra.Command{
+	Use:   "scan-commits [range]",
+	Short: "Close tasks referenced by commit trailers",
+	Long: `Scan commits for task trailers and close the referenced tasks.
+
+Recognized trailers: Task, Closes, Fixes, Resolves. Each referenced task
+gets the commit SHA linked, is marked done, and receives a comment with
+the commit subject. Commits that are already linked are skipped, so
+scanning the same range twice is safe.
+
+The range is passed to git log and defaults to HEAD.
+
+Examples:
+  git ctx scan-commits                  # All commits reachable from HEAD
+  git ctx scan-commits main..feature    # Commits on a branch
+  git ctx scan-commits -n 1 HEAD        # Just the latest commit
+
+Commit message example:
+  Add login endpoint
+
+  Closes: task-abc123`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runScanCommits,
+}
+
+var hooksCmd = &cobra.Command{
+	Use:   "hooks",
+	Short: "Manage git hooks",
+}
+
+var hooksInstallCmd = &cobra.Command{
+	Use:   "install",
+	Short: "Install the post-commit hook",
+	Long: `Install a post-commit hook that runs scan-commits on every new commit,
+so task state follows the actual history.
+
+Examples:
+  git ctx hooks install
+  git ctx hooks install --force   # Replace an existing hook`,
+	RunE: runHooksInstall,
+}
+
+var hooksUninstallCmd = &cobra.Command{
+	Use:   "uninstall",
+	Short: "Remove the post-commit hook",
+	RunE:  runHooksUninstall,
+}
+
+func init() {
+	scanCommitsCmd.Flags().IntVarP(&scanMaxCount, "max-count", "n", 0, "Limit the number of commits to scan")
+	scanCommitsCmd.Flags().BoolVarP(&scanQuiet, "quiet", "q", false, "Only print closed tasks")
+
+	hooksInstallCmd.Flags().BoolVarP(&hooksForce, "force", "f", false, "Overwrite an existing hook")
+	hooksCmd.AddCommand(hooksInstallCmd)
+	hooksCmd.AddCommand(hooksUninstallCmd)
+}
+
+// commitInfo is a commit read from git log.
+type commitInfo struct {
+	SHA     string
+	Subject string
+	Body    string
+	Author  string
+}
+
+func runScanCommits(cmd *cobra.Command, args []string) error {
+	rev := "HEAD"
+	if len(args) > 0 {
+		rev = args[0]
+	}
+
+	commits, err := readCommits(rev, scanMaxCount)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	w, err := client.Workflow(ctx)
+	if err != nil {
+		return err
+	}
+
+	closed := 0
+	for _, c := range commits {
+		for _, id := range model.ParseTaskTrailers(c.Body) {
+			t, err := client.GetTask(ctx, id)
+			if errors.Is(err, gitctx.ErrNotFound) {
+				if !scanQuiet {
+					fmt.Fprintf(os.Stderr, "Warning: %s references unknown task %s\n", shortSHA(c.SHA), id)
+				}
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			if !t.LinkCommit(c.SHA) {
+				continue // scanned before; a reopened task stays open
+			}
+
+			// With hooks.autoClose off, commits are only linked. Closing
+			// goes through the client, so the workflow is checked and the
+			// owner's path locks are released.
+			closes := cfg.HooksAutoClose
+			if closes && !w.IsDone(t.Status) {
+				_, err := client.WithAuthor(c.Author).CompleteTask(ctx, id)
+				switch {
+				case errors.Is(err, gitctx.ErrNotAllowed):
+					closes = false
+					if !scanQuiet {
+						fmt.Fprintf(os.Stderr, "Warning: %v; only linked\n", err)
+					}
+				case err != nil:
+					return fmt.Errorf("failed to close %s: %w", id, err)
+				default:
+					closed++
+				}
+			}
+			verb := "Referenced by"
+			if closes {
+				verb = "Closed by"
+			}
+
+			_, err = client.UpdateTask(ctx, id, func(t *model.Task) error {
+				if !t.LinkCommit(c.SHA) {
+					return errUnchanged
+				}
+				t.AddComment(c.Author, fmt.Sprintf("%s %s: %s", verb, shortSHA(c.SHA), c.Subject))
+				return nil
+			})
+			if errors.Is(err, errUnchanged) {
+				continue
+			}
+			if err != nil {
+				return fmt.Errorf("failed to update %s: %w", id, err)
+			}
+
+			if closes {
+				fmt.Printf("Done: %s (%s)\n", id, shortSHA(c.SHA))
+			} else {
+				fmt.Printf("Linked: %s (%s)\n", id, shortSHA(c.SHA))
+			}
+		}
+	}
+
+	if !scanQuiet {
+		fmt.Printf("Scanned %d commits, closed %d tasks\n", len(commits), closed)
+	}
+
+	return nil
+}
+
+// readCommits returns commits in rev, oldest first.
+func readCommits(rev string, maxCount int) ([]commitInfo, error) {
+	gitArgs := []string{"log", "--reverse", "--format=%H%x1f%s%x1f%an%x1f%B%x1e"}
+	if maxCount > 0 {
+		// --reverse is applied after --max-count, so this keeps the newest n
+		gitArgs = append(gitArgs, fmt.Sprintf("--max-count=%d", maxCount))
+	}
+	gitArgs = append(gitArgs, rev, "--")
+
+	output, err := exec.Command("git", gitArgs...).Output()
+	if err != nil {
+		return nil, fmt.Errorf("git log %s failed: %w", rev, err)
+	}
+
+	var commits []commitInfo
+	for _, record := range strings.Split(string(output), "\x1e") {
+		record = strings.TrimLeft(record, "\n")
+		if record == "" {
+			continue
+		}
+		fields := strings.SplitN(record, "\x1f", 4)
+		if len(fields) != 4 {
+			continue
+		}
+		commits = append(commits, commitInfo{
+			SHA:     fields[0],
+			Subject: fields[1],
+			Author:  fields[2],
+			Body:    fields[3],
+		})
+	}
+
+	return commits, nil
+}
+
+func shortSHA(sha string) string {
+	if len(sha) > 7 {
+		return sha[:7]
+	}
+	return sha
+}
+
+func runHooksInstall(cmd *cobra.Command, args []string) error {
+	path, err := hookPath("post-commit")
+	if err != nil {
+		return err
+	}
+
+	if existing, err := os.ReadFile(path); err == nil {
+		if !strings.Contains(string(existing), hookMarker) && !hooksForce {
+			return fmt.Errorf("%s already exists (use --force to replace it)", path)
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, []byte(postCommitHook), 0755); err != nil {
+		return fmt.Errorf("failed to write hook: %w", err)
+	}
+
+	fmt.Printf("Installed: %s\n", path)
+	return nil
+}
+
+func runHooksUninstall(cmd *cobra.Command, args []string) error {
+	path, err := hookPath("post-commit")
+	if err != nil {
+		return err
+	}
+
+	existing, err := os.ReadFile(path)
+	if err != nil {
+		fmt.Println("No hook installed")
+		return nil
+	}
+	if !strings.Contains(string(existing), hookMarker) {
+		return fmt.Errorf("%s was not installed by git-ctx", path)
+	}
+
+	if err := os.Remove(path); err != nil {
+		return err
+	}
+
+	fmt.Printf("Removed: %s\n", path)
+	return nil
+}
+
+// hookPath returns the path of a git hook, honoring core.hooksPath.
+func hookPath(name string) (string, error) {
+	output, err := exec.Command("git", "rev-parse", "--git-path", filepath.Join("hooks", name)).Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate hooks directory: %w", err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
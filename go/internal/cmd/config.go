@@ -0,0 +1,135 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/user/git-context/internal/config"
+	"github.com/user/git-context/internal/output"
+)
+
+var flagConfigGlobal bool
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Show and change settings",
+	Long: `Show and change git-context settings.
+
+Settings are read from git config as ctx.<key>, then from a committed
+.gitctx.toml or .gitctx.yaml at the top of the work tree, then from the
+built-in defaults. "config set" writes git config, so it overrides the
+committed file for you only.
+
+Keys:
+  storage          local or shared: where new entries, tasks and locks go
+  lock.ttl         how long locks last, e.g. 30m or 4h
+  task.lease       how long a task claim lasts without a heartbeat
+  remote           remote for push, pull and status
+  editor           editor command; defaults to $EDITOR, then vim
+  id.length        hex digits in new IDs (4-32)
+  templates        start new entries from their kind's template
+  hooks.autoClose  mark tasks done when commits reference them
+
+Examples:
+  git ctx config list
+  git ctx config get lock.ttl
+  git ctx config set storage shared
+  git ctx config set editor "code --wait" --global
+  git ctx config unset storage`,
+}
+
+var configListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List every setting with its value and source",
+	Args:  cobra.NoArgs,
+	RunE:  runConfigList,
+}
+
+var configGetCmd = &cobra.Command{
+	Use:   "get <key>",
+	Short: "Print the value of a setting",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runConfigGet,
+}
+
+var configSetCmd = &cobra.Command{
+	Use:   "set <key> <value>",
+	Short: "Set a setting in git config",
+	Args:  cobra.ExactArgs(2),
+	RunE:  runConfigSet,
+}
+
+var configUnsetCmd = &cobra.Command{
+	Use:   "unset <key>",
+	Short: "Remove a setting from git config",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runConfigUnset,
+}
+
+func init() {
+	addOutputFlags(configListCmd)
+	configSetCmd.Flags().BoolVar(&flagConfigGlobal, "global", false, "Write to your global git config instead of the repository's")
+	configUnsetCmd.Flags().BoolVar(&flagConfigGlobal, "global", false, "Remove from your global git config instead of the repository's")
+
+	configCmd.AddCommand(configListCmd)
+	configCmd.AddCommand(configGetCmd)
+	configCmd.AddCommand(configSetCmd)
+	configCmd.AddCommand(configUnsetCmd)
+}
+
+func runConfigList(cmd *cobra.Command, args []string) error {
+	c, err := config.Load(".")
+	if err != nil {
+		return err
+	}
+	warnConfig(c)
+
+	values := c.Values()
+	l := &output.List{
+		Columns: []output.Column{{Header: "KEY"}, {Header: "VALUE"}, {Header: "SOURCE"}},
+		Items:   values,
+	}
+	for _, v := range values {
+		l.Rows = append(l.Rows, []string{v.Key, v.Value, v.Source})
+	}
+	return writeList(cmd, l)
+}
+
+func runConfigGet(cmd *cobra.Command, args []string) error {
+	c, err := config.Load(".")
+	if err != nil {
+		return err
+	}
+	warnConfig(c)
+
+	v, err := c.Get(args[0])
+	if err != nil {
+		return err
+	}
+	fmt.Println(v.Value)
+	return nil
+}
+
+func runConfigSet(cmd *cobra.Command, args []string) error {
+	if err := config.Set(".", args[0], args[1], flagConfigGlobal); err != nil {
+		return err
+	}
+	fmt.Printf("Set %s = %s\n", args[0], args[1])
+	return nil
+}
+
+func runConfigUnset(cmd *cobra.Command, args []string) error {
+	if err := config.Unset(".", args[0], flagConfigGlobal); err != nil {
+		return err
+	}
+	fmt.Printf("Unset %s\n", args[0])
+	return nil
+}
+
+// warnConfig reports config keys that were skipped while loading.
+func warnConfig(c *config.Config) {
+	for _, w := range c.Warnings {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", w)
+	}
+}
@@ -1,12 +1,14 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"os"
-	"os/exec"
-	"time"
+	"strings"
 
 	"github.com/spf13/cobra"
+	"github.com/user/git-context/gitctx"
+	"github.com/user/git-context/internal/model"
 )
 
 var editCmd = &cobra.Command{
@@ -14,6 +16,11 @@ var editCmd = &cobra.Command{
 	Short: "Edit a context entry",
 	Long: `Edit a context entry in your default editor.
 
+The editor shows a YAML front-matter block with the title, kind, tags
+and links, followed by the markdown content. If the front matter is
+invalid, the editor reopens with the error noted at the top; save the
+buffer unchanged to give up.
+
 Searches both local and shared storage.
 
 Examples:
@@ -22,62 +29,63 @@ Examples:
 	RunE: runEdit,
 }
 
-func runEdit(cmd *cobra.Command, args []string) error {
-	id := args[0]
-	
-	// Find entry
-	m, storageType := findMemory(id)
-	if m == nil {
-		return fmt.Errorf("not found: %s", id)
-	}
-	
-	// Create temp file with content
-	tmpfile, err := os.CreateTemp("", "git-ctx-*.md")
-	if err != nil {
-		return err
-	}
-	defer os.Remove(tmpfile.Name())
+// editMemory opens the editor on a memory's front matter and content and
+// applies the result. Invalid front matter reopens the editor until it
+// is fixed or the buffer is saved unchanged.
+func editMemory(m *model.Memory) error {
+	buffer := model.FormatFrontMatter(m)
+	reopened := false
 	
-	tmpfile.WriteString(m.Content)
-	tmpfile.Close()
-	
-	// Open editor
-	editor := os.Getenv("EDITOR")
-	if editor == "" {
-		editor = "vim"
+	for {
+		text, err := editText(buffer)
+		if err != nil {
+			return fmt.Errorf("editor failed: %w", err)
+		}
+		
+		fm, body, err := model.ParseFrontMatter(text)
+		if err == nil {
+			err = validateLinkTargets(fm.Links)
+		}
+		if err == nil {
+			fm.Apply(m)
+			m.Content = body
+			return nil
+		}
+		
+		if strings.TrimSpace(text) == "" {
+			return fmt.Errorf("empty buffer, aborted")
+		}
+		if reopened && text == strings.TrimRight(buffer, "\n") {
+			// Saved without fixing the reported error
+			return fmt.Errorf("aborted: %w", err)
+		}
+		
+		fmt.Fprintf(os.Stderr, "Error: %v (reopening editor)\n", err)
+		buffer = model.AnnotateFrontMatterError(text, err)
+		reopened = true
 	}
-	
-	editCmd := exec.Command(editor, tmpfile.Name())
-	editCmd.Stdin = os.Stdin
-	editCmd.Stdout = os.Stdout
-	editCmd.Stderr = os.Stderr
-	
-	if err := editCmd.Run(); err != nil {
-		return fmt.Errorf("editor failed: %w", err)
+}
+
+// validateLinkTargets checks that entity links point at existing entries
+// or tasks.
+func validateLinkTargets(links []model.Link) error {
+	for i, l := range links {
+		if l.Type.IsEntity() && entityTitle(l.Target) == "" {
+			return fmt.Errorf("links[%d]: %w: %s", i, gitctx.ErrNotFound, l.Target)
+		}
 	}
+	return nil
+}
+
+func runEdit(cmd *cobra.Command, args []string) error {
+	id := args[0]
 	
-	// Read new content
-	newContent, err := os.ReadFile(tmpfile.Name())
+	// Edit and save to the storage it was found in
+	_, err := client.UpdateMemory(context.Background(), id, editMemory)
 	if err != nil {
 		return err
 	}
 	
-	// Update entry
-	m.Content = string(newContent)
-	m.UpdatedAt = time.Now().UTC()
-	
-	// Save to correct storage
-	var saveErr error
-	if storageType == "local" {
-		saveErr = store.Local.WriteMemory(m)
-	} else {
-		saveErr = store.Shared.WriteMemory(m)
-	}
-	
-	if saveErr != nil {
-		return fmt.Errorf("failed to save: %w", saveErr)
-	}
-	
 	fmt.Printf("Updated: %s\n", id)
 	return nil
 }
@@ -0,0 +1,31 @@
+package cmd
+
+import (
+	"errors"
+
+	"github.com/user/git-context/gitctx"
+)
+
+// Exit codes, so scripts and agents can tell failures apart without
+// parsing messages.
+const (
+	ExitError    = 1 // any other failure
+	ExitNotFound = 3 // no such entry, task, lock or template
+	ExitConflict = 4 // claimed by someone else, ID taken, or changed concurrently
+	ExitLocked   = 5 // target is locked
+)
+
+// ExitCode returns the process exit code for an error from Execute.
+func ExitCode(err error) int {
+	switch {
+	case err == nil:
+		return 0
+	case errors.Is(err, gitctx.ErrNotFound):
+		return ExitNotFound
+	case errors.Is(err, gitctx.ErrLocked):
+		return ExitLocked
+	case errors.Is(err, gitctx.ErrConflict):
+		return ExitConflict
+	}
+	return ExitError
+}
@@ -0,0 +1,193 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/user/git-context/gitctx"
+	"github.com/user/git-context/internal/export"
+)
+
+var (
+	exportFormat     string
+	exportOutput     string
+	importFormat     string
+	importOnConflict string
+	importDryRun     bool
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export context to a file",
+	Long: `Export memories, tasks (with comments) and locks.
+
+IDs and timestamps are preserved, so the result can be imported into
+another repository or kept as a backup.
+
+Formats:
+  jsonl         One JSON record per line (default)
+  tar           Tar archive of JSON files
+  markdown-dir  Directory of markdown files with YAML front matter
+
+Examples:
+  git ctx export > backup.jsonl              # Local context
+  git ctx export --all -o backup.jsonl       # Local and shared
+  git ctx export --shared --format tar -o shared.tar
+  git ctx export --all --format markdown-dir -o context/`,
+	RunE: runExport,
+}
+
+var importCmd = &cobra.Command{
+	Use:   "import <file>",
+	Short: "Import context from an export",
+	Long: `Import memories, tasks and locks from a file written by export.
+
+Entries go back into the storage (local or shared) they were exported
+from. The format is detected from the file unless --format is given.
+
+When an ID already exists:
+  skip       Keep the existing entry (default)
+  overwrite  Replace it with the imported one
+  rename     Import under a new ID; links between imported entries
+             are updated to match
+
+Examples:
+  git ctx import backup.jsonl
+  git ctx import shared.tar --on-conflict overwrite
+  git ctx import context/ --on-conflict rename --dry-run`,
+	Args: cobra.ExactArgs(1),
+	RunE: runImport,
+}
+
+func init() {
+	exportCmd.Flags().StringVarP(&exportFormat, "format", "f", "jsonl", "Output format: jsonl, tar, markdown-dir")
+	exportCmd.Flags().StringVarP(&exportOutput, "output", "o", "", "Output file or directory (default stdout)")
+
+	importCmd.Flags().StringVarP(&importFormat, "format", "f", "", "Input format (detected if omitted)")
+	importCmd.Flags().StringVar(&importOnConflict, "on-conflict", "skip", "What to do with existing IDs: skip, overwrite, rename")
+	importCmd.Flags().BoolVar(&importDryRun, "dry-run", false, "Report what would be imported without writing")
+}
+
+func runExport(cmd *cobra.Command, args []string) error {
+	format, err := export.ParseFormat(exportFormat)
+	if err != nil {
+		return err
+	}
+
+	snap, err := client.Export(context.Background(), listLocation())
+	if err != nil {
+		return err
+	}
+
+	if format == export.FormatMarkdownDir {
+		if exportOutput == "" {
+			return fmt.Errorf("markdown-dir needs an output directory (-o)")
+		}
+		if err := export.WriteMarkdownDir(exportOutput, snap); err != nil {
+			return err
+		}
+	} else {
+		var w io.Writer = os.Stdout
+		if exportOutput != "" {
+			f, err := os.Create(exportOutput)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			w = f
+		}
+
+		if format == export.FormatTar {
+			err = export.WriteTar(w, snap)
+		} else {
+			err = export.WriteJSONL(w, snap)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to export: %w", err)
+		}
+	}
+
+	if exportOutput != "" {
+		fmt.Fprintf(os.Stderr, "Exported %s to %s\n", describeSnapshot(snap), exportOutput)
+	}
+	return nil
+}
+
+func runImport(cmd *cobra.Command, args []string) error {
+	path := args[0]
+
+	policy, err := gitctx.ParseConflictPolicy(importOnConflict)
+	if err != nil {
+		return fmt.Errorf("invalid --on-conflict: %w", err)
+	}
+
+	var format export.Format
+	if importFormat != "" {
+		format, err = export.ParseFormat(importFormat)
+	} else {
+		format, err = export.DetectFormat(path)
+	}
+	if err != nil {
+		return err
+	}
+
+	var snap *export.Snapshot
+	if format == export.FormatMarkdownDir {
+		snap, err = export.ReadMarkdownDir(path)
+	} else {
+		f, openErr := os.Open(path)
+		if openErr != nil {
+			return openErr
+		}
+		defer f.Close()
+
+		if format == export.FormatTar {
+			snap, err = export.ReadTar(f)
+		} else {
+			snap, err = export.ReadJSONL(f)
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	result, err := client.Import(context.Background(), snap, gitctx.ImportOptions{
+		OnConflict: policy,
+		DryRun:     importDryRun,
+	})
+	for _, c := range result.Conflicts {
+		switch {
+		case c.Skipped:
+			fmt.Printf("Skipped %s (%s): %s\n", c.Entity, c.Location, c.ID)
+		case c.NewID != "":
+			fmt.Printf("Renamed %s (%s): %s -> %s\n", c.Entity, c.Location, c.ID, c.NewID)
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	verb := "Imported"
+	if importDryRun {
+		verb = "Would import"
+	}
+	fmt.Printf("%s %d, skipped %d, overwrote %d, renamed %d\n",
+		verb, result.Imported, result.Skipped, result.Overwritten, result.Renamed)
+	return nil
+}
+
+func describeSnapshot(snap *export.Snapshot) string {
+	count := func(f func(*export.Set) int) int {
+		return f(&snap.Local) + f(&snap.Shared)
+	}
+	parts := []string{
+		fmt.Sprintf("%d memories", count(func(s *export.Set) int { return len(s.Memories) })),
+		fmt.Sprintf("%d tasks", count(func(s *export.Set) int { return len(s.Tasks) })),
+		fmt.Sprintf("%d locks", count(func(s *export.Set) int { return len(s.Locks) })),
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,259 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/user/git-context/gitctx"
+	"github.com/user/git-context/internal/model"
+)
+
+var (
+	linkType   string
+	linkRemove bool
+)
+
+var linkCmd = &cobra.Command{
+	Use:   "link <from> <to>",
+	Short: "Link an entry or task to another entity",
+	Long: `Add a typed link from a memory or task to another memory, task,
+commit or branch.
+
+Link types: relates-to (default), supersedes, decided-by, implements,
+commit, branch. Links are shown from both sides by show and task show.
+
+Memories can also reference each other inline with [[id]] in their
+content; these show up as backlinks on the referenced entity.
+
+Examples:
+  git ctx link abc12345 task-def456                  # relates-to
+  git ctx link task-def456 abc12345 --type decided-by
+  git ctx link abc12345 0a1b2c3 --type commit
+  git ctx link task-def456 feature/auth --type branch
+  git ctx link abc12345 task-def456 --remove`,
+	Args: cobra.ExactArgs(2),
+	RunE: runLink,
+}
+
+func init() {
+	linkCmd.Flags().StringVar(&linkType, "type", string(model.LinkRelatesTo), "Link type")
+	linkCmd.Flags().BoolVar(&linkRemove, "remove", false, "Remove the link instead of adding it")
+}
+
+func runLink(cmd *cobra.Command, args []string) error {
+	from, to := args[0], args[1]
+
+	typ, err := model.ParseLinkType(linkType)
+	if err != nil {
+		return err
+	}
+
+	if !linkRemove {
+		to, err = resolveLinkTarget(typ, to)
+		if err != nil {
+			return err
+		}
+	}
+
+	// The source can be a memory or a task
+	ctx := context.Background()
+	_, err = client.UpdateMemory(ctx, from, func(m *model.Memory) error {
+		var changed bool
+		if linkRemove {
+			m.Links, changed = model.RemoveLink(m.Links, to, typ)
+		} else {
+			changed = m.AddLink(model.Link{Type: typ, Target: to})
+		}
+		if !changed {
+			return errUnchanged
+		}
+		return nil
+	})
+	if errors.Is(err, gitctx.ErrNotFound) {
+		_, err = client.UpdateTask(ctx, from, func(t *model.Task) error {
+			var changed bool
+			if linkRemove {
+				t.Links, changed = model.RemoveLink(t.Links, to, typ)
+			} else {
+				changed = t.AddLink(model.Link{Type: typ, Target: to})
+			}
+			if !changed {
+				return errUnchanged
+			}
+			return nil
+		})
+	}
+	if errors.Is(err, errUnchanged) {
+		return reportUnchanged(from, to, typ)
+	}
+	if err != nil {
+		return err
+	}
+	return reportLinked(from, to, typ)
+}
+
+// errUnchanged stops an update that would not change anything.
+var errUnchanged = errors.New("unchanged")
+
+// resolveLinkTarget validates the target of a link and normalizes it.
+// Commits are expanded to their full SHA.
+func resolveLinkTarget(typ model.LinkType, target string) (string, error) {
+	switch typ {
+	case model.LinkCommit:
+		output, err := exec.Command("git", "rev-parse", "--verify", "--quiet", target+"^{commit}").Output()
+		if err != nil {
+			return "", fmt.Errorf("not a commit: %s", target)
+		}
+		return strings.TrimSpace(string(output)), nil
+	case model.LinkBranch:
+		if err := exec.Command("git", "check-ref-format", "--branch", target).Run(); err != nil {
+			return "", fmt.Errorf("not a valid branch name: %s", target)
+		}
+		return target, nil
+	default:
+		if entityTitle(target) == "" {
+			return "", fmt.Errorf("%w: %s", gitctx.ErrNotFound, target)
+		}
+		return target, nil
+	}
+}
+
+func reportLinked(from, to string, typ model.LinkType) error {
+	if linkRemove {
+		fmt.Printf("Unlinked: %s -/-> %s\n", from, to)
+	} else {
+		fmt.Printf("Linked: %s -%s-> %s\n", from, typ, to)
+	}
+	return nil
+}
+
+func reportUnchanged(from, to string, typ model.LinkType) error {
+	if linkRemove {
+		return fmt.Errorf("no %s link from %s to %s", typ, from, to)
+	}
+	fmt.Printf("Already linked: %s -%s-> %s\n", from, typ, to)
+	return nil
+}
+
+// backlink is a reference to an entity from another entity.
+type backlink struct {
+	Label string
+	From  string
+	Title string
+}
+
+// findBacklinks returns everything that links to or mentions id, across
+// local and shared storage.
+func findBacklinks(id string) []backlink {
+	var result []backlink
+
+	for _, m := range allMemories() {
+		if m.ID == id {
+			continue
+		}
+		for _, l := range m.Links {
+			if l.Target == id && l.Type.IsEntity() {
+				result = append(result, backlink{Label: l.Type.Inverse(), From: m.ID, Title: m.Title})
+			}
+		}
+		for _, ref := range m.InlineRefs() {
+			if ref == id {
+				result = append(result, backlink{Label: "referenced-by", From: m.ID, Title: m.Title})
+			}
+		}
+	}
+
+	for _, t := range allTasks() {
+		if t.ID == id {
+			continue
+		}
+		for _, l := range t.Links {
+			if l.Target == id && l.Type.IsEntity() {
+				result = append(result, backlink{Label: l.Type.Inverse(), From: t.ID, Title: t.Title})
+			}
+		}
+	}
+
+	return result
+}
+
+// printLinks prints the outgoing links, inline references and backlinks
+// of an entity. content is scanned for [[id]] references.
+func printLinks(id string, links []model.Link, content string) {
+	refs := model.ParseInlineRefs(content)
+	backlinks := findBacklinks(id)
+
+	if len(links) == 0 && len(refs) == 0 && len(backlinks) == 0 {
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+
+	if len(links) > 0 || len(refs) > 0 {
+		fmt.Fprintln(w, "\nLinks:")
+		for _, l := range links {
+			fmt.Fprintf(w, "  %s\t%s\n", l.Type, describeTarget(l))
+		}
+		for _, ref := range refs {
+			fmt.Fprintf(w, "  references\t%s\n", describeEntity(ref))
+		}
+	}
+
+	if len(backlinks) > 0 {
+		fmt.Fprintln(w, "\nBacklinks:")
+		for _, b := range backlinks {
+			fmt.Fprintf(w, "  %s\t%s (%s)\n", b.Label, b.From, b.Title)
+		}
+	}
+
+	w.Flush()
+}
+
+func describeTarget(l model.Link) string {
+	switch l.Type {
+	case model.LinkCommit:
+		return shortSHA(l.Target)
+	case model.LinkBranch:
+		return l.Target
+	default:
+		return describeEntity(l.Target)
+	}
+}
+
+func describeEntity(id string) string {
+	title := entityTitle(id)
+	if title == "" {
+		return id + " (missing)"
+	}
+	return fmt.Sprintf("%s (%s)", id, title)
+}
+
+// entityTitle returns the title of the memory or task with the given ID,
+// or "" if it does not exist.
+func entityTitle(id string) string {
+	if m, _, err := findMemory(id); err == nil {
+		return m.Title
+	}
+	if t, _, err := findTask(id); err == nil {
+		return t.Title
+	}
+	return ""
+}
+
+// allMemories returns memories from both local and shared storage.
+func allMemories() []*model.Memory {
+	memories, _ := client.ListMemories(context.Background(), gitctx.ListOptions{IncludeArchived: true})
+	return memories
+}
+
+// allTasks returns tasks from both local and shared storage.
+func allTasks() []*model.Task {
+	tasks, _ := client.ListTasks(context.Background(), gitctx.TaskFilter{})
+	return tasks
+}
@@ -1,13 +1,12 @@
 package cmd
 
 import (
-	"encoding/json"
-	"fmt"
-	"os"
-	"text/tabwriter"
+	"context"
 
 	"github.com/spf13/cobra"
+	"github.com/user/git-context/gitctx"
 	"github.com/user/git-context/internal/model"
+	"github.com/user/git-context/internal/output"
 )
 
 var listCmd = &cobra.Command{
@@ -16,80 +15,88 @@ var listCmd = &cobra.Command{
 	Long: `List context entries from git.
 
 By default, shows local entries. Use --shared for shared entries,
-or --all for everything.
+or --all for everything. Archived and superseded entries are hidden
+unless --archived is given.
 
 Examples:
   git ctx list                # Local entries
   git ctx list --shared       # Shared entries
   git ctx list --all          # Everything
-  git ctx list --json         # JSON output`,
+  git ctx list --json         # JSON output
+  git ctx list --format csv   # Also yaml, jsonl, markdown
+  git ctx list --template '{{.ID}} {{.Title}}'
+  git ctx list --archived     # Include archived entries
+  git ctx list --kind decision`,
 	RunE: runList,
 }
 
+var (
+	listArchived bool
+	listKind     string
+)
+
+func init() {
+	listCmd.Flags().BoolVar(&listArchived, "archived", false, "Include archived and superseded entries")
+	listCmd.Flags().StringVarP(&listKind, "kind", "k", "", "Only show entries of this kind")
+	addOutputFlags(listCmd)
+}
+
 func runList(cmd *cobra.Command, args []string) error {
 	var memories []*model.Memory
 	
-	// Collect entries based on flags
-	if flagAll || !flagShared {
-		local, err := store.Local.ListMemories()
+	var kind model.MemoryKind
+	if listKind != "" {
+		var err error
+		kind, err = model.ParseKind(listKind)
 		if err != nil {
-			return fmt.Errorf("failed to list local: %w", err)
-		}
-		for _, m := range local {
-			m.Shared = false
-			memories = append(memories, m)
+			return err
 		}
 	}
 	
-	if flagAll || flagShared {
-		shared, err := store.Shared.ListMemories()
-		if err != nil {
-			return fmt.Errorf("failed to list shared: %w", err)
-		}
-		for _, m := range shared {
-			m.Shared = true
+	// Collect entries based on flags
+	all, err := client.ListMemories(context.Background(), gitctx.ListOptions{
+		Location:        listLocation(),
+		IncludeArchived: listArchived,
+	})
+	if err != nil {
+		return err
+	}
+	for _, m := range all {
+		if kind == "" || m.IsKind(kind) {
 			memories = append(memories, m)
 		}
 	}
 	
-	// Output
-	if flagJSON {
-		return outputJSON(memories)
-	}
-	
-	return outputTable(memories)
+	return writeList(cmd, memoryList(memories))
 }
 
-func outputJSON(memories []*model.Memory) error {
-	data, err := json.MarshalIndent(memories, "", "  ")
-	if err != nil {
-		return err
+// memoryList lays out memories for list and search.
+func memoryList(memories []*model.Memory) *output.List {
+	l := &output.List{
+		Columns: []output.Column{
+			{Header: "ID"},
+			{Header: "TITLE", Max: 45},
+			{Header: "KIND"},
+			{Header: "TYPE"},
+			{Header: "AUTHOR"},
+		},
+		Items: memories,
 	}
-	fmt.Println(string(data))
-	return nil
-}
-
-func outputTable(memories []*model.Memory) error {
-	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-	
-	fmt.Fprintln(w, "ID\tTITLE\tTYPE\tAUTHOR")
-	fmt.Fprintln(w, "----\t-----\t----\t------")
 	
 	for _, m := range memories {
-		typeStr := "[local]"
-		if m.Shared {
-			typeStr = "[shared]"
+		typeStr := storageTag(m.Shared)
+		if m.IsArchived() {
+			typeStr += " archived"
 		}
 		
-		title := m.Title
-		if len(title) > 45 {
-			title = title[:42] + "..."
+		kind := m.Kind
+		if kind == "" {
+			kind = model.KindNote
 		}
 		
-		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", m.ID, title, typeStr, m.Author)
+		l.Rows = append(l.Rows, []string{m.ID, m.Title, string(kind), typeStr, m.Author})
+		l.Raw = append(l.Raw, []string{m.ID, m.Title, string(kind), storageLabel(m.Shared), m.Author})
 	}
 	
-	return w.Flush()
+	return l
 }
-
-
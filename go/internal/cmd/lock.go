@@ -1,12 +1,18 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"os"
-	"text/tabwriter"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/spf13/cobra"
+	"github.com/user/git-context/gitctx"
 	"github.com/user/git-context/internal/model"
+	"github.com/user/git-context/internal/output"
 )
 
 var lockCmd = &cobra.Command{
@@ -16,10 +22,31 @@ var lockCmd = &cobra.Command{
 
 Targets can be task IDs or file paths.
 
+--reason says why, and is shown to anyone the lock keeps out. Locking
+a target you already hold renews it. A path is also kept out by someone
+else's lock on a directory above it or a file inside it, including the
+paths locked by their task claims.
+
+Locks are exclusive unless --mode shared is given. Any number of agents
+can hold a shared lock, such as agents only reading a module to write
+tests against it, but no one can lock it exclusively meanwhile. The
+sole holder of a shared lock can lock it exclusively to upgrade it.
+
+With --wait, a taken lock is queued for instead: you join the lock's
+queue and poll until it is released to you or lapses with no one ahead
+of you. Releasing a lock hands it to the first waiter, so waiters get
+it in turn. Waiters that stop polling lose their place. Waiting for a
+shared lock pulls before each poll and pushes whenever the queue
+changes, so other clones see it, and pulls once more after getting the
+lock in case another clone took it first.
+
 Examples:
   git ctx lock task-abc123      # Lock a task
   git ctx lock src/auth/        # Lock a directory
-  git ctx lock --shared task-1  # Shared lock (syncs)`,
+  git ctx lock src/auth/ --reason "migrating to OAuth"
+  git ctx lock --shared task-1  # Lock in shared storage (syncs)
+  git ctx lock src/auth/ --mode shared   # Read lock: others may read too
+  git ctx lock src/auth/ --wait --timeout 10m`,
 	Args: cobra.ExactArgs(1),
 	RunE: runLock,
 }
@@ -27,7 +54,41 @@ Examples:
 var lockListCmd = &cobra.Command{
 	Use:   "list",
 	Short: "List all locks",
-	RunE:  runLockList,
+	Long: `List live locks.
+
+Examples:
+  git ctx lock list
+  git ctx lock list --all --json
+  git ctx lock list --template '{{.Target}} {{.Mode}} {{.Holders}}'`,
+	RunE: runLockList,
+}
+
+var lockBreakCmd = &cobra.Command{
+	Use:   "break <target>",
+	Short: "Remove someone else's lock",
+	Long: `Remove a lock whoever holds it, such as one left behind by an agent
+that died, instead of waiting for it to expire. A reason is required;
+it is recorded in the lock history with whose lock was broken. The lock
+passes to the next waiter, if there is one.
+
+Examples:
+  git ctx lock break src/auth/ --reason "agent-2 crashed mid-task"`,
+	Args: cobra.ExactArgs(1),
+	RunE: runLockBreak,
+}
+
+var lockHistoryCmd = &cobra.Command{
+	Use:   "history [target]",
+	Short: "Show lock acquires, renewals, releases and breaks",
+	Long: `Show the lock journal: every acquire, renewal, release and break,
+oldest first. Give a target to see only its history.
+
+Examples:
+  git ctx lock history
+  git ctx lock history src/auth/
+  git ctx lock history --all --json`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runLockHistory,
 }
 
 var unlockCmd = &cobra.Command{
@@ -43,81 +104,227 @@ Examples:
 	RunE: runUnlock,
 }
 
+var (
+	lockMode    string
+	lockReason  string
+	lockWait    bool
+	lockTimeout time.Duration
+)
+
 func init() {
+	lockCmd.Flags().StringVarP(&lockReason, "reason", "r", "", "Why you are locking it")
+	lockCmd.Flags().StringVar(&lockMode, "mode", "exclusive", "Lock mode: exclusive, or shared for readers")
+	lockCmd.Flags().BoolVar(&lockWait, "wait", false, "Queue for the lock if it is taken")
+	lockCmd.Flags().DurationVar(&lockTimeout, "timeout", 0, "With --wait, give up after this long (default: no limit)")
+	lockBreakCmd.Flags().StringVarP(&lockReason, "reason", "r", "", "Why the lock is being broken (required)")
+	lockBreakCmd.MarkFlagRequired("reason")
 	lockCmd.AddCommand(lockListCmd)
+	lockCmd.AddCommand(lockBreakCmd)
+	lockCmd.AddCommand(lockHistoryCmd)
+	addOutputFlags(lockListCmd)
+	addOutputFlags(lockHistoryCmd)
 	rootCmd.AddCommand(unlockCmd)
 }
 
 func runLock(cmd *cobra.Command, args []string) error {
 	target := args[0]
-	author := model.GetAuthorShort()
-	
-	// Check if already locked (either storage)
-	existingLocal, _ := store.Local.ReadLock(target)
-	existingShared, _ := store.Shared.ReadLock(target)
 	
-	if existingLocal != nil && !existingLocal.IsExpired() {
-		return fmt.Errorf("already locked by %s (expires: %s)", 
-			existingLocal.LockedBy, existingLocal.ExpiresAt.Format("15:04"))
+	mode, err := model.ParseLockMode(lockMode)
+	if err != nil {
+		return err
 	}
-	if existingShared != nil && !existingShared.IsExpired() {
-		return fmt.Errorf("already locked by %s (expires: %s)", 
-			existingShared.LockedBy, existingShared.ExpiresAt.Format("15:04"))
+	if lockTimeout < 0 {
+		return fmt.Errorf("--timeout must be positive")
+	}
+	if lockTimeout > 0 && !lockWait {
+		return fmt.Errorf("--timeout needs --wait")
 	}
 	
-	// Create lock
-	lock := model.NewLock(target, author)
+	opts := gitctx.LockOptions{Mode: mode, Reason: lockReason, Shared: flagShared}
+	if lockWait {
+		return waitLock(target, opts)
+	}
 	
-	storage := getStorage()
-	if err := storage.WriteLock(lock); err != nil {
-		return fmt.Errorf("failed to lock: %w", err)
+	l, loc, err := client.LockWith(cmd.Context(), target, opts)
+	if err != nil {
+		return err
 	}
 	
-	storageType := "local"
+	printLocked(l, loc)
+	return nil
+}
+
+// printLocked reports a lock the user now holds, and where it is kept.
+func printLocked(l *model.Lock, loc gitctx.Location) {
+	if !l.IsShared() {
+		fmt.Printf("Locked (%s): %s\n", loc, l.Target)
+		return
+	}
+	fmt.Printf("Locked (%s, shared): %s\n", loc, l.Target)
+	if len(l.Holders) > 1 {
+		fmt.Printf("Held by: %s\n", strings.Join(l.Holders, ", "))
+	}
+}
+
+// waitLock queues for target until it is ours, --timeout passes or the
+// user interrupts.
+func waitLock(target string, lockOpts gitctx.LockOptions) error {
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+	if lockTimeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, lockTimeout)
+		defer cancel()
+	}
+	
+	var last string
+	opts := gitctx.WaitOptions{
+		LockOptions: lockOpts,
+		Waiting: func(err error) {
+			// Only report changes, not every poll
+			if msg := err.Error(); msg != last {
+				fmt.Fprintf(os.Stderr, "Waiting: %s\n", msg)
+				last = msg
+			}
+		},
+	}
 	if flagShared {
-		storageType = "shared"
+		// Sync failures only warn: the wait goes on, and a rejected push
+		// is retried after the next pull
+		remote := cfg.Remote
+		var warned string
+		warn := func(err error) {
+			if msg := err.Error(); msg != warned {
+				fmt.Fprintf(os.Stderr, "Warning: %s\n", msg)
+				warned = msg
+			}
+		}
+		opts.Refresh = func() error {
+			if _, err := client.Pull(ctx, remote); err != nil {
+				warn(fmt.Errorf("failed to pull: %w", err))
+			}
+			return nil
+		}
+		opts.Publish = func() error {
+			if _, err := client.Push(ctx, remote); err != nil {
+				warn(fmt.Errorf("failed to push: %w", err))
+			}
+			return nil
+		}
+	}
+	
+	l, loc, err := client.WaitLock(ctx, target, opts)
+	if err != nil {
+		return err
+	}
+	
+	printLocked(l, loc)
+	return nil
+}
+
+func runLockBreak(cmd *cobra.Command, args []string) error {
+	target := args[0]
+	
+	l, err := client.BreakLock(cmd.Context(), target, lockReason)
+	if err != nil {
+		return err
 	}
-	fmt.Printf("Locked (%s): %s\n", storageType, target)
 	
+	fmt.Printf("Broke lock on %s (held by %s)\n", target, strings.Join(l.HeldBy(), ", "))
 	return nil
 }
 
+func runLockHistory(cmd *cobra.Command, args []string) error {
+	var target string
+	if len(args) > 0 {
+		target = args[0]
+	}
+	
+	loc := gitctx.Local
+	if flagAll {
+		loc = gitctx.All
+	} else if flagShared {
+		loc = gitctx.Shared
+	}
+	
+	events, err := client.LockHistory(cmd.Context(), target, loc)
+	if err != nil {
+		return err
+	}
+	
+	l := &output.List{
+		Columns: []output.Column{
+			{Header: "TIME"},
+			{Header: "EVENT"},
+			{Header: "TARGET"},
+			{Header: "BY"},
+			{Header: "DETAIL", Max: 50},
+		},
+		Items: events,
+		Empty: "No lock history",
+	}
+	for _, e := range events {
+		l.Rows = append(l.Rows, []string{
+			e.At.Local().Format("2006-01-02 15:04"), string(e.Type), e.Target, e.Author, e.Describe(),
+		})
+		l.Raw = append(l.Raw, []string{
+			e.At.Format(time.RFC3339), string(e.Type), e.Target, e.Author, e.Describe(),
+		})
+	}
+	return writeList(cmd, l)
+}
+
+// lockItem is a lock as listings show it.
+type lockItem struct {
+	*model.Lock
+	Shared bool `json:"shared"`
+}
+
 func runLockList(cmd *cobra.Command, args []string) error {
-	var locks []*model.Lock
+	var locks []lockItem
+	collect := func(loc gitctx.Location) error {
+		held, err := client.ListLocks(cmd.Context(), loc)
+		if err != nil {
+			return err
+		}
+		for _, l := range held {
+			locks = append(locks, lockItem{l, loc == gitctx.Shared})
+		}
+		return nil
+	}
 	
-	// Collect locks based on flags
+	// Collect live locks based on flags
 	if flagAll || !flagShared {
-		local, err := store.Local.ListLocks()
-		if err == nil {
-			locks = append(locks, local...)
+		if err := collect(gitctx.Local); err != nil {
+			return err
 		}
 	}
-	
 	if flagAll || flagShared {
-		shared, err := store.Shared.ListLocks()
-		if err == nil {
-			locks = append(locks, shared...)
+		if err := collect(gitctx.Shared); err != nil {
+			return err
 		}
 	}
 	
-	if len(locks) == 0 {
-		fmt.Println("No active locks")
-		return nil
+	l := &output.List{
+		Columns: []output.Column{{Header: "TARGET"}, {Header: "MODE"}, {Header: "HELD BY", Max: 30}, {Header: "EXPIRES"}, {Header: "QUEUE", Max: 30}, {Header: "REASON", Max: 40}},
+		Items:   locks,
+		Empty:   "No active locks",
 	}
-	
-	// Table output
-	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-	
-	fmt.Fprintln(w, "TARGET\tLOCKED BY\tEXPIRES")
-	fmt.Fprintln(w, "------\t---------\t-------")
-	
-	for _, l := range locks {
-		if !l.IsExpired() {
-			fmt.Fprintf(w, "%s\t%s\t%s\n", l.Target, l.LockedBy, l.ExpiresAt.Format("15:04"))
+	for _, item := range locks {
+		waiters := make([]string, len(item.Queue))
+		for i, w := range item.Queue {
+			waiters[i] = w.Agent
 		}
+		queue := strings.Join(waiters, ", ")
+		mode := model.LockExclusive
+		if item.IsShared() {
+			mode = model.LockShared
+		}
+		holders := strings.Join(item.HeldBy(), ", ")
+		l.Rows = append(l.Rows, []string{item.Target, string(mode), holders, item.ExpiresAt.Local().Format("15:04"), orDash(queue), orDash(item.Reason)})
+		l.Raw = append(l.Raw, []string{item.Target, string(mode), holders, item.ExpiresAt.Format(time.RFC3339), queue, item.Reason})
 	}
 	
-	return w.Flush()
+	return writeList(cmd, l)
 }
 
 func runUnlock(cmd *cobra.Command, args []string) error {
@@ -125,56 +332,38 @@ func runUnlock(cmd *cobra.Command, args []string) error {
 	
 	if len(args) == 0 {
 		// Unlock all owned by current user
-		return unlockAll(author)
+		return unlockAll(cmd.Context(), author)
 	}
 	
 	target := args[0]
 	
-	// Try to find and unlock
-	localLock, _ := store.Local.ReadLock(target)
-	if localLock != nil {
-		if !localLock.IsOwnedBy(author) {
-			return fmt.Errorf("cannot unlock: owned by %s", localLock.LockedBy)
-		}
-		store.Local.DeleteLock(target)
-		fmt.Printf("Unlocked (local): %s\n", target)
-		return nil
+	loc, err := client.Unlock(cmd.Context(), target)
+	if err != nil {
+		return err
 	}
 	
-	sharedLock, _ := store.Shared.ReadLock(target)
-	if sharedLock != nil {
-		if !sharedLock.IsOwnedBy(author) {
-			return fmt.Errorf("cannot unlock: owned by %s", sharedLock.LockedBy)
-		}
-		store.Shared.DeleteLock(target)
-		fmt.Printf("Unlocked (shared): %s\n", target)
-		return nil
-	}
-	
-	return fmt.Errorf("not locked: %s", target)
+	fmt.Printf("Unlocked (%s): %s\n", loc, target)
+	return nil
 }
 
-func unlockAll(author string) error {
+func unlockAll(ctx context.Context, author string) error {
 	count := 0
 	
-	// Local locks
-	localLocks, _ := store.Local.ListLocks()
-	for _, l := range localLocks {
-		if l.IsOwnedBy(author) {
-			store.Local.DeleteLock(l.Target)
-			fmt.Printf("Unlocked (local): %s\n", l.Target)
-			count++
-		}
+	locks, err := client.ListLocks(ctx, gitctx.All)
+	if err != nil {
+		return err
 	}
-	
-	// Shared locks
-	sharedLocks, _ := store.Shared.ListLocks()
-	for _, l := range sharedLocks {
-		if l.IsOwnedBy(author) {
-			store.Shared.DeleteLock(l.Target)
-			fmt.Printf("Unlocked (shared): %s\n", l.Target)
-			count++
+	for _, l := range locks {
+		if !l.IsOwnedBy(author) {
+			continue
+		}
+		// Unlock hands the lock to the next waiter, if any
+		loc, err := client.Unlock(ctx, l.Target)
+		if err != nil {
+			return err
 		}
+		fmt.Printf("Unlocked (%s): %s\n", loc, l.Target)
+		count++
 	}
 	
 	if count == 0 {
@@ -183,5 +372,3 @@ func unlockAll(author string) error {
 	
 	return nil
 }
-
-
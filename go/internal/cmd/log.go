@@ -0,0 +1,161 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/user/git-context/gitctx"
+	"github.com/user/git-context/internal/model"
+	"github.com/user/git-context/internal/output"
+)
+
+var (
+	logAuthor string
+	logSince  string
+	logUntil  string
+	logTypes  []string
+)
+
+var logCmd = &cobra.Command{
+	Use:   "log [id]",
+	Short: "Show a timeline of activity",
+	Long: `Show a chronological feed of activity across local and shared context.
+
+Includes memory creations and edits, task creations, claims, drops,
+completions and comments, and lock acquires, renewals, releases and
+breaks from the lock journal. Give an ID (or lock target) to
+see the activity of a single entry.
+
+--since and --until accept a date (2006-01-02), a timestamp (RFC 3339)
+or a duration back from now (30m, 12h, 7d, 2w).
+
+Examples:
+  git ctx log
+  git ctx log --since 7d --author alice
+  git ctx log --type task,lock
+  git ctx log task-abc123
+  git ctx log --json --since 2024-01-01
+  git ctx log --format csv > activity.csv`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runLog,
+}
+
+func init() {
+	logCmd.Flags().StringVar(&logAuthor, "author", "", "Only activity by this author")
+	logCmd.Flags().StringVar(&logSince, "since", "", "Only activity after this time")
+	logCmd.Flags().StringVar(&logUntil, "until", "", "Only activity before this time")
+	logCmd.Flags().StringSliceVarP(&logTypes, "type", "t", nil, "Only these entity types: memory, task, lock")
+	addOutputFlags(logCmd)
+}
+
+func runLog(cmd *cobra.Command, args []string) error {
+	since, err := parseTimeFlag(logSince)
+	if err != nil {
+		return fmt.Errorf("invalid --since: %w", err)
+	}
+	until, err := parseTimeFlag(logUntil)
+	if err != nil {
+		return fmt.Errorf("invalid --until: %w", err)
+	}
+
+	types := make(map[string]bool)
+	for _, typ := range logTypes {
+		switch typ {
+		case model.EntityMemory, model.EntityTask, model.EntityLock:
+			types[typ] = true
+		default:
+			return fmt.Errorf("invalid --type %q (valid: memory, task, lock)", typ)
+		}
+	}
+
+	loc := gitctx.All
+	if flagShared {
+		loc = gitctx.Shared
+	}
+	items, err := client.Activity(context.Background(), loc)
+	if err != nil {
+		return err
+	}
+
+	var filtered []model.Activity
+	for _, a := range items {
+		if len(types) > 0 && !types[a.Entity] {
+			continue
+		}
+		if len(args) > 0 && a.ID != args[0] {
+			continue
+		}
+		if logAuthor != "" && a.Author != logAuthor {
+			continue
+		}
+		if !since.IsZero() && a.At.Before(since) {
+			continue
+		}
+		if !until.IsZero() && a.At.After(until) {
+			continue
+		}
+		filtered = append(filtered, a)
+	}
+
+	l := &output.List{
+		Columns: []output.Column{
+			{Header: "TIME"},
+			{Header: "AUTHOR"},
+			{Header: "EVENT"},
+			{Header: "ID"},
+			{Header: "TITLE", Max: 50},
+		},
+		Items: filtered,
+		Empty: "No activity",
+	}
+	for _, a := range filtered {
+		title := a.Title
+		if a.Detail != "" {
+			title = a.Detail
+		}
+		l.Rows = append(l.Rows, []string{
+			a.At.Format("2006-01-02 15:04"), a.Author, a.Entity + " " + string(a.Type), a.ID, title,
+		})
+		l.Raw = append(l.Raw, []string{
+			a.At.Format(time.RFC3339), a.Author, a.Entity + " " + string(a.Type), a.ID, title,
+		})
+	}
+	return writeList(cmd, l)
+}
+
+// parseTimeFlag parses a --since/--until value. Empty means no limit.
+func parseTimeFlag(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", value, time.UTC); err == nil {
+		return t, nil
+	}
+
+	// Durations back from now; d and w are not understood by time
+	unit := time.Duration(0)
+	switch {
+	case strings.HasSuffix(value, "d"):
+		unit = 24 * time.Hour
+	case strings.HasSuffix(value, "w"):
+		unit = 7 * 24 * time.Hour
+	}
+	if unit > 0 {
+		n, err := strconv.Atoi(value[:len(value)-1])
+		if err == nil && n >= 0 {
+			return time.Now().Add(-time.Duration(n) * unit), nil
+		}
+	} else if d, err := time.ParseDuration(value); err == nil && d >= 0 {
+		return time.Now().Add(-d), nil
+	}
+
+	return time.Time{}, fmt.Errorf("%q is not a date, timestamp or duration", value)
+}
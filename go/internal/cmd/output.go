@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/user/git-context/internal/output"
+)
+
+var (
+	flagFormat   string
+	flagTemplate string
+)
+
+// addOutputFlags adds --format and --template to a listing command.
+func addOutputFlags(c *cobra.Command) {
+	c.Flags().StringVar(&flagFormat, "format", string(output.Table), "Output format: "+output.FormatNames())
+	c.Flags().StringVar(&flagTemplate, "template", "", "Go template for each item, e.g. '{{.ID}} {{.Title}}'")
+}
+
+// writeList prints a listing as chosen by --format, --template and
+// --json, which is short for --format json.
+func writeList(c *cobra.Command, l *output.List) error {
+	format, err := output.ParseFormat(flagFormat)
+	if err != nil {
+		return err
+	}
+	if flagJSON {
+		if c.Flags().Changed("format") && format != output.JSON {
+			return fmt.Errorf("--json conflicts with --format %s", format)
+		}
+		format = output.JSON
+	}
+
+	return output.Write(os.Stdout, l, output.Options{Format: format, Template: flagTemplate})
+}
+
+// orDash is how tables show an empty value.
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// storageTag is how listings show where an entry lives.
+func storageTag(shared bool) string {
+	if shared {
+		return "[shared]"
+	}
+	return "[local]"
+}
@@ -11,7 +11,8 @@ var rmCmd = &cobra.Command{
 	Short: "Remove a context entry",
 	Long: `Remove a context entry from git.
 
-Searches both local and shared storage.
+Searches both local and shared storage. To replace an outdated
+decision, use supersede or archive instead so its history is kept.
 
 Examples:
   git ctx rm abc12345`,
@@ -22,21 +23,18 @@ Examples:
 func runRm(cmd *cobra.Command, args []string) error {
 	id := args[0]
 	
-	// Try local first
-	err := store.Local.DeleteMemory(id)
-	if err == nil {
-		fmt.Printf("Removed (local): %s\n", id)
-		return nil
+	// Local first, then shared
+	_, storageType, err := findMemory(id)
+	if err != nil {
+		return err
 	}
 	
-	// Try shared
-	err = store.Shared.DeleteMemory(id)
-	if err == nil {
-		fmt.Printf("Removed (shared): %s\n", id)
-		return nil
+	if err := client.DeleteMemory(cmd.Context(), id); err != nil {
+		return fmt.Errorf("failed to remove %s: %w", id, err)
 	}
 	
-	return fmt.Errorf("not found: %s", id)
+	fmt.Printf("Removed (%s): %s\n", storageType, id)
+	return nil
 }
 
 
@@ -4,21 +4,25 @@ package cmd
 import (
 	"fmt"
 	"os"
-	"os/exec"
-	"strings"
 
 	"github.com/spf13/cobra"
-	"github.com/user/git-context/internal/storage"
+	"github.com/user/git-context/gitctx"
+	"github.com/user/git-context/internal/config"
+	"github.com/user/git-context/internal/model"
 )
 
 var (
 	// Global flags
 	flagShared bool
+	flagLocal  bool
 	flagAll    bool
 	flagJSON   bool
 	
-	// Storage instances
-	store *storage.MultiStorage
+	// Context of the current repository
+	client *gitctx.Client
+	
+	// Repository settings
+	cfg = config.Default()
 )
 
 var rootCmd = &cobra.Command{
@@ -40,17 +44,21 @@ Examples:
 			return nil
 		}
 		
-		// Find git directory
-		gitDir, err := findGitDir()
-		if err != nil {
-			return fmt.Errorf("not a git repository")
+		// config commands load settings themselves, so a bad value can
+		// still be inspected and fixed
+		if cmd.Parent() == configCmd {
+			return nil
 		}
 		
-		// Initialize storage
-		store, err = storage.NewMultiStorage(gitDir)
+		// Open the repository's context, with its settings
+		var err error
+		client, err = gitctx.Open(".")
 		if err != nil {
-			return fmt.Errorf("failed to initialize storage: %w", err)
+			return err
 		}
+		cfg = client.Config()
+		warnConfig(cfg)
+		applyConfig(cmd)
 		
 		return nil
 	},
@@ -63,6 +71,7 @@ func Execute() error {
 func init() {
 	// Global flags
 	rootCmd.PersistentFlags().BoolVarP(&flagShared, "shared", "s", false, "Use shared storage (syncs with push/pull)")
+	rootCmd.PersistentFlags().BoolVarP(&flagLocal, "local", "l", false, "Use local storage even if storage is set to shared")
 	rootCmd.PersistentFlags().BoolVarP(&flagAll, "all", "a", false, "Show both local and shared")
 	rootCmd.PersistentFlags().BoolVar(&flagJSON, "json", false, "Output as JSON")
 	
@@ -73,28 +82,60 @@ func init() {
 	rootCmd.AddCommand(editCmd)
 	rootCmd.AddCommand(rmCmd)
 	rootCmd.AddCommand(searchCmd)
+	rootCmd.AddCommand(linkCmd)
+	rootCmd.AddCommand(supersedeCmd)
+	rootCmd.AddCommand(archiveCmd)
+	rootCmd.AddCommand(shareCmd)
+	rootCmd.AddCommand(unshareCmd)
+	rootCmd.AddCommand(templateCmd)
 	rootCmd.AddCommand(taskCmd)
 	rootCmd.AddCommand(lockCmd)
 	rootCmd.AddCommand(pushCmd)
 	rootCmd.AddCommand(pullCmd)
+	rootCmd.AddCommand(bundleCmd)
+	rootCmd.AddCommand(statusCmd)
+	rootCmd.AddCommand(logCmd)
+	rootCmd.AddCommand(watchCmd)
+	rootCmd.AddCommand(serveCmd)
+	rootCmd.AddCommand(uiCmd)
+	rootCmd.AddCommand(exportCmd)
+	rootCmd.AddCommand(importCmd)
+	rootCmd.AddCommand(scanCommitsCmd)
+	rootCmd.AddCommand(hooksCmd)
+	rootCmd.AddCommand(configCmd)
+}
+
+// applyConfig applies the repository's settings. --shared and --local
+// override the storage setting.
+func applyConfig(cmd *cobra.Command) {
+	model.DefaultLockExpiry = cfg.LockTTL
+	model.DefaultClaimLease = cfg.ClaimLease
+	model.IDLength = cfg.IDLength
+	
+	if flagLocal {
+		flagShared = false
+	} else if cfg.Storage == "shared" && !cmd.Flags().Changed("shared") {
+		flagShared = true
+	}
 }
 
-// findGitDir finds the .git directory.
-func findGitDir() (string, error) {
-	cmd := exec.Command("git", "rev-parse", "--git-dir")
-	output, err := cmd.Output()
-	if err != nil {
-		return "", err
+// listLocation returns which storages listings read, based on flags.
+func listLocation() gitctx.Location {
+	switch {
+	case flagAll:
+		return gitctx.All
+	case flagShared:
+		return gitctx.Shared
 	}
-	return strings.TrimSpace(string(output)), nil
+	return gitctx.Local
 }
 
-// getStorage returns the appropriate storage based on flags.
-func getStorage() storage.Storage {
+// storageLocation returns where new entries go, based on flags.
+func storageLocation() gitctx.Location {
 	if flagShared {
-		return store.Shared
+		return gitctx.Shared
 	}
-	return store.Local
+	return gitctx.Local
 }
 
 // die prints an error and exits.
@@ -0,0 +1,43 @@
+package cmd
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/spf13/cobra"
+	"github.com/user/git-context/internal/server"
+)
+
+var serveAddr string
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serve context over a local HTTP/JSON API",
+	Long: `Serve memories, tasks, comments and locks over HTTP/JSON.
+
+The API is described by GET /openapi.json. Requests act as the git
+user running the server unless they send an X-Git-Ctx-Author header.
+There is no authentication, so keep the server on a loopback address.
+Requests with a non-loopback Host or a foreign Origin are refused, and
+writes must be sent as Content-Type: application/json.
+
+Examples:
+  git ctx serve
+  git ctx serve --addr 127.0.0.1:9000
+  curl localhost:7373/tasks?status=open
+  curl -X POST -H 'Content-Type: application/json' \
+    -H 'X-Git-Ctx-Author: agent-1' localhost:7373/tasks/task-abc123/claim`,
+	Args: cobra.NoArgs,
+	RunE: runServe,
+}
+
+func init() {
+	serveCmd.Flags().StringVar(&serveAddr, "addr", "127.0.0.1:7373", "Address to listen on")
+}
+
+func runServe(cmd *cobra.Command, args []string) error {
+	srv := server.New(client)
+
+	fmt.Printf("Serving on http://%s (API description at /openapi.json)\n", serveAddr)
+	return http.ListenAndServe(serveAddr, srv)
+}
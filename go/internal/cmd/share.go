@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+var shareCmd = &cobra.Command{
+	Use:   "share <id>",
+	Short: "Move an entry or task to shared storage",
+	Long: `Move a memory or task from local to shared storage.
+
+The ID, timestamps, links and comments are kept. Run push afterwards
+to publish it.
+
+Examples:
+  git ctx share abc12345
+  git ctx share task-abc123`,
+	Args: cobra.ExactArgs(1),
+	RunE: runShare,
+}
+
+var unshareCmd = &cobra.Command{
+	Use:   "unshare <id>",
+	Short: "Move an entry or task back to local storage",
+	Long: `Move a memory or task from shared back to local storage.
+
+The shared copy is deleted, so after the next push it disappears for
+everyone else too. Its history stays in the shared refs.
+
+Examples:
+  git ctx unshare abc12345`,
+	Args: cobra.ExactArgs(1),
+	RunE: runUnshare,
+}
+
+func runShare(cmd *cobra.Command, args []string) error {
+	if err := client.Share(context.Background(), args[0]); err != nil {
+		return err
+	}
+	fmt.Printf("Moved to shared: %s\n", args[0])
+	return nil
+}
+
+func runUnshare(cmd *cobra.Command, args []string) error {
+	if err := client.Unshare(context.Background(), args[0]); err != nil {
+		return err
+	}
+	fmt.Printf("Moved to local: %s\n", args[0])
+	return nil
+}
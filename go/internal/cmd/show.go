@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 
@@ -26,9 +27,9 @@ func runShow(cmd *cobra.Command, args []string) error {
 	id := args[0]
 	
 	// Try local first, then shared
-	m, storageType := findMemory(id)
-	if m == nil {
-		return fmt.Errorf("not found: %s", id)
+	m, storageType, err := findMemory(id)
+	if err != nil {
+		return err
 	}
 	
 	if flagJSON {
@@ -43,28 +44,36 @@ func runShow(cmd *cobra.Command, args []string) error {
 	// Pretty print
 	fmt.Println("════════════════════════════════════════════════════════════")
 	fmt.Printf("  %s\n", m.Title)
-	fmt.Printf("  by %s • %s • [%s]\n", m.Author, m.CreatedAt.Format("2006-01-02T15:04:05Z"), storageType)
+	kind := m.Kind
+	if kind == "" {
+		kind = model.KindNote
+	}
+	fmt.Printf("  %s by %s • %s • [%s]\n", kind, m.Author, m.CreatedAt.Format("2006-01-02T15:04:05Z"), storageType)
 	fmt.Println("════════════════════════════════════════════════════════════")
+	
+	if m.SupersededBy != "" {
+		fmt.Printf("\n  ⚠ Superseded by %s\n", describeEntity(m.SupersededBy))
+		fmt.Printf("    git ctx show %s\n", m.SupersededBy)
+	} else if m.ArchivedAt != nil {
+		fmt.Printf("\n  ⚠ Archived %s\n", m.ArchivedAt.Format("2006-01-02"))
+	}
+	
 	fmt.Println()
 	fmt.Println(m.Content)
 	
+	printLinks(m.ID, m.Links, m.Content)
+	
 	return nil
 }
 
-func findMemory(id string) (*model.Memory, string) {
-	// Try local
-	m, err := store.Local.ReadMemory(id)
-	if err == nil && m != nil {
-		return m, "local"
-	}
-	
-	// Try shared
-	m, err = store.Shared.ReadMemory(id)
-	if err == nil && m != nil {
-		return m, "shared"
+// findMemory looks up a memory in local, then shared storage, and says
+// where it was found.
+func findMemory(id string) (*model.Memory, string, error) {
+	m, err := client.GetMemory(context.Background(), id)
+	if err != nil {
+		return nil, "", err
 	}
-	
-	return nil, ""
+	return m, storageLabel(m.Shared), nil
 }
 
 
@@ -0,0 +1,188 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/user/git-context/gitctx"
+	"github.com/user/git-context/internal/model"
+)
+
+var statusNoFetch bool
+
+var statusCmd = &cobra.Command{
+	Use:   "status [remote]",
+	Short: "Show sync status of shared context",
+	Long: `Show how shared context compares with the remote, and what you hold.
+
+Fetches from the remote first (use --no-fetch to work offline against
+the last fetch), then shows for each namespace how many changes are
+not yet pushed (↑) and not yet pulled (↓), the entries involved, and
+the tasks and locks you currently hold.
+
+Examples:
+  git ctx status
+  git ctx status --no-fetch
+  git ctx status upstream --json`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runStatus,
+}
+
+func init() {
+	statusCmd.Flags().BoolVar(&statusNoFetch, "no-fetch", false, "Don't fetch; compare with the last fetch")
+}
+
+// statusReport is the --json form of status.
+type statusReport struct {
+	Remote     string                   `json:"remote"`
+	Fetched    bool                     `json:"fetched"`
+	Namespaces []gitctx.NamespaceStatus `json:"namespaces"`
+	Tasks      []*model.Task            `json:"tasks"`
+	Locks      []*model.Lock            `json:"locks"`
+}
+
+func runStatus(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+	remote := remoteArg(args)
+
+	report := statusReport{Remote: remote}
+	if !statusNoFetch {
+		if err := client.Fetch(ctx, remote); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: could not fetch %s, showing last known state: %v\n", remote, err)
+		} else {
+			report.Fetched = true
+		}
+	}
+
+	namespaces, err := client.SyncStatus(ctx, remote)
+	if err != nil {
+		return fmt.Errorf("failed to compare with %s: %w", remote, err)
+	}
+	report.Namespaces = namespaces
+	report.Tasks, report.Locks, err = heldByMe(ctx)
+	if err != nil {
+		return err
+	}
+
+	if flagJSON {
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	printStatus(&report)
+	return nil
+}
+
+// heldByMe returns the unfinished tasks owned by and the live locks
+// held by the current user, from both storages.
+func heldByMe(ctx context.Context) ([]*model.Task, []*model.Lock, error) {
+	author := client.Author()
+	workflow, err := client.Workflow(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var tasks []*model.Task
+	owned, err := client.ListTasks(ctx, gitctx.TaskFilter{Owner: author})
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, t := range owned {
+		if !workflow.IsDone(t.Status) {
+			tasks = append(tasks, t)
+		}
+	}
+
+	var locks []*model.Lock
+	live, err := client.ListLocks(ctx, gitctx.All)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, l := range live {
+		if l.IsOwnedBy(author) {
+			locks = append(locks, l)
+		}
+	}
+	return tasks, locks, nil
+}
+
+func printStatus(report *statusReport) {
+	state := "as of last fetch"
+	if report.Fetched {
+		state = "fetched just now"
+	}
+	fmt.Printf("Shared context vs %s (%s)\n\n", report.Remote, state)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, ns := range report.Namespaces {
+		fmt.Fprintf(w, "  %s\t↑%d\t↓%d\n", ns.Namespace, ns.Ahead, ns.Behind)
+	}
+	w.Flush()
+
+	printChanges("Not pushed", report.Namespaces, func(ns gitctx.NamespaceStatus) []gitctx.EntryChange {
+		return ns.Outgoing
+	})
+	printChanges("Incoming (run 'git ctx pull')", report.Namespaces, func(ns gitctx.NamespaceStatus) []gitctx.EntryChange {
+		return ns.Incoming
+	})
+
+	fmt.Println()
+	if len(report.Tasks) == 0 {
+		fmt.Println("No tasks in progress")
+	} else {
+		fmt.Println("Your tasks:")
+		w = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		for _, t := range report.Tasks {
+			fmt.Fprintf(w, "  %s\t%s\t[%s]\n", t.ID, t.Title, storageLabel(t.Shared))
+		}
+		w.Flush()
+	}
+
+	if len(report.Locks) == 0 {
+		fmt.Println("No locks held")
+	} else {
+		fmt.Println("Your locks:")
+		w = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		for _, l := range report.Locks {
+			fmt.Fprintf(w, "  %s\texpires %s\n", l.Target, l.ExpiresAt.Format("15:04"))
+		}
+		w.Flush()
+	}
+}
+
+func printChanges(heading string, namespaces []gitctx.NamespaceStatus, pick func(gitctx.NamespaceStatus) []gitctx.EntryChange) {
+	var found bool
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, ns := range namespaces {
+		for _, c := range pick(ns) {
+			if !found {
+				fmt.Printf("\n%s:\n", heading)
+				found = true
+			}
+			note := ""
+			switch {
+			case c.Deleted:
+				note = "(deleted)"
+			case c.New:
+				note = "(new)"
+			}
+			fmt.Fprintf(w, "  %s/%s\t%s\t%s\n", ns.Namespace, c.Name, c.Title, note)
+		}
+	}
+	w.Flush()
+}
+
+func storageLabel(shared bool) string {
+	if shared {
+		return "shared"
+	}
+	return "local"
+}
@@ -1,55 +1,106 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
+	"strings"
 
 	"github.com/spf13/cobra"
+	"github.com/user/git-context/gitctx"
 )
 
 var pushCmd = &cobra.Command{
-	Use:   "push",
+	Use:   "push [remote]",
 	Short: "Push shared context to remote",
 	Long: `Push shared context entries to the remote repository.
 
-Only shared entries are pushed (created with --shared flag).
+Only shared entries are pushed (created with --shared flag). Entries
+the remote has changed since your last pull are rejected; run pull
+first to merge them.
 
 Examples:
-  git ctx push`,
+  git ctx push
+  git ctx push upstream`,
+	Args: cobra.MaximumNArgs(1),
 	RunE: runPush,
 }
 
 var pullCmd = &cobra.Command{
-	Use:   "pull",
+	Use:   "pull [remote]",
 	Short: "Pull shared context from remote",
 	Long: `Pull shared context entries from the remote repository.
 
+Entries changed on both sides are merged: the most recent change wins,
+and task comments and links from both sides are kept.
+
 Examples:
-  git ctx pull`,
+  git ctx pull
+  git ctx pull upstream`,
+	Args: cobra.MaximumNArgs(1),
 	RunE: runPull,
 }
 
 func runPush(cmd *cobra.Command, args []string) error {
-	// TODO: Implement git refs push
-	// For now, using the Bash version's approach would work:
-	// git push origin 'refs/context/*:refs/context/*'
-	
-	fmt.Println("Push not yet implemented in Go version.")
-	fmt.Println("Use the Bash version for push/pull, or run:")
-	fmt.Println("  git push origin 'refs/context/*:refs/context/*'")
-	
+	remote := remoteArg(args)
+
+	result, err := client.Push(context.Background(), remote)
+	if err != nil {
+		return fmt.Errorf("failed to push: %w", err)
+	}
+
+	if len(result.Pushed) == 0 && len(result.Rejected) == 0 {
+		fmt.Println("Everything up to date")
+	} else if len(result.Pushed) > 0 {
+		fmt.Printf("Pushed %d entries to %s\n", len(result.Pushed), remote)
+	}
+
+	if len(result.Rejected) > 0 {
+		for _, ref := range result.Rejected {
+			fmt.Printf("  rejected: %s\n", refName(ref))
+		}
+		return fmt.Errorf("%w: %d entries changed on %s; run 'git ctx pull %s' first", gitctx.ErrConflict, len(result.Rejected), remote, remote)
+	}
+
 	return nil
 }
 
 func runPull(cmd *cobra.Command, args []string) error {
-	// TODO: Implement git refs pull
-	// For now, using the Bash version's approach would work:
-	// git fetch origin 'refs/context/*:refs/context/*'
-	
-	fmt.Println("Pull not yet implemented in Go version.")
-	fmt.Println("Use the Bash version for push/pull, or run:")
-	fmt.Println("  git fetch origin 'refs/context/*:refs/context/*'")
-	
+	remote := remoteArg(args)
+
+	result, err := client.Pull(context.Background(), remote)
+	if err != nil {
+		return fmt.Errorf("failed to pull: %w", err)
+	}
+
+	printMergeResult(result)
 	return nil
 }
 
+// remoteArg returns the remote named in args, or the configured one.
+func remoteArg(args []string) string {
+	if len(args) > 0 {
+		return args[0]
+	}
+	return cfg.Remote
+}
+
+// refName turns refs/context/tasks/task-abc into tasks/task-abc.
+func refName(ref string) string {
+	return strings.TrimPrefix(ref, "refs/context/")
+}
+
+func printMergeResult(result *gitctx.MergeResult) {
+	for ref, err := range result.Invalid {
+		fmt.Printf("  skipped: %s (%v)\n", refName(ref), err)
+	}
+	
+	if result.Changed() == 0 {
+		fmt.Println("Already up to date")
+		return
+	}
 
+	for _, ref := range result.Merged {
+		fmt.Printf("  merged: %s\n", refName(ref))
+	}
+	fmt.Printf("%d new, %d updated, %d merged\n", len(result.Added), len(result.Updated), len(result.Merged))
+}
@@ -1,18 +1,22 @@
 package cmd
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
-	"os"
 	"strings"
-	"text/tabwriter"
 
 	"github.com/spf13/cobra"
+	"github.com/user/git-context/gitctx"
 	"github.com/user/git-context/internal/model"
+	"github.com/user/git-context/internal/output"
 )
 
 var (
 	taskDescription string
+	taskReviewer    string
+	taskLockPaths   []string
+	taskListStatus  string
 )
 
 var taskCmd = &cobra.Command{
@@ -42,7 +46,10 @@ var taskListCmd = &cobra.Command{
 Examples:
   git ctx task list           # Local tasks
   git ctx task list --shared  # Shared tasks
-  git ctx task list --all     # Everything`,
+  git ctx task list --all     # Everything
+  git ctx task list --status open  # Including lapsed claims
+  git ctx task list --format markdown
+  git ctx task list --template '{{.ID}} {{.Status}} {{.Title}}'`,
 	RunE: runTaskList,
 }
 
@@ -56,6 +63,16 @@ var taskShowCmd = &cobra.Command{
 var taskClaimCmd = &cobra.Command{
 	Use:   "claim <id>",
 	Short: "Claim a task (take ownership)",
+	Long: `Claim a task, taking ownership of it.
+
+--lock adds paths to the task and locks them along with any it already
+has. If someone else holds a lock on one of them, or on a directory
+containing it or a file inside it, nothing is claimed or locked. The
+locks are released when the task is dropped or done.
+
+Examples:
+  git ctx task claim task-abc123
+  git ctx task claim task-abc123 --lock src/auth/ --lock go.mod`,
 	Args:  cobra.ExactArgs(1),
 	RunE:  runTaskClaim,
 }
@@ -74,6 +91,88 @@ var taskDoneCmd = &cobra.Command{
 	RunE:  runTaskDone,
 }
 
+var taskMoveCmd = &cobra.Command{
+	Use:   "move <id> <status>",
+	Short: "Move a task to another status",
+	Long: `Move a task to another status of the workflow. Only moves the
+workflow allows are accepted; see "git ctx task workflow show".
+
+Moving to claimed takes ownership, and moving to open releases it.
+Review is not a plain move: use "task request-review", which names the
+reviewer, and "task approve" or "task reject" to leave it.
+
+Examples:
+  git ctx task move task-abc123 wontfix
+  git ctx task move task-abc123 open`,
+	Args: cobra.ExactArgs(2),
+	RunE: runTaskMove,
+}
+
+var taskAssignCmd = &cobra.Command{
+	Use:   "assign <id> <agent>",
+	Short: "Assign a task to an agent",
+	Long: `Claim a task on another agent's behalf, so an orchestrator can
+dispatch work. The task's paths are locked for the agent, and the
+assignment fails if anyone else holds a lock on one of them. A task
+that is already claimed is reassigned, passing its locks on.
+
+Examples:
+  git ctx task assign task-abc123 agent-2`,
+	Args: cobra.ExactArgs(2),
+	RunE: runTaskAssign,
+}
+
+var taskRequestReviewCmd = &cobra.Command{
+	Use:   "request-review <id>",
+	Short: "Hand a task you own to a reviewer",
+	Long: `Move a task you own into review. The reviewer approves it, which
+marks it done, or rejects it, which hands it back to you.
+
+Examples:
+  git ctx task request-review task-abc123 --reviewer lead`,
+	Args: cobra.ExactArgs(1),
+	RunE: runTaskRequestReview,
+}
+
+var taskApproveCmd = &cobra.Command{
+	Use:   "approve <id> <comment>",
+	Short: "Approve a task in review and mark it done",
+	Args:  cobra.ExactArgs(2),
+	RunE:  runTaskApprove,
+}
+
+var taskRejectCmd = &cobra.Command{
+	Use:   "reject <id> <comment>",
+	Short: "Send a task in review back to its owner",
+	Args:  cobra.ExactArgs(2),
+	RunE:  runTaskReject,
+}
+
+var taskHeartbeatCmd = &cobra.Command{
+	Use:   "heartbeat <id>",
+	Short: "Renew the lease on a task you claimed",
+	Long: `Renew the lease on a task you claimed. Claims lapse after the
+task.lease setting (2h by default) without a heartbeat; a lapsed claim
+shows as stale, anyone can claim the task, and "task reap" reopens it.
+
+Examples:
+  git ctx task heartbeat task-abc123`,
+	Args: cobra.ExactArgs(1),
+	RunE: runTaskHeartbeat,
+}
+
+var taskReapCmd = &cobra.Command{
+	Use:   "reap",
+	Short: "Reopen tasks whose claims have lapsed",
+	Long: `Reopen every claimed task whose lease has lapsed, adding a comment
+that records whose claim it was and when it expired.
+
+Examples:
+  git ctx task reap`,
+	Args: cobra.NoArgs,
+	RunE: runTaskReap,
+}
+
 var taskCommentCmd = &cobra.Command{
 	Use:   "comment <id> <message>",
 	Short: "Add a comment to a task",
@@ -88,100 +187,85 @@ func init() {
 	taskCmd.AddCommand(taskClaimCmd)
 	taskCmd.AddCommand(taskDropCmd)
 	taskCmd.AddCommand(taskDoneCmd)
+	taskCmd.AddCommand(taskMoveCmd)
+	taskCmd.AddCommand(taskAssignCmd)
+	taskCmd.AddCommand(taskRequestReviewCmd)
+	taskCmd.AddCommand(taskApproveCmd)
+	taskCmd.AddCommand(taskRejectCmd)
+	taskCmd.AddCommand(taskHeartbeatCmd)
+	taskCmd.AddCommand(taskReapCmd)
 	taskCmd.AddCommand(taskCommentCmd)
+	taskCmd.AddCommand(taskWorkflowCmd)
 	
 	taskAddCmd.Flags().StringVarP(&taskDescription, "description", "d", "", "Task description")
+	taskClaimCmd.Flags().StringSliceVar(&taskLockPaths, "lock", nil, "Lock a path the task touches (repeatable)")
+	taskRequestReviewCmd.Flags().StringVarP(&taskReviewer, "reviewer", "r", "", "Who reviews the task")
+	taskRequestReviewCmd.MarkFlagRequired("reviewer")
+	taskListCmd.Flags().StringVar(&taskListStatus, "status", "", "Only tasks with this status; open includes lapsed claims")
+	addOutputFlags(taskListCmd)
 }
 
 func runTaskAdd(cmd *cobra.Command, args []string) error {
 	title := strings.Join(args, " ")
-	author := model.GetAuthorShort()
 	
-	t := model.NewTask(title, taskDescription, author, flagShared)
-	
-	storage := getStorage()
-	if err := storage.WriteTask(t); err != nil {
-		return fmt.Errorf("failed to create task: %w", err)
+	t, err := client.AddTask(context.Background(), title, gitctx.TaskOptions{
+		Description: taskDescription,
+		Shared:      flagShared,
+	})
+	if err != nil {
+		return err
 	}
 	
-	storageType := "local"
-	if flagShared {
-		storageType = "shared"
-	}
-	fmt.Printf("Created (%s): %s\n", storageType, t.ID)
+	fmt.Printf("Created (%s): %s\n", storageLabel(t.Shared), t.ID)
 	
 	return nil
 }
 
 func runTaskList(cmd *cobra.Command, args []string) error {
-	var tasks []*model.Task
-	
 	// Collect tasks based on flags
-	if flagAll || !flagShared {
-		local, err := store.Local.ListTasks()
-		if err != nil {
-			return fmt.Errorf("failed to list local tasks: %w", err)
-		}
-		for _, t := range local {
-			t.Shared = false
-			tasks = append(tasks, t)
-		}
+	tasks, err := client.ListTasks(context.Background(), gitctx.TaskFilter{
+		Location: listLocation(),
+		Status:   model.TaskStatus(taskListStatus),
+	})
+	if err != nil {
+		return err
 	}
 	
-	if flagAll || flagShared {
-		shared, err := store.Shared.ListTasks()
-		if err != nil {
-			return fmt.Errorf("failed to list shared tasks: %w", err)
-		}
-		for _, t := range shared {
-			t.Shared = true
-			tasks = append(tasks, t)
-		}
+	l := &output.List{
+		Columns: []output.Column{
+			{Header: "ID"},
+			{Header: "TITLE", Max: 35},
+			{Header: "STATUS"},
+			{Header: "TYPE"},
+			{Header: "OWNER"},
+		},
+		Items: tasks,
 	}
 	
-	if flagJSON {
-		data, err := json.MarshalIndent(tasks, "", "  ")
-		if err != nil {
-			return err
-		}
-		fmt.Println(string(data))
-		return nil
-	}
-	
-	// Table output
-	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-	
-	fmt.Fprintln(w, "ID\tTITLE\tSTATUS\tTYPE\tOWNER")
-	fmt.Fprintln(w, "----\t-----\t------\t----\t-----")
-	
 	for _, t := range tasks {
-		typeStr := "[local]"
-		if t.Shared {
-			typeStr = "[shared]"
-		}
-		
-		title := t.Title
-		if len(title) > 35 {
-			title = title[:32] + "..."
-		}
-		
 		owner := t.Owner
 		if owner == "" {
 			owner = "-"
 		}
 		
-		fmt.Fprintf(w, "%s\t%s\t[%s]\t%s\t%s\n", t.ID, title, t.Status, typeStr, owner)
+		status := string(t.Status)
+		if t.IsStale() {
+			status += ", stale"
+		}
+		
+		l.Rows = append(l.Rows, []string{t.ID, t.Title, "[" + status + "]", storageTag(t.Shared), owner})
+		l.Raw = append(l.Raw, []string{t.ID, t.Title, string(t.Status), storageLabel(t.Shared), t.Owner})
 	}
 	
-	return w.Flush()
+	return writeList(cmd, l)
 }
 
 func runTaskShow(cmd *cobra.Command, args []string) error {
 	id := args[0]
 	
-	t, storageType := findTask(id)
-	if t == nil {
-		return fmt.Errorf("not found: %s", id)
+	t, storageType, err := findTask(id)
+	if err != nil {
+		return err
 	}
 	
 	if flagJSON {
@@ -208,10 +292,28 @@ func runTaskShow(cmd *cobra.Command, args []string) error {
 		fmt.Printf("\nOwner: %s\n", t.Owner)
 	}
 	
+	if t.LeaseExpiresAt != nil {
+		lease := "until " + t.LeaseExpiresAt.Local().Format("2006-01-02 15:04")
+		if t.IsStale() {
+			lease = "expired " + t.LeaseExpiresAt.Local().Format("2006-01-02 15:04") + " (stale)"
+		}
+		fmt.Printf("Lease: %s\n", lease)
+	}
+	
+	if t.Reviewer != "" {
+		fmt.Printf("Reviewer: %s\n", t.Reviewer)
+	}
+	
+	if len(t.Paths) > 0 {
+		fmt.Printf("Paths: %s\n", strings.Join(t.Paths, ", "))
+	}
+	
 	if len(t.BlockedBy) > 0 {
 		fmt.Printf("\nBlocked by: %s\n", strings.Join(t.BlockedBy, ", "))
 	}
 	
+	printLinks(t.ID, t.Links, "")
+	
 	if len(t.Comments) > 0 {
 		fmt.Println("\nComments:")
 		for _, c := range t.Comments {
@@ -224,134 +326,152 @@ func runTaskShow(cmd *cobra.Command, args []string) error {
 
 func runTaskClaim(cmd *cobra.Command, args []string) error {
 	id := args[0]
-	author := model.GetAuthorShort()
-	
-	t, storageType := findTask(id)
-	if t == nil {
-		return fmt.Errorf("not found: %s", id)
-	}
-	
-	if t.Status == model.TaskClaimed && t.Owner != author {
-		return fmt.Errorf("already claimed by %s", t.Owner)
-	}
-	
-	t.Claim(author)
-	
-	// Save to correct storage
-	var err error
-	if storageType == "local" {
-		err = store.Local.WriteTask(t)
-	} else {
-		err = store.Shared.WriteTask(t)
-	}
 	
+	t, err := client.ClaimTaskAndLock(cmd.Context(), id, taskLockPaths)
 	if err != nil {
-		return fmt.Errorf("failed to claim: %w", err)
+		return err
 	}
 	
 	fmt.Printf("Claimed: %s\n", id)
+	if len(t.Paths) > 0 {
+		fmt.Printf("Locked: %s\n", strings.Join(t.Paths, ", "))
+	}
 	return nil
 }
 
 func runTaskDrop(cmd *cobra.Command, args []string) error {
 	id := args[0]
-	author := model.GetAuthorShort()
 	
-	t, storageType := findTask(id)
-	if t == nil {
-		return fmt.Errorf("not found: %s", id)
+	if _, err := client.DropTask(cmd.Context(), id); err != nil {
+		return err
 	}
 	
-	if t.Owner != author {
-		return fmt.Errorf("not owned by you (owner: %s)", t.Owner)
+	fmt.Printf("Dropped: %s\n", id)
+	return nil
+}
+
+func runTaskDone(cmd *cobra.Command, args []string) error {
+	id := args[0]
+	
+	if _, err := client.CompleteTask(cmd.Context(), id); err != nil {
+		return err
 	}
 	
-	t.Drop()
+	fmt.Printf("Done: %s\n", id)
+	return nil
+}
+
+func runTaskMove(cmd *cobra.Command, args []string) error {
+	id := args[0]
+	status := model.TaskStatus(args[1])
 	
-	// Save to correct storage
-	var err error
-	if storageType == "local" {
-		err = store.Local.WriteTask(t)
-	} else {
-		err = store.Shared.WriteTask(t)
+	if _, err := client.MoveTask(cmd.Context(), id, status); err != nil {
+		return err
 	}
 	
-	if err != nil {
-		return fmt.Errorf("failed to drop: %w", err)
+	fmt.Printf("Moved: %s -> %s\n", id, status)
+	return nil
+}
+
+func runTaskAssign(cmd *cobra.Command, args []string) error {
+	id, agent := args[0], args[1]
+	
+	if _, err := client.AssignTask(cmd.Context(), id, agent); err != nil {
+		return err
 	}
 	
-	fmt.Printf("Dropped: %s\n", id)
+	fmt.Printf("Assigned: %s -> %s\n", id, agent)
 	return nil
 }
 
-func runTaskDone(cmd *cobra.Command, args []string) error {
+func runTaskRequestReview(cmd *cobra.Command, args []string) error {
 	id := args[0]
 	
-	t, storageType := findTask(id)
-	if t == nil {
-		return fmt.Errorf("not found: %s", id)
+	if _, err := client.RequestReview(cmd.Context(), id, taskReviewer); err != nil {
+		return err
 	}
 	
-	t.Done()
-	
-	// Save to correct storage
-	var err error
-	if storageType == "local" {
-		err = store.Local.WriteTask(t)
-	} else {
-		err = store.Shared.WriteTask(t)
-	}
+	fmt.Printf("Review requested: %s (reviewer: %s)\n", id, taskReviewer)
+	return nil
+}
+
+func runTaskApprove(cmd *cobra.Command, args []string) error {
+	id := args[0]
 	
-	if err != nil {
-		return fmt.Errorf("failed to mark done: %w", err)
+	if _, err := client.ApproveTask(cmd.Context(), id, args[1]); err != nil {
+		return err
 	}
 	
-	fmt.Printf("Done: %s\n", id)
+	fmt.Printf("Approved: %s\n", id)
 	return nil
 }
 
-func runTaskComment(cmd *cobra.Command, args []string) error {
+func runTaskReject(cmd *cobra.Command, args []string) error {
 	id := args[0]
-	message := args[1]
-	author := model.GetAuthorShort()
 	
-	t, storageType := findTask(id)
-	if t == nil {
-		return fmt.Errorf("not found: %s", id)
+	if _, err := client.RejectTask(cmd.Context(), id, args[1]); err != nil {
+		return err
 	}
 	
-	t.AddComment(author, message)
+	fmt.Printf("Rejected: %s\n", id)
+	return nil
+}
+
+func runTaskHeartbeat(cmd *cobra.Command, args []string) error {
+	id := args[0]
 	
-	// Save to correct storage
-	var err error
-	if storageType == "local" {
-		err = store.Local.WriteTask(t)
-	} else {
-		err = store.Shared.WriteTask(t)
+	t, err := client.HeartbeatTask(cmd.Context(), id)
+	if err != nil {
+		return err
 	}
 	
+	fmt.Printf("Lease renewed: %s (until %s)\n", id, t.LeaseExpiresAt.Local().Format("15:04"))
+	return nil
+}
+
+func runTaskReap(cmd *cobra.Command, args []string) error {
+	reaped, err := client.ReapTasks(cmd.Context(), gitctx.All)
+	for _, t := range reaped {
+		fmt.Printf("Reopened: %s (was claimed by %s)\n", t.ID, lastReaped(t))
+	}
 	if err != nil {
-		return fmt.Errorf("failed to add comment: %w", err)
+		return err
 	}
 	
-	fmt.Printf("Comment added to: %s\n", id)
+	if len(reaped) == 0 {
+		fmt.Println("No stale claims")
+	}
 	return nil
 }
 
-func findTask(id string) (*model.Task, string) {
-	// Try local
-	t, err := store.Local.ReadTask(id)
-	if err == nil && t != nil {
-		return t, "local"
+// lastReaped returns whose claim was released by the last reap.
+func lastReaped(t *model.Task) string {
+	for i := len(t.History) - 1; i >= 0; i-- {
+		if t.History[i].Type == model.EventReaped {
+			return t.History[i].Detail
+		}
 	}
+	return ""
+}
+
+func runTaskComment(cmd *cobra.Command, args []string) error {
+	id := args[0]
+	message := args[1]
 	
-	// Try shared
-	t, err = store.Shared.ReadTask(id)
-	if err == nil && t != nil {
-		return t, "shared"
+	if _, err := client.CommentTask(cmd.Context(), id, message); err != nil {
+		return err
 	}
 	
-	return nil, ""
+	fmt.Printf("Comment added to: %s\n", id)
+	return nil
+}
+
+func findTask(id string) (*model.Task, string, error) {
+	t, err := client.GetTask(context.Background(), id)
+	if err != nil {
+		return nil, "", err
+	}
+	return t, storageLabel(t.Shared), nil
 }
 
 
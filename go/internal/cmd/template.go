@@ -0,0 +1,172 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/user/git-context/internal/model"
+	"github.com/user/git-context/internal/output"
+)
+
+var templateCmd = &cobra.Command{
+	Use:   "template",
+	Short: "Manage editor templates for entry kinds",
+	Long: `Manage the editor templates used by "git ctx add --kind".
+
+Templates are Go text/templates with {{.Title}}, {{.Author}}, {{.Kind}}
+and {{.Date}} available. Overrides are stored in context storage: use
+--shared to override a template for everyone in the repository. Local
+overrides take precedence over shared ones, which take precedence over
+the built-in templates.
+
+Examples:
+  git ctx template list
+  git ctx template show decision
+  git ctx template edit decision --shared
+  git ctx template reset decision --shared`,
+}
+
+var templateListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List templates and where they come from",
+	RunE:  runTemplateList,
+}
+
+var templateShowCmd = &cobra.Command{
+	Use:   "show <kind>",
+	Short: "Show the template used for a kind",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runTemplateShow,
+}
+
+var templateEditCmd = &cobra.Command{
+	Use:   "edit <kind>",
+	Short: "Override the template for a kind",
+	Long: `Open the current template for a kind in your editor and save the
+result as an override. Content can also be piped via stdin.
+
+Examples:
+  git ctx template edit decision
+  git ctx template edit bug --shared < bug-template.md`,
+	Args: cobra.ExactArgs(1),
+	RunE: runTemplateEdit,
+}
+
+var templateResetCmd = &cobra.Command{
+	Use:   "reset <kind>",
+	Short: "Remove a template override",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runTemplateReset,
+}
+
+func init() {
+	templateCmd.AddCommand(templateListCmd)
+	templateCmd.AddCommand(templateShowCmd)
+	templateCmd.AddCommand(templateEditCmd)
+	templateCmd.AddCommand(templateResetCmd)
+	addOutputFlags(templateListCmd)
+}
+
+// loadTemplate returns the template text for a kind and where it came
+// from: "local", "shared" or "built-in".
+func loadTemplate(kind model.MemoryKind) (string, string) {
+	text, loc, err := client.Template(context.Background(), kind)
+	if err != nil || loc == "" {
+		return model.DefaultTemplates[kind], "built-in"
+	}
+	return text, string(loc)
+}
+
+// templateItem is a template as listings show it.
+type templateItem struct {
+	Kind   model.MemoryKind `json:"kind"`
+	Source string           `json:"source"`
+}
+
+func runTemplateList(cmd *cobra.Command, args []string) error {
+	var templates []templateItem
+	for _, kind := range model.MemoryKinds {
+		_, source := loadTemplate(kind)
+		templates = append(templates, templateItem{kind, source})
+	}
+
+	l := &output.List{
+		Columns: []output.Column{{Header: "KIND"}, {Header: "SOURCE"}},
+		Items:   templates,
+	}
+	for _, t := range templates {
+		l.Rows = append(l.Rows, []string{string(t.Kind), t.Source})
+	}
+	return writeList(cmd, l)
+}
+
+func runTemplateShow(cmd *cobra.Command, args []string) error {
+	kind, err := model.ParseKind(args[0])
+	if err != nil {
+		return err
+	}
+
+	text, _ := loadTemplate(kind)
+	fmt.Print(text)
+	return nil
+}
+
+func runTemplateEdit(cmd *cobra.Command, args []string) error {
+	kind, err := model.ParseKind(args[0])
+	if err != nil {
+		return err
+	}
+
+	var text string
+	stat, _ := os.Stdin.Stat()
+	if (stat.Mode() & os.ModeCharDevice) == 0 {
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return fmt.Errorf("failed to read stdin: %w", err)
+		}
+		text = string(data)
+	} else {
+		current, _ := loadTemplate(kind)
+		text, err = editText(current)
+		if err != nil {
+			return fmt.Errorf("editor failed: %w", err)
+		}
+	}
+
+	if strings.TrimSpace(text) == "" {
+		return fmt.Errorf("empty template, not saved")
+	}
+
+	if err := client.SetTemplate(context.Background(), kind, text, storageLocation()); err != nil {
+		return err
+	}
+
+	storageType := "local"
+	if flagShared {
+		storageType = "shared"
+	}
+	fmt.Printf("Saved template (%s): %s\n", storageType, kind)
+	return nil
+}
+
+func runTemplateReset(cmd *cobra.Command, args []string) error {
+	kind, err := model.ParseKind(args[0])
+	if err != nil {
+		return err
+	}
+
+	if err := client.ResetTemplate(context.Background(), kind, storageLocation()); err != nil {
+		return err
+	}
+
+	storageType := "local"
+	if flagShared {
+		storageType = "shared"
+	}
+	fmt.Printf("Reset template (%s): %s\n", storageType, kind)
+	return nil
+}
@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/user/git-context/internal/tui"
+)
+
+var uiInterval time.Duration
+
+var uiCmd = &cobra.Command{
+	Use:   "ui",
+	Short: "Browse context and work the task board in a terminal UI",
+	Long: `Open a full-screen terminal UI with three views:
+
+  1 Memories  list of entries with a rendered markdown preview
+  2 Tasks     board with a column per status; claim, complete,
+              drop and comment on tasks
+  3 Locks     live locks and who holds them
+
+Local and shared context are shown together, and the views update as
+entries change, whether by another agent or a pull. Press tab to switch
+views and q to quit; the bottom line lists the keys of each view.
+
+Examples:
+  git ctx ui
+  git ctx ui --interval 5s`,
+	Args: cobra.NoArgs,
+	RunE: runUI,
+}
+
+func init() {
+	uiCmd.Flags().DurationVar(&uiInterval, "interval", time.Second, "How often to check for changes")
+}
+
+func runUI(cmd *cobra.Command, args []string) error {
+	if uiInterval <= 0 {
+		return fmt.Errorf("--interval must be positive")
+	}
+
+	app := tui.New(client)
+	app.Interval = uiInterval
+	return app.Run()
+}
@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/user/git-context/gitctx"
+	"github.com/user/git-context/internal/model"
+)
+
+var (
+	watchTasks    bool
+	watchLocks    bool
+	watchExec     string
+	watchInterval time.Duration
+)
+
+var watchCmd = &cobra.Command{
+	Use:   "watch",
+	Short: "Stream context changes as JSON events",
+	Long: `Watch local and shared context and print one JSON event per line
+for every change, whether made here, by another agent, or by a pull.
+
+Events have an entity (memory, task, lock), an action (created,
+updated, deleted, claimed, dropped, done, commented, acquired,
+released), the ID, the storage, the author and the entity's state.
+
+With --exec, the command is run through sh for each event with the
+event JSON on stdin and GIT_CTX_ENTITY, GIT_CTX_ACTION, GIT_CTX_ID and
+GIT_CTX_EVENT set in its environment.
+
+Examples:
+  git ctx watch
+  git ctx watch --tasks
+  git ctx watch --tasks --locks --interval 5s
+  git ctx watch --tasks --exec 'jq -r .action >> events.log'`,
+	Args: cobra.NoArgs,
+	RunE: runWatch,
+}
+
+func init() {
+	watchCmd.Flags().BoolVar(&watchTasks, "tasks", false, "Only task events")
+	watchCmd.Flags().BoolVar(&watchLocks, "locks", false, "Only lock events")
+	watchCmd.Flags().StringVar(&watchExec, "exec", "", "Run a shell command for each event")
+	watchCmd.Flags().DurationVar(&watchInterval, "interval", time.Second, "How often to check for changes")
+}
+
+func runWatch(cmd *cobra.Command, args []string) error {
+	if watchInterval <= 0 {
+		return fmt.Errorf("--interval must be positive")
+	}
+
+	watcher, err := client.Watch()
+	if err != nil {
+		return err
+	}
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+	ticker := time.NewTicker(watchInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return nil
+		case <-ticker.C:
+		}
+
+		changes, err := watcher.Poll()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+			continue
+		}
+
+		for _, c := range changes {
+			if !watchWanted(c) {
+				continue
+			}
+
+			data, err := json.Marshal(c)
+			if err != nil {
+				return err
+			}
+			fmt.Println(string(data))
+
+			if watchExec != "" {
+				if err := runEventHook(watchExec, c, data); err != nil {
+					fmt.Fprintf(os.Stderr, "Warning: --exec failed for %s %s: %v\n", c.Action, c.ID, err)
+				}
+			}
+		}
+	}
+}
+
+// watchWanted applies --tasks and --locks. With neither, everything is
+// reported.
+func watchWanted(c gitctx.Change) bool {
+	if !watchTasks && !watchLocks {
+		return true
+	}
+	return (watchTasks && c.Entity == model.EntityTask) ||
+		(watchLocks && c.Entity == model.EntityLock)
+}
+
+// runEventHook runs command for one event.
+func runEventHook(command string, c gitctx.Change, data []byte) error {
+	hook := exec.Command("sh", "-c", command)
+	hook.Stdin = bytes.NewReader(append(data, '\n'))
+	hook.Stdout = os.Stderr // keep stdout a clean event stream
+	hook.Stderr = os.Stderr
+	hook.Env = append(os.Environ(),
+		"GIT_CTX_ENTITY="+c.Entity,
+		"GIT_CTX_ACTION="+c.Action,
+		"GIT_CTX_ID="+c.ID,
+		"GIT_CTX_EVENT="+string(data),
+	)
+	return hook.Run()
+}
@@ -0,0 +1,168 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/user/git-context/internal/model"
+	"gopkg.in/yaml.v3"
+)
+
+var taskWorkflowCmd = &cobra.Command{
+	Use:   "workflow",
+	Short: "Show or change the task workflow",
+	Long: `Show or change the statuses tasks move through.
+
+The workflow lists every status in board order. "next" lists the
+statuses a task may move to with "git ctx task move", and "done" marks
+statuses that count as finished, so tasks blocked by them are unblocked.
+It must keep the built-in statuses open, claimed and done; "task
+request-review" also needs review.
+
+The workflow is kept in shared context: push it to share it with the
+team. Pull skips a remote workflow that is not valid.
+
+Example workflow:
+  statuses:
+    - name: open
+      next: [claimed, wontfix]
+    - name: claimed
+      next: [open, review]
+    - name: review
+      next: [claimed, done]
+    - name: done
+      done: true
+      next: [open]
+    - name: wontfix
+      done: true
+      next: [open]
+
+Examples:
+  git ctx task workflow show
+  git ctx task workflow edit
+  git ctx task workflow edit < workflow.yaml
+  git ctx task workflow reset`,
+}
+
+var taskWorkflowShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Print the workflow as YAML",
+	Args:  cobra.NoArgs,
+	RunE:  runTaskWorkflowShow,
+}
+
+var taskWorkflowEditCmd = &cobra.Command{
+	Use:   "edit",
+	Short: "Edit the workflow in your editor, or replace it from stdin",
+	Args:  cobra.NoArgs,
+	RunE:  runTaskWorkflowEdit,
+}
+
+var taskWorkflowResetCmd = &cobra.Command{
+	Use:   "reset",
+	Short: "Go back to the default workflow",
+	Args:  cobra.NoArgs,
+	RunE:  runTaskWorkflowReset,
+}
+
+func init() {
+	taskWorkflowCmd.AddCommand(taskWorkflowShowCmd)
+	taskWorkflowCmd.AddCommand(taskWorkflowEditCmd)
+	taskWorkflowCmd.AddCommand(taskWorkflowResetCmd)
+}
+
+func runTaskWorkflowShow(cmd *cobra.Command, args []string) error {
+	w, err := client.Workflow(cmd.Context())
+	if err != nil {
+		return err
+	}
+
+	if flagJSON {
+		data, err := json.MarshalIndent(w, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+	text, err := encodeWorkflow(w)
+	if err != nil {
+		return err
+	}
+	fmt.Print(text)
+	return nil
+}
+
+func runTaskWorkflowEdit(cmd *cobra.Command, args []string) error {
+	var text string
+	stat, _ := os.Stdin.Stat()
+	if (stat.Mode() & os.ModeCharDevice) == 0 {
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return fmt.Errorf("failed to read stdin: %w", err)
+		}
+		text = string(data)
+	} else {
+		current, err := client.Workflow(cmd.Context())
+		if err != nil {
+			return err
+		}
+		initial, err := encodeWorkflow(current)
+		if err != nil {
+			return err
+		}
+		text, err = editText(initial)
+		if err != nil {
+			return fmt.Errorf("editor failed: %w", err)
+		}
+	}
+
+	if strings.TrimSpace(text) == "" {
+		return fmt.Errorf("empty workflow, not saved")
+	}
+
+	// JSON is YAML, so this reads either
+	var w model.Workflow
+	if err := yaml.Unmarshal([]byte(text), &w); err != nil {
+		return fmt.Errorf("failed to parse workflow: %w", err)
+	}
+	if err := client.SetWorkflow(cmd.Context(), &w); err != nil {
+		return err
+	}
+
+	fmt.Printf("Saved workflow (shared): %s\n", strings.Join(statusNames(w.Names()), ", "))
+	return nil
+}
+
+func runTaskWorkflowReset(cmd *cobra.Command, args []string) error {
+	if err := client.ResetWorkflow(cmd.Context()); err != nil {
+		return err
+	}
+	fmt.Println("Reset workflow (shared) to open, claimed, review, done")
+	return nil
+}
+
+func encodeWorkflow(w *model.Workflow) (string, error) {
+	var b strings.Builder
+	enc := yaml.NewEncoder(&b)
+	enc.SetIndent(2)
+	if err := enc.Encode(w); err != nil {
+		return "", err
+	}
+	if err := enc.Close(); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+func statusNames(statuses []model.TaskStatus) []string {
+	names := make([]string, len(statuses))
+	for i, s := range statuses {
+		names[i] = string(s)
+	}
+	return names
+}
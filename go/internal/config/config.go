@@ -0,0 +1,378 @@
+// Package config reads git-context settings. Each setting comes from,
+// in order of precedence:
+//
+//  1. git config, as ctx.<key> (repository, then global and system)
+//  2. .gitctx.toml or .gitctx.yaml at the top of the work tree, which is
+//     committed so a team shares it
+//  3. the built-in default
+package config
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// GitPrefix is the git config section holding git-context settings.
+const GitPrefix = "ctx."
+
+// Files are the committed config files that are read, in order of
+// preference. Only one may exist.
+var Files = []string{".gitctx.toml", ".gitctx.yaml", ".gitctx.yml"}
+
+// Sources of a value.
+const (
+	SourceDefault = "default"
+	SourceGit     = "git config"
+)
+
+// Setting is a configuration key.
+type Setting struct {
+	Key     string
+	Default string
+	Help    string
+	check   func(string) error
+}
+
+// Settings lists every key.
+var Settings = []Setting{
+	{Key: "storage", Default: "local", Help: "Storage for new entries, tasks and locks: local or shared", check: oneOf("local", "shared")},
+	{Key: "lock.ttl", Default: "4h", Help: "How long locks last", check: positiveDuration},
+	{Key: "task.lease", Default: "2h", Help: "How long a task claim lasts without a heartbeat", check: positiveDuration},
+	{Key: "remote", Default: "origin", Help: "Remote for push, pull and status", check: nonEmpty},
+	{Key: "editor", Default: "", Help: "Editor command; defaults to $EDITOR, then vim"},
+	{Key: "id.length", Default: "8", Help: "Hex digits in new IDs (4-32)", check: intBetween(4, 32)},
+	{Key: "templates", Default: "true", Help: "Start new entries in the editor from their kind's template", check: isBool},
+	{Key: "hooks.autoClose", Default: "true", Help: "Mark tasks done when commits reference them; false only links the commit", check: isBool},
+}
+
+// Value is the effective value of a setting and where it came from.
+type Value struct {
+	Key    string `json:"key"`
+	Value  string `json:"value"`
+	Source string `json:"source"`
+}
+
+// Config is the effective configuration of a repository.
+type Config struct {
+	Storage        string
+	LockTTL        time.Duration
+	ClaimLease     time.Duration
+	Remote         string
+	Editor         string
+	IDLength       int
+	Templates      bool
+	HooksAutoClose bool
+
+	// Warnings name keys that were set but are not settings, such as
+	// ones written by a newer version. They are skipped rather than
+	// refused, so one stray key does not break every command.
+	Warnings []string
+
+	values map[string]Value
+}
+
+// Default returns the built-in configuration.
+func Default() *Config {
+	c := &Config{values: make(map[string]Value)}
+	for _, s := range Settings {
+		c.values[s.Key] = Value{Key: s.Key, Value: s.Default, Source: SourceDefault}
+	}
+	if err := c.apply(); err != nil {
+		panic(err) // the defaults are valid
+	}
+	return c
+}
+
+// Load reads the configuration of the repository containing dir.
+func Load(dir string) (*Config, error) {
+	c := Default()
+
+	if top := topLevel(dir); top != "" {
+		values, file, unknown, err := readFile(top)
+		if err != nil {
+			return nil, err
+		}
+		for _, key := range unknown {
+			c.Warnings = append(c.Warnings, fmt.Sprintf("ignoring unknown key %q in %s", key, file))
+		}
+		for key, v := range values {
+			c.values[key] = Value{Key: key, Value: v, Source: file}
+		}
+	}
+
+	values, unknown, err := readGitConfig(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range unknown {
+		c.Warnings = append(c.Warnings, fmt.Sprintf("ignoring unknown key %q in %s", key, SourceGit))
+	}
+	for key, v := range values {
+		c.values[key] = Value{Key: key, Value: v, Source: SourceGit}
+	}
+
+	if err := c.apply(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Get returns the effective value of key.
+func (c *Config) Get(key string) (Value, error) {
+	s, err := lookup(key)
+	if err != nil {
+		return Value{}, err
+	}
+	return c.values[s.Key], nil
+}
+
+// Values returns every setting's effective value, in documented order.
+func (c *Config) Values() []Value {
+	values := make([]Value, len(Settings))
+	for i, s := range Settings {
+		values[i] = c.values[s.Key]
+	}
+	return values
+}
+
+// Set writes key to the repository's git config, or the user's global
+// git config if global is set.
+func Set(dir, key, value string, global bool) error {
+	s, err := lookup(key)
+	if err != nil {
+		return err
+	}
+	if err := s.validate(value); err != nil {
+		return err
+	}
+
+	args := []string{"-C", dir, "config"}
+	if global {
+		args = append(args, "--global")
+	}
+	args = append(args, GitPrefix+s.Key, value)
+	if out, err := exec.Command("git", args...).CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to set %s: %s", s.Key, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// Unset removes key from the repository's (or global) git config.
+func Unset(dir, key string, global bool) error {
+	s, err := lookup(key)
+	if err != nil {
+		return err
+	}
+
+	args := []string{"-C", dir, "config"}
+	if global {
+		args = append(args, "--global")
+	}
+	args = append(args, "--unset", GitPrefix+s.Key)
+	out, err := exec.Command("git", args...).CombinedOutput()
+	if exit, ok := err.(*exec.ExitError); ok && exit.ExitCode() == 5 {
+		return nil // was not set
+	}
+	if err != nil {
+		return fmt.Errorf("failed to unset %s: %s", s.Key, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// apply parses the values into the typed fields.
+func (c *Config) apply() error {
+	for _, s := range Settings {
+		v := c.values[s.Key]
+		if err := s.validate(v.Value); err != nil {
+			return fmt.Errorf("invalid %s in %s: %w", s.Key, v.Source, err)
+		}
+	}
+
+	get := func(key string) string { return c.values[key].Value }
+	c.Storage = get("storage")
+	c.LockTTL, _ = time.ParseDuration(get("lock.ttl"))
+	c.ClaimLease, _ = time.ParseDuration(get("task.lease"))
+	c.Remote = get("remote")
+	c.Editor = get("editor")
+	c.IDLength, _ = strconv.Atoi(get("id.length"))
+	c.Templates, _ = strconv.ParseBool(get("templates"))
+	c.HooksAutoClose, _ = strconv.ParseBool(get("hooks.autoClose"))
+	return nil
+}
+
+func (s Setting) validate(value string) error {
+	if s.check == nil {
+		return nil
+	}
+	return s.check(value)
+}
+
+// lookup finds a setting by key, ignoring case as git does.
+func lookup(key string) (Setting, error) {
+	key = strings.TrimPrefix(strings.ToLower(key), GitPrefix)
+	for _, s := range Settings {
+		if strings.ToLower(s.Key) == key {
+			return s, nil
+		}
+	}
+
+	keys := make([]string, len(Settings))
+	for i, s := range Settings {
+		keys[i] = s.Key
+	}
+	return Setting{}, fmt.Errorf("unknown key %q (valid: %s)", key, strings.Join(keys, ", "))
+}
+
+// topLevel returns the work tree root, or "" for a bare repository.
+func topLevel(dir string) string {
+	out, err := exec.Command("git", "-C", dir, "rev-parse", "--show-toplevel").Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// readFile reads the committed config file in top, if there is one. It
+// also returns the keys in it that are not settings.
+func readFile(top string) (map[string]string, string, []string, error) {
+	var found []string
+	for _, name := range Files {
+		if _, err := os.Stat(filepath.Join(top, name)); err == nil {
+			found = append(found, name)
+		}
+	}
+	switch len(found) {
+	case 0:
+		return nil, "", nil, nil
+	case 1:
+	default:
+		return nil, "", nil, fmt.Errorf("found both %s; keep one", strings.Join(found, " and "))
+	}
+
+	name := found[0]
+	data, err := os.ReadFile(filepath.Join(top, name))
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	raw := make(map[string]interface{})
+	if strings.HasSuffix(name, ".toml") {
+		err = toml.Unmarshal(data, &raw)
+	} else {
+		err = yaml.Unmarshal(data, &raw)
+	}
+	if err != nil {
+		return nil, "", nil, fmt.Errorf("failed to parse %s: %w", name, err)
+	}
+
+	values := make(map[string]string)
+	unknown := flatten("", raw, values)
+	return values, name, unknown, nil
+}
+
+// flatten turns nested tables into dotted keys with canonical names. It
+// returns the keys that are not settings, which are left out.
+func flatten(prefix string, raw map[string]interface{}, values map[string]string) []string {
+	keys := make([]string, 0, len(raw))
+	for k := range raw {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var unknown []string
+	for _, k := range keys {
+		key := prefix + k
+		switch v := raw[k].(type) {
+		case map[string]interface{}:
+			unknown = append(unknown, flatten(key+".", v, values)...)
+		default:
+			s, err := lookup(key)
+			if err != nil {
+				unknown = append(unknown, key)
+				continue
+			}
+			values[s.Key] = fmt.Sprint(v)
+		}
+	}
+	return unknown
+}
+
+// readGitConfig returns the ctx.* keys set in git config, and the ones
+// that are not settings.
+func readGitConfig(dir string) (map[string]string, []string, error) {
+	out, err := exec.Command("git", "-C", dir, "config", "--get-regexp", `^ctx\.`).Output()
+	if exit, ok := err.(*exec.ExitError); ok && exit.ExitCode() == 1 {
+		return nil, nil, nil // none set
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read git config: %w", err)
+	}
+
+	values := make(map[string]string)
+	var unknown []string
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		key, value := line, ""
+		if i := strings.IndexByte(line, ' '); i >= 0 {
+			key, value = line[:i], line[i+1:]
+		}
+		s, err := lookup(key)
+		if err != nil {
+			unknown = append(unknown, key)
+			continue
+		}
+		values[s.Key] = value
+	}
+	return values, unknown, nil
+}
+
+func oneOf(options ...string) func(string) error {
+	return func(v string) error {
+		for _, o := range options {
+			if v == o {
+				return nil
+			}
+		}
+		return fmt.Errorf("%q is not one of %s", v, strings.Join(options, ", "))
+	}
+}
+
+func positiveDuration(v string) error {
+	d, err := time.ParseDuration(v)
+	if err != nil || d <= 0 {
+		return fmt.Errorf("%q is not a positive duration such as 30m or 4h", v)
+	}
+	return nil
+}
+
+func nonEmpty(v string) error {
+	if strings.TrimSpace(v) == "" {
+		return fmt.Errorf("value is empty")
+	}
+	return nil
+}
+
+func intBetween(min, max int) func(string) error {
+	return func(v string) error {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < min || n > max {
+			return fmt.Errorf("%q is not a number from %d to %d", v, min, max)
+		}
+		return nil
+	}
+}
+
+func isBool(v string) error {
+	if _, err := strconv.ParseBool(v); err != nil {
+		return fmt.Errorf("%q is not true or false", v)
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+// Package export reads and writes snapshots of the context store in
+// portable formats, for backups and moving context between repositories.
+package export
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/user/git-context/internal/model"
+	"github.com/user/git-context/internal/storage"
+)
+
+// Format is a snapshot file format.
+type Format string
+
+const (
+	FormatJSONL       Format = "jsonl"
+	FormatTar         Format = "tar"
+	FormatMarkdownDir Format = "markdown-dir"
+)
+
+// ParseFormat validates a format name.
+func ParseFormat(s string) (Format, error) {
+	switch Format(s) {
+	case FormatJSONL, FormatTar, FormatMarkdownDir:
+		return Format(s), nil
+	}
+	return "", fmt.Errorf("invalid format %q (valid: jsonl, tar, markdown-dir)", s)
+}
+
+// DetectFormat guesses the format of an existing file or directory.
+func DetectFormat(path string) (Format, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	if info.IsDir() {
+		return FormatMarkdownDir, nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	// Tar headers carry the "ustar" magic at offset 257
+	header := make([]byte, 262)
+	n, _ := f.Read(header)
+	if n == len(header) && strings.HasPrefix(string(header[257:]), "ustar") {
+		return FormatTar, nil
+	}
+
+	return FormatJSONL, nil
+}
+
+// Set is the content of one storage.
+type Set struct {
+	Memories []*model.Memory
+	Tasks    []*model.Task
+	Locks    []*model.Lock
+}
+
+// IsEmpty returns true if the set holds nothing.
+func (s *Set) IsEmpty() bool {
+	return len(s.Memories) == 0 && len(s.Tasks) == 0 && len(s.Locks) == 0
+}
+
+// Snapshot is the content of the local and shared storage.
+type Snapshot struct {
+	Local  Set
+	Shared Set
+}
+
+// Collect reads everything from a storage into a set.
+func Collect(s storage.Storage, shared bool) (Set, error) {
+	var set Set
+
+	memories, err := s.ListMemories()
+	if err != nil {
+		return set, fmt.Errorf("failed to list memories: %w", err)
+	}
+	for _, m := range memories {
+		m.Shared = shared
+	}
+	set.Memories = memories
+
+	tasks, err := s.ListTasks()
+	if err != nil {
+		return set, fmt.Errorf("failed to list tasks: %w", err)
+	}
+	for _, t := range tasks {
+		t.Shared = shared
+	}
+	set.Tasks = tasks
+
+	locks, err := s.ListLocks()
+	if err != nil {
+		return set, fmt.Errorf("failed to list locks: %w", err)
+	}
+	set.Locks = locks
+
+	return set, nil
+}
+
+// storageName returns the name used for a side in exported files.
+func storageName(shared bool) string {
+	if shared {
+		return "shared"
+	}
+	return "local"
+}
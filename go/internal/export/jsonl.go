@@ -0,0 +1,121 @@
+package export
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/user/git-context/internal/model"
+)
+
+// record is one line of a JSONL export.
+type record struct {
+	Type    string          `json:"type"`    // memory, task or lock
+	Storage string          `json:"storage"` // local or shared
+	Data    json.RawMessage `json:"data"`
+}
+
+// WriteJSONL writes a snapshot as one JSON record per line.
+func WriteJSONL(w io.Writer, snap *Snapshot) error {
+	enc := json.NewEncoder(w)
+
+	write := func(typ string, shared bool, v interface{}) error {
+		data, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		return enc.Encode(record{Type: typ, Storage: storageName(shared), Data: data})
+	}
+
+	for _, side := range []struct {
+		set    *Set
+		shared bool
+	}{{&snap.Local, false}, {&snap.Shared, true}} {
+		for _, m := range side.set.Memories {
+			if err := write("memory", side.shared, m); err != nil {
+				return err
+			}
+		}
+		for _, t := range side.set.Tasks {
+			if err := write("task", side.shared, t); err != nil {
+				return err
+			}
+		}
+		for _, l := range side.set.Locks {
+			if err := write("lock", side.shared, l); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// ReadJSONL reads a snapshot written by WriteJSONL.
+func ReadJSONL(r io.Reader) (*Snapshot, error) {
+	snap := &Snapshot{}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		var rec record
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		if err := snap.add(rec.Type, rec.Storage, rec.Data); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return snap, nil
+}
+
+// add decodes a JSON entity and adds it to the right side of the snapshot.
+func (snap *Snapshot) add(typ, storageType string, data []byte) error {
+	var set *Set
+	switch storageType {
+	case "local":
+		set = &snap.Local
+	case "shared":
+		set = &snap.Shared
+	default:
+		return fmt.Errorf("unknown storage %q", storageType)
+	}
+
+	switch typ {
+	case "memory":
+		var m model.Memory
+		if err := json.Unmarshal(data, &m); err != nil {
+			return err
+		}
+		m.Shared = storageType == "shared"
+		set.Memories = append(set.Memories, &m)
+	case "task":
+		var t model.Task
+		if err := json.Unmarshal(data, &t); err != nil {
+			return err
+		}
+		t.Shared = storageType == "shared"
+		set.Tasks = append(set.Tasks, &t)
+	case "lock":
+		var l model.Lock
+		if err := json.Unmarshal(data, &l); err != nil {
+			return err
+		}
+		set.Locks = append(set.Locks, &l)
+	default:
+		return fmt.Errorf("unknown record type %q", typ)
+	}
+
+	return nil
+}
@@ -0,0 +1,213 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// The markdown-dir format is a readable directory tree:
+//
+//	<storage>/memory/<id>.md   front matter + content
+//	<storage>/tasks/<id>.md    front matter + description
+//	<storage>/locks/<name>.yaml
+//
+// Front matter holds every other field, so the tree round-trips.
+
+// WriteMarkdownDir writes a snapshot into dir, which must not exist or be
+// empty.
+func WriteMarkdownDir(dir string, snap *Snapshot) error {
+	if entries, err := os.ReadDir(dir); err == nil && len(entries) > 0 {
+		return fmt.Errorf("%s is not empty", dir)
+	}
+
+	for _, side := range []struct {
+		set    *Set
+		shared bool
+	}{{&snap.Local, false}, {&snap.Shared, true}} {
+		base := filepath.Join(dir, storageName(side.shared))
+
+		for _, m := range side.set.Memories {
+			data, err := markdownFile(m, "content", m.Content)
+			if err != nil {
+				return err
+			}
+			if err := writeFile(filepath.Join(base, "memory", m.ID+".md"), data); err != nil {
+				return err
+			}
+		}
+
+		for _, t := range side.set.Tasks {
+			data, err := markdownFile(t, "description", t.Description)
+			if err != nil {
+				return err
+			}
+			if err := writeFile(filepath.Join(base, "tasks", t.ID+".md"), data); err != nil {
+				return err
+			}
+		}
+
+		for _, l := range side.set.Locks {
+			data, err := toYAML(l, "")
+			if err != nil {
+				return err
+			}
+			if err := writeFile(filepath.Join(base, "locks", lockFileName(l.Target)+".yaml"), data); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// ReadMarkdownDir reads a snapshot written by WriteMarkdownDir.
+func ReadMarkdownDir(dir string) (*Snapshot, error) {
+	snap := &Snapshot{}
+
+	for _, storageType := range []string{"local", "shared"} {
+		for dirName, typ := range entityDirs {
+			entries, err := os.ReadDir(filepath.Join(dir, storageType, dirName))
+			if err != nil {
+				if os.IsNotExist(err) {
+					continue
+				}
+				return nil, err
+			}
+
+			for _, entry := range entries {
+				if entry.IsDir() {
+					continue
+				}
+				path := filepath.Join(dir, storageType, dirName, entry.Name())
+				raw, err := os.ReadFile(path)
+				if err != nil {
+					return nil, err
+				}
+
+				var data []byte
+				switch typ {
+				case "memory":
+					data, err = parseMarkdownFile(raw, "content")
+				case "task":
+					data, err = parseMarkdownFile(raw, "description")
+				default:
+					data, err = fromYAML(raw, "", "")
+				}
+				if err != nil {
+					return nil, fmt.Errorf("%s: %w", path, err)
+				}
+
+				if err := snap.add(typ, storageType, data); err != nil {
+					return nil, fmt.Errorf("%s: %w", path, err)
+				}
+			}
+		}
+	}
+
+	return snap, nil
+}
+
+// markdownFile renders v as YAML front matter, leaving out bodyField,
+// followed by body.
+func markdownFile(v interface{}, bodyField, body string) ([]byte, error) {
+	header, err := toYAML(v, bodyField)
+	if err != nil {
+		return nil, err
+	}
+	return []byte("---\n" + string(header) + "---\n\n" + body), nil
+}
+
+// parseMarkdownFile is the inverse of markdownFile and returns JSON.
+func parseMarkdownFile(raw []byte, bodyField string) ([]byte, error) {
+	text := strings.ReplaceAll(string(raw), "\r\n", "\n")
+	if !strings.HasPrefix(text, "---\n") {
+		return nil, fmt.Errorf("missing front matter")
+	}
+	rest := text[4:]
+	end := strings.Index(rest, "\n---\n")
+	if end < 0 {
+		return nil, fmt.Errorf("unterminated front matter")
+	}
+	header := rest[:end+1]
+	body := strings.TrimPrefix(rest[end+5:], "\n")
+
+	return fromYAML([]byte(header), bodyField, body)
+}
+
+// toYAML converts v to YAML through its JSON form, so field names and
+// order match the JSON encoding. omit names a field to leave out.
+func toYAML(v interface{}, omit string) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	// JSON is YAML; decoding into a node keeps the field order
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return nil, err
+	}
+	blockStyle(&node)
+	if omit != "" && len(node.Content) > 0 {
+		removeKey(node.Content[0], omit)
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&node); err != nil {
+		return nil, err
+	}
+	enc.Close()
+	return buf.Bytes(), nil
+}
+
+// fromYAML converts YAML back to JSON, setting bodyField to body when
+// bodyField is non-empty.
+func fromYAML(raw []byte, bodyField, body string) ([]byte, error) {
+	var fields map[string]interface{}
+	if err := yaml.Unmarshal(raw, &fields); err != nil {
+		return nil, err
+	}
+	if fields == nil {
+		fields = make(map[string]interface{})
+	}
+	if bodyField != "" && body != "" {
+		fields[bodyField] = body
+	}
+	return json.Marshal(fields)
+}
+
+// blockStyle drops the flow and quoting styles inherited from JSON; the
+// encoder quotes scalars again where needed.
+func blockStyle(n *yaml.Node) {
+	n.Style = 0
+	for _, c := range n.Content {
+		blockStyle(c)
+	}
+}
+
+func removeKey(mapping *yaml.Node, key string) {
+	if mapping.Kind != yaml.MappingNode {
+		return
+	}
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			mapping.Content = append(mapping.Content[:i], mapping.Content[i+2:]...)
+			return
+		}
+	}
+}
+
+func writeFile(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
@@ -0,0 +1,114 @@
+package export
+
+import (
+	"archive/tar"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+)
+
+// WriteTar writes a snapshot as a tar archive laid out as
+// <storage>/<memory|tasks|locks>/<name>.json.
+func WriteTar(w io.Writer, snap *Snapshot) error {
+	tw := tar.NewWriter(w)
+	now := time.Now()
+
+	write := func(name string, v interface{}) error {
+		data, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return err
+		}
+		hdr := &tar.Header{
+			Name:    name,
+			Mode:    0644,
+			Size:    int64(len(data)),
+			ModTime: now,
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		_, err = tw.Write(data)
+		return err
+	}
+
+	for _, side := range []struct {
+		set    *Set
+		shared bool
+	}{{&snap.Local, false}, {&snap.Shared, true}} {
+		prefix := storageName(side.shared)
+		for _, m := range side.set.Memories {
+			if err := write(path.Join(prefix, "memory", m.ID+".json"), m); err != nil {
+				return err
+			}
+		}
+		for _, t := range side.set.Tasks {
+			if err := write(path.Join(prefix, "tasks", t.ID+".json"), t); err != nil {
+				return err
+			}
+		}
+		for _, l := range side.set.Locks {
+			if err := write(path.Join(prefix, "locks", lockFileName(l.Target)+".json"), l); err != nil {
+				return err
+			}
+		}
+	}
+
+	return tw.Close()
+}
+
+// ReadTar reads a snapshot written by WriteTar.
+func ReadTar(r io.Reader) (*Snapshot, error) {
+	snap := &Snapshot{}
+	tr := tar.NewReader(r)
+
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if hdr.Typeflag != tar.TypeReg || !strings.HasSuffix(hdr.Name, ".json") {
+			continue
+		}
+
+		parts := strings.Split(path.Clean(hdr.Name), "/")
+		if len(parts) != 3 {
+			return nil, fmt.Errorf("%s: unexpected path", hdr.Name)
+		}
+
+		typ, ok := entityDirs[parts[1]]
+		if !ok {
+			return nil, fmt.Errorf("%s: unknown directory %q", hdr.Name, parts[1])
+		}
+
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, err
+		}
+		if err := snap.add(typ, parts[0], data); err != nil {
+			return nil, fmt.Errorf("%s: %w", hdr.Name, err)
+		}
+	}
+
+	return snap, nil
+}
+
+// entityDirs maps export directory names to record types.
+var entityDirs = map[string]string{
+	"memory": "memory",
+	"tasks":  "task",
+	"locks":  "lock",
+}
+
+// lockFileName derives a file name from a lock target, which may contain
+// slashes.
+func lockFileName(target string) string {
+	h := sha256.Sum256([]byte(target))
+	return fmt.Sprintf("%x", h[:8])
+}
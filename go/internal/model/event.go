@@ -0,0 +1,131 @@
+package model
+
+import (
+	"sort"
+	"time"
+)
+
+// EventType is what happened to an entry, task or lock.
+type EventType string
+
+const (
+	EventCreated   EventType = "created"
+	EventEdited    EventType = "edited"
+	EventClaimed   EventType = "claimed"
+	EventDropped   EventType = "dropped"
+	EventDone      EventType = "done"
+	EventMoved     EventType = "moved"
+	EventCommented EventType = "commented"
+	EventAcquired  EventType = "acquired"
+
+	EventAssigned        EventType = "assigned"
+	EventReviewRequested EventType = "review-requested"
+	EventApproved        EventType = "approved"
+	EventRejected        EventType = "rejected"
+	EventReaped          EventType = "reaped"
+)
+
+// Event is one change in the history of a memory or task.
+type Event struct {
+	Type   EventType `json:"type"`
+	Author string    `json:"author"`
+	At     time.Time `json:"at"`
+	Detail string    `json:"detail,omitempty"` // assignee or reviewer
+}
+
+// newEvent records an event that happened now.
+func newEvent(typ EventType, author string) Event {
+	return Event{Type: typ, Author: author, At: time.Now().UTC()}
+}
+
+// Entity types in the activity timeline.
+const (
+	EntityMemory = "memory"
+	EntityTask   = "task"
+	EntityLock   = "lock"
+)
+
+// Activity is one line of the timeline across all context.
+type Activity struct {
+	At     time.Time `json:"at"`
+	Entity string    `json:"entity"` // memory, task or lock
+	Type   EventType `json:"type"`
+	ID     string    `json:"id"` // lock target for locks
+	Title  string    `json:"title,omitempty"`
+	Author string    `json:"author"`
+	Detail string    `json:"detail,omitempty"`
+	Shared bool      `json:"shared"`
+}
+
+// MemoryActivity returns the timeline of a memory. Entries written
+// before history was kept show their last edit only.
+func MemoryActivity(m *Memory) []Activity {
+	item := func(typ EventType, author string, at time.Time) Activity {
+		return Activity{At: at, Entity: EntityMemory, Type: typ, ID: m.ID, Title: m.Title, Author: author, Shared: m.Shared}
+	}
+
+	items := []Activity{item(EventCreated, m.Author, m.CreatedAt)}
+	for _, e := range m.History {
+		items = append(items, item(e.Type, e.Author, e.At))
+	}
+	if len(m.History) == 0 && m.UpdatedAt.Sub(m.CreatedAt) >= time.Second {
+		items = append(items, item(EventEdited, m.Author, m.UpdatedAt))
+	}
+	return items
+}
+
+// TaskActivity returns the timeline of a task, including comments.
+func TaskActivity(t *Task) []Activity {
+	item := func(typ EventType, author string, at time.Time) Activity {
+		return Activity{At: at, Entity: EntityTask, Type: typ, ID: t.ID, Title: t.Title, Author: author, Shared: t.Shared}
+	}
+
+	items := []Activity{item(EventCreated, t.CreatedBy, t.CreatedAt)}
+	for _, e := range t.History {
+		a := item(e.Type, e.Author, e.At)
+		a.Detail = e.Detail
+		items = append(items, a)
+	}
+	if len(t.History) == 0 && t.DoneAt != nil {
+		items = append(items, item(EventDone, t.Owner, *t.DoneAt))
+	}
+	for _, c := range t.Comments {
+		a := item(EventCommented, c.Author, c.CreatedAt)
+		a.Detail = c.Content
+		items = append(items, a)
+	}
+	return items
+}
+
+// LockActivity returns a lock journal event as a line of the timeline.
+func LockActivity(e *LockEvent, shared bool) Activity {
+	return Activity{
+		At:     e.At,
+		Entity: EntityLock,
+		Type:   e.Type,
+		ID:     e.Target,
+		Author: e.Author,
+		Detail: e.Describe(),
+		Shared: shared,
+	}
+}
+
+// CurrentLockActivity returns the acquisition of a lock taken before the
+// lock journal was kept, the only part of its history known.
+func CurrentLockActivity(l *Lock, shared bool) Activity {
+	return Activity{
+		At:     l.LockedAt,
+		Entity: EntityLock,
+		Type:   EventAcquired,
+		ID:     l.Target,
+		Author: l.LockedBy,
+		Shared: shared,
+	}
+}
+
+// SortActivity orders a timeline from oldest to newest.
+func SortActivity(items []Activity) {
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].At.Before(items[j].At)
+	})
+}
@@ -0,0 +1,163 @@
+package model
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const frontMatterDelim = "---"
+
+// frontMatterErrorPrefix marks validation errors written into the editor
+// buffer so they can be stripped on the next round.
+const frontMatterErrorPrefix = "# error: "
+
+// FrontMatter holds the editable fields of a memory, written as a YAML
+// block at the top of the editor buffer.
+type FrontMatter struct {
+	Title string     `yaml:"title"`
+	Kind  MemoryKind `yaml:"kind"`
+	Tags  []string   `yaml:"tags,flow"`
+	Links []Link     `yaml:"links,omitempty"`
+}
+
+// FormatFrontMatter renders a memory as a YAML front-matter block
+// followed by its markdown content.
+func FormatFrontMatter(m *Memory) string {
+	kind := m.Kind
+	if kind == "" {
+		kind = KindNote
+	}
+
+	fm := FrontMatter{
+		Title: m.Title,
+		Kind:  kind,
+		Tags:  m.Tags,
+		Links: m.Links,
+	}
+	if fm.Tags == nil {
+		fm.Tags = []string{}
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	enc.Encode(fm)
+	enc.Close()
+
+	return frontMatterDelim + "\n" + buf.String() + frontMatterDelim + "\n\n" + m.Content
+}
+
+// ParseFrontMatter splits an editor buffer into its front matter and
+// markdown body, and validates the front matter.
+func ParseFrontMatter(text string) (*FrontMatter, string, error) {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	if !strings.HasPrefix(text, frontMatterDelim+"\n") {
+		return nil, "", errors.New("missing front matter: the buffer must start with ---")
+	}
+
+	rest := text[len(frontMatterDelim)+1:]
+	end := strings.Index(rest, "\n"+frontMatterDelim)
+	var header, body string
+	switch {
+	case strings.HasPrefix(rest, frontMatterDelim):
+		// Empty front matter
+		body = rest[len(frontMatterDelim):]
+	case end >= 0:
+		header = rest[:end]
+		body = rest[end+len(frontMatterDelim)+1:]
+	default:
+		return nil, "", errors.New("unterminated front matter: add a closing ---")
+	}
+
+	// The closing delimiter must be on a line of its own
+	if body != "" && !strings.HasPrefix(body, "\n") {
+		return nil, "", errors.New("unterminated front matter: add a closing ---")
+	}
+	body = strings.TrimPrefix(body, "\n")
+	body = strings.TrimPrefix(body, "\n")
+
+	var fm FrontMatter
+	dec := yaml.NewDecoder(strings.NewReader(header))
+	dec.KnownFields(true)
+	if err := dec.Decode(&fm); err != nil && !errors.Is(err, io.EOF) {
+		return nil, "", fmt.Errorf("invalid front matter: %w", err)
+	}
+
+	if err := fm.Validate(); err != nil {
+		return nil, "", err
+	}
+
+	return &fm, body, nil
+}
+
+// Validate checks the front matter fields and normalizes them.
+func (fm *FrontMatter) Validate() error {
+	fm.Title = strings.TrimSpace(fm.Title)
+	if fm.Title == "" {
+		return errors.New("title is required")
+	}
+
+	kind, err := ParseKind(string(fm.Kind))
+	if err != nil {
+		return err
+	}
+	fm.Kind = kind
+
+	var tags []string
+	for _, tag := range fm.Tags {
+		tag = strings.TrimSpace(tag)
+		if tag == "" {
+			return errors.New("tags must not be empty")
+		}
+		tags = append(tags, tag)
+	}
+	fm.Tags = tags
+
+	for i, l := range fm.Links {
+		if _, err := ParseLinkType(string(l.Type)); err != nil {
+			return fmt.Errorf("links[%d]: %w", i, err)
+		}
+		if strings.TrimSpace(l.Target) == "" {
+			return fmt.Errorf("links[%d]: target is required", i)
+		}
+	}
+
+	return nil
+}
+
+// Apply copies the front matter fields onto a memory.
+func (fm *FrontMatter) Apply(m *Memory) {
+	m.Title = fm.Title
+	m.Kind = fm.Kind
+	m.Tags = fm.Tags
+	m.Links = fm.Links
+}
+
+// AnnotateFrontMatterError writes err as YAML comments at the top of the
+// front matter so the user sees it when the editor reopens. Errors from
+// a previous round are replaced.
+func AnnotateFrontMatterError(text string, err error) string {
+	var kept []string
+	for _, line := range strings.Split(text, "\n") {
+		if !strings.HasPrefix(line, frontMatterErrorPrefix) {
+			kept = append(kept, line)
+		}
+	}
+	text = strings.Join(kept, "\n")
+
+	var notes []string
+	for _, line := range strings.Split(err.Error(), "\n") {
+		notes = append(notes, frontMatterErrorPrefix+line)
+	}
+	annotation := strings.Join(notes, "\n") + "\n"
+
+	if strings.HasPrefix(text, frontMatterDelim+"\n") {
+		return frontMatterDelim + "\n" + annotation + text[len(frontMatterDelim)+1:]
+	}
+	return annotation + text
+}
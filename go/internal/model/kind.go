@@ -0,0 +1,143 @@
+package model
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// MemoryKind categorizes a memory entry.
+type MemoryKind string
+
+const (
+	KindNote     MemoryKind = "note"
+	KindDecision MemoryKind = "decision"
+	KindPlan     MemoryKind = "plan"
+	KindHandoff  MemoryKind = "handoff"
+	KindBug      MemoryKind = "bug"
+)
+
+// MemoryKinds lists all valid memory kinds.
+var MemoryKinds = []MemoryKind{KindNote, KindDecision, KindPlan, KindHandoff, KindBug}
+
+// ParseKind validates a memory kind name. An empty name is a note.
+func ParseKind(s string) (MemoryKind, error) {
+	if s == "" {
+		return KindNote, nil
+	}
+	for _, k := range MemoryKinds {
+		if string(k) == s {
+			return k, nil
+		}
+	}
+
+	names := make([]string, len(MemoryKinds))
+	for i, k := range MemoryKinds {
+		names[i] = string(k)
+	}
+	return "", fmt.Errorf("invalid kind %q (valid: %s)", s, strings.Join(names, ", "))
+}
+
+// DefaultTemplates are the built-in editor templates for each kind.
+// They are Go text/templates rendered with TemplateData.
+var DefaultTemplates = map[MemoryKind]string{
+	KindNote: `# {{.Title}}
+
+`,
+	KindDecision: `# {{.Title}}
+
+## Context
+What is the issue that motivates this decision?
+
+## Decision
+What is the change that we are proposing or doing?
+
+## Consequences
+What becomes easier or harder because of this change?
+
+## Status
+Proposed ({{.Date}})
+`,
+	KindPlan: `# {{.Title}}
+
+## Goal
+[One sentence describing success]
+
+## Phases
+- [ ] Phase 1:
+- [ ] Phase 2:
+- [ ] Phase 3:
+
+## Key Questions
+1.
+
+## Decisions Made
+- (none yet)
+
+## Status
+**Currently in Phase 1**
+`,
+	KindHandoff: `# {{.Title}}
+
+Handoff by {{.Author}} on {{.Date}}
+
+## Completed
+-
+
+## In Progress
+-
+
+## Next Steps
+-
+
+## Blockers
+-
+`,
+	KindBug: `# {{.Title}}
+
+## Symptoms
+
+## Steps to Reproduce
+1.
+
+## Root Cause
+
+## Fix
+`,
+}
+
+// TemplateData is the data available to memory templates.
+type TemplateData struct {
+	Title  string
+	Author string
+	Kind   MemoryKind
+	Date   string
+}
+
+// RenderTemplate renders a memory template. An empty text uses the
+// built-in template for the kind.
+func RenderTemplate(text string, kind MemoryKind, title, author string) (string, error) {
+	if text == "" {
+		text = DefaultTemplates[kind]
+	}
+
+	tmpl, err := template.New(string(kind)).Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("invalid %s template: %w", kind, err)
+	}
+
+	var buf bytes.Buffer
+	err = tmpl.Execute(&buf, TemplateData{
+		Title:  title,
+		Author: author,
+		Kind:   kind,
+		Date:   time.Now().Format("2006-01-02"),
+	})
+	if err != nil {
+		return "", fmt.Errorf("invalid %s template: %w", kind, err)
+	}
+
+	return buf.String(), nil
+}
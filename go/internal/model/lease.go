@@ -0,0 +1,39 @@
+package model
+
+import "time"
+
+// DefaultClaimLease is how long a claim lasts without a heartbeat. It is
+// set from the task.lease setting.
+var DefaultClaimLease = 2 * time.Hour
+
+// renewLease starts a new lease from now if the task is claimed, and
+// clears it otherwise.
+func (t *Task) renewLease(now time.Time) {
+	if t.Status != TaskClaimed {
+		t.LeaseExpiresAt = nil
+		return
+	}
+	expires := now.Add(DefaultClaimLease)
+	t.LeaseExpiresAt = &expires
+}
+
+// Heartbeat extends the owner's claim by a full lease.
+func (t *Task) Heartbeat() {
+	now := time.Now().UTC()
+	t.renewLease(now)
+	t.UpdatedAt = now
+}
+
+// IsStale returns true if the task is claimed and its lease has lapsed.
+// Claims made before leases existed never go stale.
+func (t *Task) IsStale() bool {
+	return t.Status == TaskClaimed && t.LeaseExpiresAt != nil && time.Now().UTC().After(*t.LeaseExpiresAt)
+}
+
+// Reap reopens a task whose claim lapsed, recording whose it was.
+func (t *Task) Reap(actor string) {
+	owner := t.Owner
+	t.Owner = ""
+	t.Status = TaskOpen
+	t.recordDetail(EventReaped, actor, owner)
+}
@@ -0,0 +1,120 @@
+package model
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// LinkType describes how two entities are related.
+type LinkType string
+
+const (
+	LinkRelatesTo  LinkType = "relates-to"
+	LinkSupersedes LinkType = "supersedes"
+	LinkDecidedBy  LinkType = "decided-by"
+	LinkImplements LinkType = "implements"
+	LinkCommit     LinkType = "commit"
+	LinkBranch     LinkType = "branch"
+)
+
+// LinkTypes lists all valid link types.
+var LinkTypes = []LinkType{
+	LinkRelatesTo,
+	LinkSupersedes,
+	LinkDecidedBy,
+	LinkImplements,
+	LinkCommit,
+	LinkBranch,
+}
+
+// Link is a typed reference from one entity to another entity, a commit
+// or a branch.
+type Link struct {
+	Type   LinkType `json:"type"`
+	Target string   `json:"target"`
+}
+
+// ParseLinkType validates a link type name.
+func ParseLinkType(s string) (LinkType, error) {
+	for _, t := range LinkTypes {
+		if string(t) == s {
+			return t, nil
+		}
+	}
+
+	names := make([]string, len(LinkTypes))
+	for i, t := range LinkTypes {
+		names[i] = string(t)
+	}
+	return "", fmt.Errorf("invalid link type %q (valid: %s)", s, strings.Join(names, ", "))
+}
+
+// IsEntity returns true if the link points at a memory or task rather
+// than a git object.
+func (t LinkType) IsEntity() bool {
+	return t != LinkCommit && t != LinkBranch
+}
+
+// Inverse returns the label used when showing the link from the target's
+// side.
+func (t LinkType) Inverse() string {
+	switch t {
+	case LinkSupersedes:
+		return "superseded-by"
+	case LinkDecidedBy:
+		return "decides"
+	case LinkImplements:
+		return "implemented-by"
+	default:
+		return string(t)
+	}
+}
+
+// AddLink appends a link unless an identical one exists.
+// Returns false if the link was already present.
+func AddLink(links []Link, l Link) ([]Link, bool) {
+	for _, existing := range links {
+		if existing == l {
+			return links, false
+		}
+	}
+	return append(links, l), true
+}
+
+// RemoveLink removes all links to target, or only those of the given type
+// when typ is non-empty. Returns false if nothing was removed.
+func RemoveLink(links []Link, target string, typ LinkType) ([]Link, bool) {
+	var kept []Link
+	for _, l := range links {
+		if l.Target == target && (typ == "" || l.Type == typ) {
+			continue
+		}
+		kept = append(kept, l)
+	}
+	return kept, len(kept) != len(links)
+}
+
+var inlineRefPattern = regexp.MustCompile(`\[\[([A-Za-z0-9_-]+)\]\]`)
+
+// ParseInlineRefs returns the IDs referenced as [[id]] in content.
+func ParseInlineRefs(content string) []string {
+	var ids []string
+	seen := make(map[string]bool)
+	for _, match := range inlineRefPattern.FindAllStringSubmatch(content, -1) {
+		id := match[1]
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// RewriteInlineRefs replaces each [[id]] in content with [[rename(id)]].
+func RewriteInlineRefs(content string, rename func(string) string) string {
+	return inlineRefPattern.ReplaceAllStringFunc(content, func(ref string) string {
+		id := inlineRefPattern.FindStringSubmatch(ref)[1]
+		return "[[" + rename(id) + "]]"
+	})
+}
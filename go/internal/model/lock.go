@@ -1,16 +1,50 @@
 package model
 
-import "time"
+import (
+	"fmt"
+	"path"
+	"strings"
+	"time"
+)
 
-// DefaultLockExpiry is how long locks last before expiring.
-const DefaultLockExpiry = 4 * time.Hour
+// DefaultLockExpiry is how long locks last before expiring. It is set
+// from the lock.ttl setting.
+var DefaultLockExpiry = 4 * time.Hour
+
+// LockMode says whether a lock can be held by one agent or many.
+type LockMode string
+
+// Lock modes.
+const (
+	// LockExclusive locks are held by one agent, such as one editing the
+	// target. Locks without a mode are exclusive.
+	LockExclusive LockMode = "exclusive"
+	// LockShared locks are held by any number of agents that only read
+	// the target, and keep out exclusive ones.
+	LockShared LockMode = "shared"
+)
+
+// ParseLockMode parses a mode name; "" is exclusive.
+func ParseLockMode(s string) (LockMode, error) {
+	switch LockMode(s) {
+	case "", LockExclusive:
+		return LockExclusive, nil
+	case LockShared:
+		return LockShared, nil
+	}
+	return "", fmt.Errorf("invalid lock mode %q (valid: exclusive, shared)", s)
+}
 
 // Lock represents a lock on a task or file path.
 type Lock struct {
 	Target    string    `json:"target"`
-	LockedBy  string    `json:"lockedBy"`
+	LockedBy  string    `json:"lockedBy"` // the holder, or the first of a shared lock's holders
 	LockedAt  time.Time `json:"lockedAt"`
 	ExpiresAt time.Time `json:"expiresAt"`
+	Mode      LockMode  `json:"mode,omitempty"`
+	Holders   []string  `json:"holders,omitempty"` // everyone holding a shared lock
+	Reason    string    `json:"reason,omitempty"`  // why it was taken
+	Queue     []Waiter  `json:"queue,omitempty"`   // agents waiting for it, first in line first
 }
 
 // NewLock creates a new lock with default expiry.
@@ -29,9 +63,55 @@ func (l *Lock) IsExpired() bool {
 	return time.Now().UTC().After(l.ExpiresAt)
 }
 
-// IsOwnedBy returns true if the lock is owned by the given user.
+// IsShared returns true if the lock can have several holders.
+func (l *Lock) IsShared() bool {
+	return l.Mode == LockShared
+}
+
+// HeldBy returns the lock's holders.
+func (l *Lock) HeldBy() []string {
+	if l.IsShared() {
+		return l.Holders
+	}
+	return []string{l.LockedBy}
+}
+
+// IsOwnedBy returns true if the given user holds the lock, alone or as
+// one of a shared lock's holders.
 func (l *Lock) IsOwnedBy(user string) bool {
-	return l.LockedBy == user
+	for _, h := range l.HeldBy() {
+		if h == user {
+			return true
+		}
+	}
+	return false
 }
 
+// IsHeldByOthers returns true if anyone but the given user holds the
+// lock.
+func (l *Lock) IsHeldByOthers(user string) bool {
+	for _, h := range l.HeldBy() {
+		if h != user {
+			return true
+		}
+	}
+	return false
+}
 
+// PathsOverlap reports whether two lock targets cover any of the same
+// files: they are the same path, or one is a directory containing the
+// other.
+func PathsOverlap(a, b string) bool {
+	a, b = cleanTarget(a), cleanTarget(b)
+	if a == b {
+		return true
+	}
+	if len(a) > len(b) {
+		a, b = b, a
+	}
+	return strings.HasPrefix(b, a+"/")
+}
+
+func cleanTarget(target string) string {
+	return path.Clean(strings.ReplaceAll(target, "\\", "/"))
+}
@@ -0,0 +1,89 @@
+package model
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// Lock journal event types. Acquiring is EventAcquired.
+const (
+	EventRenewed  EventType = "renewed"
+	EventReleased EventType = "released"
+	EventBroken   EventType = "broken"
+)
+
+// lockEventIDLength is the number of hex characters in lock event IDs.
+// Unlike task and memory IDs they are never typed, and journals merged
+// from many clones pile up, so they are long whatever id.length says.
+const lockEventIDLength = 16
+
+// MaxLockEvents is how many events the journal keeps per target. Once a
+// target has more, the oldest are dropped.
+var MaxLockEvents = 500
+
+// LockEvent is one entry in the lock journal, which records every
+// acquire, renewal, release and break so a lock's history outlives the
+// lock. Journal entries are never changed; they are only dropped once
+// their target has more than MaxLockEvents newer ones.
+type LockEvent struct {
+	ID     string    `json:"id"`
+	Target string    `json:"target"`
+	Type   EventType `json:"type"`
+	Author string    `json:"author"`
+	At     time.Time `json:"at"`
+	Mode   LockMode  `json:"mode,omitempty"`
+	Holder string    `json:"holder,omitempty"` // whose lock was broken
+	Reason string    `json:"reason,omitempty"`
+}
+
+// NewLockEvent records an event that happened to the lock on target now.
+func NewLockEvent(target string, typ EventType, author string) *LockEvent {
+	return &LockEvent{
+		ID:     "lev-" + RandomHex(lockEventIDLength),
+		Target: target,
+		Type:   typ,
+		Author: author,
+		At:     time.Now().UTC(),
+	}
+}
+
+// Describe summarizes the event's details, such as whose lock was
+// broken and why.
+func (e *LockEvent) Describe() string {
+	var parts []string
+	if e.Mode == LockShared {
+		parts = append(parts, "shared")
+	}
+	if e.Holder != "" {
+		parts = append(parts, "held by "+e.Holder)
+	}
+	detail := strings.Join(parts, ", ")
+	if e.Reason != "" {
+		if detail != "" {
+			detail += ": "
+		}
+		detail += e.Reason
+	}
+	return detail
+}
+
+// SortLockEvents orders events from oldest to newest.
+func SortLockEvents(events []*LockEvent) {
+	sort.SliceStable(events, func(i, j int) bool {
+		if !events[i].At.Equal(events[j].At) {
+			return events[i].At.Before(events[j].At)
+		}
+		return events[i].ID < events[j].ID
+	})
+}
+
+// TrimLockEvents sorts one target's events and keeps the newest
+// MaxLockEvents.
+func TrimLockEvents(events []*LockEvent) []*LockEvent {
+	SortLockEvents(events)
+	if len(events) > MaxLockEvents {
+		events = events[len(events)-MaxLockEvents:]
+	}
+	return events
+}
@@ -8,14 +8,23 @@ import (
 
 // Memory represents a context entry (notes, decisions, plans).
 type Memory struct {
-	ID        string    `json:"id"`
-	Title     string    `json:"title"`
-	Content   string    `json:"content,omitempty"`
-	Author    string    `json:"author"`
-	Tags      []string  `json:"tags,omitempty"`
-	CreatedAt time.Time `json:"createdAt"`
-	UpdatedAt time.Time `json:"updatedAt"`
-	Shared    bool      `json:"shared"`
+	ID        string     `json:"id"`
+	Title     string     `json:"title"`
+	Kind      MemoryKind `json:"kind,omitempty"`
+	Content   string     `json:"content,omitempty"`
+	Author    string     `json:"author"`
+	Tags      []string   `json:"tags,omitempty"`
+	Links     []Link     `json:"links,omitempty"`
+	CreatedAt time.Time  `json:"createdAt"`
+	UpdatedAt time.Time  `json:"updatedAt"`
+	Shared    bool       `json:"shared"`
+
+	// Lifecycle: archived entries are hidden from list and search.
+	ArchivedAt   *time.Time `json:"archivedAt,omitempty"`
+	SupersededBy string     `json:"supersededBy,omitempty"`
+
+	// History records edits after creation.
+	History []Event `json:"history,omitempty"`
 }
 
 // NewMemory creates a new memory entry with generated ID.
@@ -24,6 +33,7 @@ func NewMemory(title, content, author string, shared bool) *Memory {
 	return &Memory{
 		ID:        GenerateID(),
 		Title:     title,
+		Kind:      KindNote,
 		Content:   content,
 		Author:    author,
 		CreatedAt: now,
@@ -32,10 +42,67 @@ func NewMemory(title, content, author string, shared bool) *Memory {
 	}
 }
 
+// Touch records an edit by author.
+func (m *Memory) Touch(author string) {
+	e := newEvent(EventEdited, author)
+	m.History = append(m.History, e)
+	m.UpdatedAt = e.At
+}
+
+// IsKind returns true if the memory is of the given kind.
+// Entries written before kinds existed count as notes.
+func (m *Memory) IsKind(kind MemoryKind) bool {
+	if m.Kind == "" {
+		return kind == KindNote
+	}
+	return m.Kind == kind
+}
+
+// Archive hides the memory from list and search.
+func (m *Memory) Archive() {
+	now := time.Now().UTC()
+	m.ArchivedAt = &now
+	m.UpdatedAt = now
+}
+
+// Restore brings an archived or superseded memory back.
+func (m *Memory) Restore() {
+	m.ArchivedAt = nil
+	m.SupersededBy = ""
+	m.UpdatedAt = time.Now().UTC()
+}
+
+// Supersede marks the memory as replaced by the memory with the given ID
+// and archives it.
+func (m *Memory) Supersede(replacementID string) {
+	m.SupersededBy = replacementID
+	m.Archive()
+}
+
+// IsArchived returns true if the memory is archived or superseded.
+func (m *Memory) IsArchived() bool {
+	return m.ArchivedAt != nil || m.SupersededBy != ""
+}
+
+// AddLink adds a typed link to the memory.
+// Returns false if the link already exists.
+func (m *Memory) AddLink(l Link) bool {
+	links, added := AddLink(m.Links, l)
+	if added {
+		m.Links = links
+		m.UpdatedAt = time.Now().UTC()
+	}
+	return added
+}
+
+// InlineRefs returns the IDs referenced as [[id]] in the content.
+func (m *Memory) InlineRefs() []string {
+	return ParseInlineRefs(m.Content)
+}
+
 // MatchesSearch returns true if the memory matches the search query.
 func (m *Memory) MatchesSearch(query string) bool {
 	query = strings.ToLower(query)
 	return strings.Contains(strings.ToLower(m.Title), query) ||
 		strings.Contains(strings.ToLower(m.Content), query)
 }
-
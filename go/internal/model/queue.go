@@ -0,0 +1,173 @@
+package model
+
+import (
+	"sort"
+	"time"
+)
+
+// WaiterTimeout is how long a waiter keeps its place in a lock's queue
+// without polling. Waiters that stop polling, such as a killed
+// "lock --wait", are dropped so they do not hold up everyone behind them.
+var WaiterTimeout = 2 * time.Minute
+
+// Waiter is an agent queued for a lock.
+type Waiter struct {
+	Agent    string    `json:"agent"`
+	Mode     LockMode  `json:"mode,omitempty"`
+	Reason   string    `json:"reason,omitempty"`
+	QueuedAt time.Time `json:"queuedAt"`
+	SeenAt   time.Time `json:"seenAt"` // last poll
+}
+
+// Position returns agent's place in the queue, counting from 0, or -1 if
+// it is not queued.
+func (l *Lock) Position(agent string) int {
+	for i, w := range l.Queue {
+		if w.Agent == agent {
+			return i
+		}
+	}
+	return -1
+}
+
+// Enqueue adds agent to the back of the queue, waiting for mode, or
+// notes that it is still waiting if it is already queued. It returns
+// agent's place.
+func (l *Lock) Enqueue(agent string, mode LockMode, reason string) int {
+	now := time.Now().UTC()
+	if i := l.Position(agent); i >= 0 {
+		l.Queue[i].Mode = mode
+		l.Queue[i].Reason = reason
+		l.Queue[i].SeenAt = now
+		return i
+	}
+	l.Queue = append(l.Queue, Waiter{Agent: agent, Mode: mode, Reason: reason, QueuedAt: now, SeenAt: now})
+	return len(l.Queue) - 1
+}
+
+// Dequeue removes agent from the queue.
+func (l *Lock) Dequeue(agent string) {
+	if i := l.Position(agent); i >= 0 {
+		l.Queue = append(l.Queue[:i:i], l.Queue[i+1:]...)
+	}
+}
+
+// PruneQueue drops waiters that have not polled within WaiterTimeout.
+func (l *Lock) PruneQueue() {
+	cutoff := time.Now().UTC().Add(-WaiterTimeout)
+	var live []Waiter
+	for _, w := range l.Queue {
+		if w.SeenAt.After(cutoff) {
+			live = append(live, w)
+		}
+	}
+	l.Queue = live
+}
+
+// Take makes agent the only holder, in the given mode and with a fresh
+// expiry, and removes it from the queue.
+func (l *Lock) Take(agent string, mode LockMode, reason string) {
+	now := time.Now().UTC()
+	l.Dequeue(agent)
+	l.LockedBy = agent
+	l.LockedAt = now
+	l.ExpiresAt = now.Add(DefaultLockExpiry)
+	l.Reason = reason
+	l.Mode = mode
+	l.Holders = nil
+	if mode == LockShared {
+		l.Holders = []string{agent}
+	}
+}
+
+// Join adds agent to a shared lock's holders and removes it from the
+// queue. Holders share one expiry, which starts afresh.
+func (l *Lock) Join(agent string) {
+	l.Dequeue(agent)
+	if !l.IsOwnedBy(agent) {
+		l.Holders = append(l.Holders, agent)
+	}
+	l.ExpiresAt = time.Now().UTC().Add(DefaultLockExpiry)
+}
+
+// Renew gives the lock a fresh expiry, and a new reason if one is given.
+func (l *Lock) Renew(reason string) {
+	l.ExpiresAt = time.Now().UTC().Add(DefaultLockExpiry)
+	if reason != "" {
+		l.Reason = reason
+	}
+}
+
+// Leave removes agent from a shared lock's holders. It returns true if
+// others still hold the lock.
+func (l *Lock) Leave(agent string) bool {
+	if !l.IsShared() {
+		return false
+	}
+	var rest []string
+	for _, h := range l.Holders {
+		if h != agent {
+			rest = append(rest, h)
+		}
+	}
+	l.Holders = rest
+	if len(rest) == 0 {
+		return false
+	}
+	l.LockedBy = rest[0]
+	return true
+}
+
+// HandOff passes the lock to the first live waiter, along with any
+// waiters for a shared lock right behind a shared first waiter. It
+// returns false if no one is waiting, in which case the lock should be
+// deleted.
+func (l *Lock) HandOff() bool {
+	l.PruneQueue()
+	if len(l.Queue) == 0 {
+		return false
+	}
+	next := l.Queue[0]
+	l.Take(next.Agent, next.Mode, next.Reason)
+	for l.IsShared() && len(l.Queue) > 0 && l.Queue[0].Mode == LockShared {
+		l.Join(l.Queue[0].Agent)
+	}
+	return true
+}
+
+// Absorb brings in another copy of the lock changed at the same time in
+// another clone, whose holder lost to l's: its waiters join l's queue,
+// which is ordered by when each waiter queued, and if both copies are
+// shared and live, its holders keep their place among l's.
+func (l *Lock) Absorb(other *Lock) {
+	if l.IsShared() && other.IsShared() && !other.IsExpired() {
+		for _, h := range other.Holders {
+			if !l.IsOwnedBy(h) {
+				l.Holders = append(l.Holders, h)
+			}
+		}
+		if other.ExpiresAt.After(l.ExpiresAt) {
+			l.ExpiresAt = other.ExpiresAt
+		}
+	}
+
+	for _, w := range other.Queue {
+		i := l.Position(w.Agent)
+		if i < 0 {
+			l.Queue = append(l.Queue, w)
+			continue
+		}
+		if w.QueuedAt.Before(l.Queue[i].QueuedAt) {
+			l.Queue[i].QueuedAt = w.QueuedAt
+		}
+		if w.SeenAt.After(l.Queue[i].SeenAt) {
+			l.Queue[i].SeenAt = w.SeenAt
+		}
+	}
+	for _, h := range l.HeldBy() {
+		l.Dequeue(h)
+	}
+	sort.SliceStable(l.Queue, func(i, j int) bool {
+		return l.Queue[i].QueuedAt.Before(l.Queue[j].QueuedAt)
+	})
+}
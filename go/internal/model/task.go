@@ -11,6 +11,7 @@ type TaskStatus string
 const (
 	TaskOpen    TaskStatus = "open"
 	TaskClaimed TaskStatus = "claimed"
+	TaskReview  TaskStatus = "review"
 	TaskDone    TaskStatus = "done"
 )
 
@@ -28,14 +29,22 @@ type Task struct {
 	Description string     `json:"description,omitempty"`
 	Status      TaskStatus `json:"status"`
 	Owner       string     `json:"owner,omitempty"`
+	Reviewer    string     `json:"reviewer,omitempty"`
 	CreatedBy   string     `json:"createdBy"`
 	CreatedAt   time.Time  `json:"createdAt"`
 	UpdatedAt   time.Time  `json:"updatedAt"`
 	DoneAt      *time.Time `json:"doneAt,omitempty"`
 	BlockedBy   []string   `json:"blockedBy,omitempty"`
 	Blocks      []string   `json:"blocks,omitempty"`
+	Paths       []string   `json:"paths,omitempty"` // locked while the task is claimed
 	Comments    []Comment  `json:"comments,omitempty"`
+	Links       []Link     `json:"links,omitempty"`
+	History     []Event    `json:"history,omitempty"`
 	Shared      bool       `json:"shared"`
+
+	// LeaseExpiresAt is when a claim lapses unless the owner sends a
+	// heartbeat. Only claimed tasks have a lease.
+	LeaseExpiresAt *time.Time `json:"leaseExpiresAt,omitempty"`
 }
 
 // NewTask creates a new task with generated ID.
@@ -57,22 +66,64 @@ func NewTask(title, description, author string, shared bool) *Task {
 func (t *Task) Claim(owner string) {
 	t.Owner = owner
 	t.Status = TaskClaimed
-	t.UpdatedAt = time.Now().UTC()
+	t.record(EventClaimed, owner)
 }
 
 // Drop releases the task ownership.
-func (t *Task) Drop() {
+func (t *Task) Drop(actor string) {
 	t.Owner = ""
 	t.Status = TaskOpen
-	t.UpdatedAt = time.Now().UTC()
+	t.record(EventDropped, actor)
+}
+
+// Assign gives the task to owner on actor's behalf.
+func (t *Task) Assign(owner, actor string) {
+	t.Owner = owner
+	t.Status = TaskClaimed
+	t.recordDetail(EventAssigned, actor, owner)
+}
+
+// RequestReview hands the task to reviewer for review. The owner keeps
+// it, so a rejection comes back to them.
+func (t *Task) RequestReview(reviewer, actor string) {
+	t.Reviewer = reviewer
+	t.Status = TaskReview
+	t.recordDetail(EventReviewRequested, actor, reviewer)
+}
+
+// Approve accepts the reviewed work and marks the task done.
+func (t *Task) Approve(actor string) {
+	t.Status = TaskDone
+	e := t.record(EventApproved, actor)
+	t.DoneAt = &e.At
+}
+
+// Reject sends the task back to its owner.
+func (t *Task) Reject(actor string) {
+	t.Status = TaskClaimed
+	t.record(EventRejected, actor)
 }
 
 // Done marks the task as complete.
-func (t *Task) Done() {
-	now := time.Now().UTC()
+func (t *Task) Done(actor string) {
 	t.Status = TaskDone
-	t.DoneAt = &now
-	t.UpdatedAt = now
+	e := t.record(EventDone, actor)
+	t.DoneAt = &e.At
+}
+
+// record appends an event to the task's history.
+func (t *Task) record(typ EventType, actor string) Event {
+	return t.recordDetail(typ, actor, "")
+}
+
+// recordDetail appends an event naming who or what it concerns.
+func (t *Task) recordDetail(typ EventType, actor, detail string) Event {
+	e := newEvent(typ, actor)
+	e.Detail = detail
+	t.History = append(t.History, e)
+	t.UpdatedAt = e.At
+	t.renewLease(e.At)
+	return e
 }
 
 // AddComment adds a comment to the task.
@@ -85,11 +136,32 @@ func (t *Task) AddComment(author, content string) {
 	t.UpdatedAt = time.Now().UTC()
 }
 
-// IsBlocked returns true if any blocking tasks are not done.
-func (t *Task) IsBlocked(tasks map[string]*Task) bool {
+// LinkCommit records a commit SHA on the task.
+// Returns false if the commit was already linked.
+func (t *Task) LinkCommit(sha string) bool {
+	return t.AddLink(Link{Type: LinkCommit, Target: sha})
+}
+
+// AddLink adds a typed link to the task.
+// Returns false if the link already exists.
+func (t *Task) AddLink(l Link) bool {
+	links, added := AddLink(t.Links, l)
+	if added {
+		t.Links = links
+		t.UpdatedAt = time.Now().UTC()
+	}
+	return added
+}
+
+// IsBlocked returns true if any blocking tasks are not in a done status
+// of the workflow. A nil workflow is the default one.
+func (t *Task) IsBlocked(tasks map[string]*Task, w *Workflow) bool {
+	if w == nil {
+		w = DefaultWorkflow()
+	}
 	for _, id := range t.BlockedBy {
 		if blocker, ok := tasks[id]; ok {
-			if blocker.Status != TaskDone {
+			if !w.IsDone(blocker.Status) {
 				return true
 			}
 		}
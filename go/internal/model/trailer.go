@@ -0,0 +1,65 @@
+package model
+
+import (
+	"strings"
+)
+
+// TaskTrailerKeys are the commit message trailers that reference tasks.
+// A commit carrying one of these trailers is linked to the task and
+// closes it.
+var TaskTrailerKeys = []string{"Task", "Closes", "Fixes", "Resolves"}
+
+// ParseTaskTrailers returns the task IDs referenced by trailers in a
+// commit message. Only the final paragraph of the message is considered,
+// following git's trailer convention.
+func ParseTaskTrailers(message string) []string {
+	message = strings.TrimSpace(message)
+	if message == "" {
+		return nil
+	}
+
+	// Trailers live in the last paragraph
+	paragraphs := strings.Split(strings.ReplaceAll(message, "\r\n", "\n"), "\n\n")
+	last := paragraphs[len(paragraphs)-1]
+
+	var ids []string
+	seen := make(map[string]bool)
+	for _, line := range strings.Split(last, "\n") {
+		key, value, ok := splitTrailer(line)
+		if !ok || !isTaskTrailerKey(key) {
+			continue
+		}
+		// Allow "Closes: task-a, task-b"
+		for _, id := range strings.FieldsFunc(value, func(r rune) bool {
+			return r == ',' || r == ' ' || r == '\t'
+		}) {
+			if strings.HasPrefix(id, "task-") && !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+			}
+		}
+	}
+
+	return ids
+}
+
+func splitTrailer(line string) (string, string, bool) {
+	i := strings.Index(line, ":")
+	if i <= 0 {
+		return "", "", false
+	}
+	key := line[:i]
+	if strings.ContainsAny(key, " \t") {
+		return "", "", false
+	}
+	return key, strings.TrimSpace(line[i+1:]), true
+}
+
+func isTaskTrailerKey(key string) bool {
+	for _, k := range TaskTrailerKeys {
+		if strings.EqualFold(k, key) {
+			return true
+		}
+	}
+	return false
+}
@@ -7,11 +7,20 @@ import (
 	"strings"
 )
 
-// GenerateID generates a random 8-character hex ID.
+// IDLength is the number of hex characters in generated IDs. It is set
+// from the id.length setting.
+var IDLength = 8
+
+// GenerateID generates a random hex ID of IDLength characters.
 func GenerateID() string {
-	bytes := make([]byte, 4)
+	return RandomHex(IDLength)
+}
+
+// RandomHex returns n random hex characters.
+func RandomHex(n int) string {
+	bytes := make([]byte, (n+1)/2)
 	rand.Read(bytes)
-	return hex.EncodeToString(bytes)
+	return hex.EncodeToString(bytes)[:n]
 }
 
 // GetAuthor returns the git user name and email as "Name <email>".
@@ -0,0 +1,136 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+)
+
+// WorkflowStatus is one status in a workflow.
+type WorkflowStatus struct {
+	Name TaskStatus `json:"name" yaml:"name"`
+	// Done statuses count as finished: a task blocked only by finished
+	// tasks is no longer blocked.
+	Done bool `json:"done,omitempty" yaml:"done,omitempty"`
+	// Next lists the statuses a task may move to from this one.
+	Next []TaskStatus `json:"next,omitempty" yaml:"next,omitempty"`
+}
+
+// Workflow defines the statuses a task can have and how it may move
+// between them. Every workflow has the built-in statuses open, claimed
+// and done, which task add, claim, drop and done use. Review handoffs
+// also need review.
+type Workflow struct {
+	Statuses []WorkflowStatus `json:"statuses" yaml:"statuses"`
+}
+
+// DefaultWorkflow is the workflow used when none is defined.
+func DefaultWorkflow() *Workflow {
+	return &Workflow{Statuses: []WorkflowStatus{
+		{Name: TaskOpen, Next: []TaskStatus{TaskClaimed, TaskDone}},
+		{Name: TaskClaimed, Next: []TaskStatus{TaskOpen, TaskReview, TaskDone}},
+		{Name: TaskReview, Next: []TaskStatus{TaskClaimed, TaskDone}},
+		{Name: TaskDone, Done: true, Next: []TaskStatus{TaskOpen}},
+	}}
+}
+
+// Status returns the named status, or nil if the workflow has none.
+func (w *Workflow) Status(name TaskStatus) *WorkflowStatus {
+	for i := range w.Statuses {
+		if w.Statuses[i].Name == name {
+			return &w.Statuses[i]
+		}
+	}
+	return nil
+}
+
+// Names returns the statuses in order.
+func (w *Workflow) Names() []TaskStatus {
+	names := make([]TaskStatus, len(w.Statuses))
+	for i, s := range w.Statuses {
+		names[i] = s.Name
+	}
+	return names
+}
+
+// IsDone reports whether a status counts as finished. Statuses outside
+// the workflow only count if they are the built-in done.
+func (w *Workflow) IsDone(status TaskStatus) bool {
+	if s := w.Status(status); s != nil {
+		return s.Done
+	}
+	return status == TaskDone
+}
+
+// CanMove reports whether a task may move from one status to another.
+func (w *Workflow) CanMove(from, to TaskStatus) bool {
+	if w.Status(to) == nil {
+		return false
+	}
+	s := w.Status(from)
+	if s == nil {
+		// A status dropped from the workflow can move anywhere, so old
+		// tasks are not stuck.
+		return true
+	}
+	for _, next := range s.Next {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate checks that the workflow is usable.
+func (w *Workflow) Validate() error {
+	seen := make(map[TaskStatus]bool)
+	for _, s := range w.Statuses {
+		name := string(s.Name)
+		if name == "" || strings.TrimSpace(name) != name || strings.ContainsAny(name, " \t\n,") {
+			return fmt.Errorf("invalid status name %q", name)
+		}
+		if seen[s.Name] {
+			return fmt.Errorf("status %s is defined twice", name)
+		}
+		seen[s.Name] = true
+	}
+
+	for _, builtin := range []TaskStatus{TaskOpen, TaskClaimed, TaskDone} {
+		if !seen[builtin] {
+			return fmt.Errorf("workflow must have the %s status", builtin)
+		}
+	}
+	if !w.IsDone(TaskDone) {
+		return fmt.Errorf("status done must be marked done")
+	}
+	if w.IsDone(TaskOpen) || w.IsDone(TaskClaimed) || w.IsDone(TaskReview) {
+		return fmt.Errorf("statuses open, claimed and review cannot be marked done")
+	}
+
+	for _, s := range w.Statuses {
+		for _, next := range s.Next {
+			if !seen[next] {
+				return fmt.Errorf("status %s moves to unknown status %s", s.Name, next)
+			}
+			if next == s.Name {
+				return fmt.Errorf("status %s moves to itself", s.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// Move changes the task's status. Moving into a done status records
+// when; moving out of one clears it. Moving to open releases the owner,
+// as drop does.
+func (t *Task) Move(to TaskStatus, actor string, w *Workflow) {
+	t.Status = to
+	if to == TaskOpen {
+		t.Owner = ""
+	}
+	e := t.record(EventMoved, actor)
+	if w.IsDone(to) {
+		t.DoneAt = &e.At
+	} else {
+		t.DoneAt = nil
+	}
+}
@@ -0,0 +1,303 @@
+// Package output renders listings in the formats every listing command
+// supports: an aligned table for people, and JSON, JSON Lines, YAML,
+// CSV, markdown or a Go template for everything else.
+package output
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"text/tabwriter"
+	"text/template"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Format is an output format.
+type Format string
+
+const (
+	Table    Format = "table"
+	JSON     Format = "json"
+	JSONL    Format = "jsonl"
+	YAML     Format = "yaml"
+	CSV      Format = "csv"
+	Markdown Format = "markdown"
+)
+
+// Formats lists the formats in the order they are documented.
+var Formats = []Format{Table, JSON, JSONL, YAML, CSV, Markdown}
+
+// ParseFormat validates a format name.
+func ParseFormat(s string) (Format, error) {
+	for _, f := range Formats {
+		if string(f) == s {
+			return f, nil
+		}
+	}
+	return "", fmt.Errorf("invalid format %q (valid: %s)", s, FormatNames())
+}
+
+// FormatNames returns the format names as a comma-separated list.
+func FormatNames() string {
+	names := make([]string, len(Formats))
+	for i, f := range Formats {
+		names[i] = string(f)
+	}
+	return strings.Join(names, ", ")
+}
+
+// Column is one column of a table, CSV or markdown listing.
+type Column struct {
+	Header string
+	// Max truncates longer values in tables; 0 means no limit. CSV and
+	// markdown always get the full value.
+	Max int
+}
+
+// List is a listing of records.
+type List struct {
+	Columns []Column
+	Rows    [][]string // one row of cells per item, in column order
+
+	// Raw, if set, are the cells CSV writes instead of Rows: values as
+	// stored, such as RFC 3339 times and empty strings, rather than as
+	// tables show them.
+	Raw [][]string
+
+	// Items are the records themselves, a slice with one element per
+	// row. They are what JSON, JSON Lines, YAML and templates render.
+	Items interface{}
+
+	// Title is printed above a table, and Empty instead of a table with
+	// no rows.
+	Title string
+	Empty string
+}
+
+// Options say how to render a list.
+type Options struct {
+	Format Format
+	// Template, if set, is executed for each item instead of Format.
+	Template string
+}
+
+// Write renders l to w.
+func Write(w io.Writer, l *List, opts Options) error {
+	if opts.Template != "" {
+		return writeTemplate(w, l, opts.Template)
+	}
+
+	switch opts.Format {
+	case JSON:
+		data, err := json.MarshalIndent(items(l), "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, string(data))
+		return err
+	case JSONL:
+		for _, item := range items(l) {
+			data, err := json.Marshal(item)
+			if err != nil {
+				return err
+			}
+			if _, err := fmt.Fprintln(w, string(data)); err != nil {
+				return err
+			}
+		}
+		return nil
+	case YAML:
+		return writeYAML(w, items(l))
+	case CSV:
+		return writeCSV(w, l)
+	case Markdown:
+		return writeMarkdown(w, l)
+	case Table, "":
+		return writeTable(w, l)
+	}
+	return fmt.Errorf("invalid format %q (valid: %s)", opts.Format, FormatNames())
+}
+
+// items returns the elements of l.Items, never nil.
+func items(l *List) []interface{} {
+	result := []interface{}{}
+	if l.Items == nil {
+		return result
+	}
+	v := reflect.ValueOf(l.Items)
+	if v.Kind() != reflect.Slice {
+		return append(result, l.Items)
+	}
+	for i := 0; i < v.Len(); i++ {
+		result = append(result, v.Index(i).Interface())
+	}
+	return result
+}
+
+// TemplateFuncs are available to --template in addition to the
+// text/template builtins.
+var TemplateFuncs = template.FuncMap{
+	"join":  strings.Join,
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+	"json": func(v interface{}) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+	"truncate": func(n int, s string) string {
+		if r := []rune(s); len(r) > n && n > 3 {
+			return string(r[:n-3]) + "..."
+		}
+		return s
+	},
+}
+
+func writeTemplate(w io.Writer, l *List, text string) error {
+	tmpl, err := template.New("item").Funcs(TemplateFuncs).Parse(text)
+	if err != nil {
+		return fmt.Errorf("invalid template: %w", err)
+	}
+
+	for _, item := range items(l) {
+		var b bytes.Buffer
+		if err := tmpl.Execute(&b, item); err != nil {
+			return fmt.Errorf("template failed: %w", err)
+		}
+		out := b.String()
+		if !strings.HasSuffix(out, "\n") {
+			out += "\n"
+		}
+		if _, err := io.WriteString(w, out); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeTable(w io.Writer, l *List) error {
+	if len(l.Rows) == 0 && l.Empty != "" {
+		_, err := fmt.Fprintln(w, l.Empty)
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	if l.Title != "" {
+		fmt.Fprintf(tw, "%s\n\n", l.Title)
+	}
+
+	headers := make([]string, len(l.Columns))
+	rules := make([]string, len(l.Columns))
+	for i, c := range l.Columns {
+		headers[i] = c.Header
+		n := len(c.Header)
+		if n < 4 {
+			n = 4
+		}
+		rules[i] = strings.Repeat("-", n)
+	}
+	fmt.Fprintln(tw, strings.Join(headers, "\t"))
+	fmt.Fprintln(tw, strings.Join(rules, "\t"))
+
+	for _, row := range l.Rows {
+		cells := make([]string, len(row))
+		for i, cell := range row {
+			if i < len(l.Columns) && l.Columns[i].Max > 0 {
+				cell = truncateCell(cell, l.Columns[i].Max)
+			}
+			cells[i] = cell
+		}
+		fmt.Fprintln(tw, strings.Join(cells, "\t"))
+	}
+	return tw.Flush()
+}
+
+// truncateCell shortens s to at most n runes on one line.
+func truncateCell(s string, n int) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if r := []rune(s); len(r) > n {
+		return string(r[:n-3]) + "..."
+	}
+	return s
+}
+
+func writeCSV(w io.Writer, l *List) error {
+	cw := csv.NewWriter(w)
+	headers := make([]string, len(l.Columns))
+	for i, c := range l.Columns {
+		headers[i] = strings.ToLower(strings.ReplaceAll(c.Header, " ", "_"))
+	}
+	if err := cw.Write(headers); err != nil {
+		return err
+	}
+	rows := l.Raw
+	if rows == nil {
+		rows = l.Rows
+	}
+	if err := cw.WriteAll(rows); err != nil {
+		return err
+	}
+	return cw.Error()
+}
+
+func writeMarkdown(w io.Writer, l *List) error {
+	var b strings.Builder
+	cells := func(values []string) {
+		b.WriteString("|")
+		for _, v := range values {
+			v = strings.Join(strings.Fields(v), " ")
+			b.WriteString(" " + strings.ReplaceAll(v, "|", `\|`) + " |")
+		}
+		b.WriteString("\n")
+	}
+
+	headers := make([]string, len(l.Columns))
+	rules := make([]string, len(l.Columns))
+	for i, c := range l.Columns {
+		headers[i] = c.Header
+		rules[i] = "---"
+	}
+	cells(headers)
+	cells(rules)
+	for _, row := range l.Rows {
+		cells(row)
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// writeYAML renders values as YAML with the same keys, in the same
+// order, as JSON.
+func writeYAML(w io.Writer, values []interface{}) error {
+	data, err := json.Marshal(values)
+	if err != nil {
+		return err
+	}
+
+	// JSON is YAML, so decoding it keeps the key order; clearing the
+	// styles turns the flow-style JSON into block-style YAML.
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return err
+	}
+	clearStyle(&node)
+
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(&node); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+func clearStyle(n *yaml.Node) {
+	n.Style = 0
+	for _, c := range n.Content {
+		clearStyle(c)
+	}
+}
@@ -0,0 +1,619 @@
+// Package server exposes context storage as a local HTTP/JSON API, so
+// tools not written in Go can read and write context without parsing
+// CLI output.
+package server
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/user/git-context/gitctx"
+	"github.com/user/git-context/internal/model"
+)
+
+// AuthorHeader names the request header that says who is acting.
+// Without it, the server's own git user is used.
+const AuthorHeader = "X-Git-Ctx-Author"
+
+//go:embed openapi.json
+var openAPISpec []byte
+
+// Server handles API requests with a gitctx client.
+type Server struct {
+	client *gitctx.Client
+
+	// Writes are read-modify-write; one at a time keeps claims atomic
+	// within this server.
+	mu sync.Mutex
+}
+
+// New creates a server. The client's author is used for requests
+// without an AuthorHeader.
+func New(client *gitctx.Client) *Server {
+	return &Server{client: client}
+}
+
+// httpError is an error with the status code to answer with.
+type httpError struct {
+	status int
+	msg    string
+}
+
+func (e *httpError) Error() string { return e.msg }
+
+func errorf(status int, format string, args ...interface{}) error {
+	return &httpError{status: status, msg: fmt.Sprintf(format, args...)}
+}
+
+var errMethod = errorf(http.StatusMethodNotAllowed, "method not allowed")
+
+// ServeHTTP routes a request.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+	}
+
+	var status int
+	var body interface{}
+	err := checkRequest(r)
+	if err == nil {
+		status, body, err = s.route(r)
+	}
+	if err != nil {
+		status = statusOf(err)
+		body = map[string]string{"error": err.Error()}
+	}
+
+	if raw, ok := body.([]byte); ok {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		w.Write(raw)
+		return
+	}
+	writeJSON(w, status, body)
+}
+
+// checkRequest guards against other origins driving the API through a
+// browser. The Host must be a loopback name, which defeats DNS
+// rebinding; an Origin, if sent, must match it; and writes must be JSON,
+// which a plain HTML form or no-cors fetch cannot send.
+func checkRequest(r *http.Request) error {
+	if !isLoopback(r.Host) {
+		return errorf(http.StatusForbidden, "host %q is not a loopback address", r.Host)
+	}
+	if origin := r.Header.Get("Origin"); origin != "" {
+		u, err := url.Parse(origin)
+		if err != nil || u.Host != r.Host {
+			return errorf(http.StatusForbidden, "cross-origin request from %q", origin)
+		}
+	}
+
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return nil
+	}
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || mediaType != "application/json" {
+		return errorf(http.StatusUnsupportedMediaType, "Content-Type must be application/json")
+	}
+	return nil
+}
+
+// isLoopback reports whether host (with optional port) names this
+// machine.
+func isLoopback(host string) bool {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.Trim(host, "[]")
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// statusOf maps an error to its HTTP status.
+func statusOf(err error) int {
+	var he *httpError
+	switch {
+	case errors.As(err, &he):
+		return he.status
+	case errors.Is(err, gitctx.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, gitctx.ErrLocked):
+		return http.StatusLocked
+	case errors.Is(err, gitctx.ErrConflict):
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
+
+func (s *Server) route(r *http.Request) (int, interface{}, error) {
+	path := strings.Trim(r.URL.Path, "/")
+	parts := strings.Split(path, "/")
+
+	switch parts[0] {
+	case "openapi.json":
+		if r.Method != http.MethodGet {
+			return 0, nil, errMethod
+		}
+		return http.StatusOK, openAPISpec, nil
+	case "memories":
+		return s.routeMemories(r, parts[1:])
+	case "tasks":
+		return s.routeTasks(r, parts[1:])
+	case "locks":
+		// Lock targets are paths; take the rest of the raw path so
+		// encoded and plain slashes both work
+		rest := strings.TrimPrefix(strings.TrimPrefix(r.URL.EscapedPath(), "/"), "locks")
+		target, err := url.PathUnescape(strings.TrimPrefix(rest, "/"))
+		if err != nil {
+			return 0, nil, errorf(http.StatusBadRequest, "invalid lock target: %v", err)
+		}
+		return s.routeLocks(r, target)
+	}
+
+	return 0, nil, errorf(http.StatusNotFound, "no such endpoint: /%s", path)
+}
+
+// Memories
+
+type memoryInput struct {
+	Title   *string   `json:"title"`
+	Content *string   `json:"content"`
+	Kind    *string   `json:"kind"`
+	Tags    *[]string `json:"tags"`
+	Shared  bool      `json:"shared"`
+}
+
+func (s *Server) routeMemories(r *http.Request, parts []string) (int, interface{}, error) {
+	c := s.clientFor(r)
+	ctx := r.Context()
+
+	if len(parts) == 0 || parts[0] == "" {
+		switch r.Method {
+		case http.MethodGet:
+			return s.listMemories(r)
+		case http.MethodPost:
+			return s.createMemory(r)
+		}
+		return 0, nil, errMethod
+	}
+	if len(parts) > 1 {
+		return 0, nil, errorf(http.StatusNotFound, "no such endpoint: %s", r.URL.Path)
+	}
+
+	id := parts[0]
+	switch r.Method {
+	case http.MethodGet:
+		return result(http.StatusOK)(c.GetMemory(ctx, id))
+	case http.MethodPatch:
+		var in memoryInput
+		if err := decode(r, &in); err != nil {
+			return 0, nil, err
+		}
+		return result(http.StatusOK)(c.UpdateMemory(ctx, id, func(m *gitctx.Memory) error {
+			return applyMemoryInput(m, &in)
+		}))
+	case http.MethodDelete:
+		if err := c.DeleteMemory(ctx, id); err != nil {
+			return 0, nil, err
+		}
+		return http.StatusNoContent, nil, nil
+	}
+	return 0, nil, errMethod
+}
+
+func (s *Server) listMemories(r *http.Request) (int, interface{}, error) {
+	q := r.URL.Query()
+	var kind model.MemoryKind
+	if q.Get("kind") != "" {
+		var err error
+		if kind, err = model.ParseKind(q.Get("kind")); err != nil {
+			return 0, nil, errorf(http.StatusBadRequest, "%v", err)
+		}
+	}
+	loc, err := location(q.Get("storage"))
+	if err != nil {
+		return 0, nil, err
+	}
+
+	list, err := s.client.SearchMemories(r.Context(), q.Get("q"), gitctx.ListOptions{
+		Location:        loc,
+		IncludeArchived: q.Get("archived") == "true",
+	})
+	if err != nil {
+		return 0, nil, err
+	}
+
+	memories := []*gitctx.Memory{}
+	for _, m := range list {
+		if kind == "" || m.IsKind(kind) {
+			memories = append(memories, m)
+		}
+	}
+	return http.StatusOK, memories, nil
+}
+
+func (s *Server) createMemory(r *http.Request) (int, interface{}, error) {
+	var in memoryInput
+	if err := decode(r, &in); err != nil {
+		return 0, nil, err
+	}
+	if in.Title == nil || strings.TrimSpace(*in.Title) == "" {
+		return 0, nil, errorf(http.StatusBadRequest, "title is required")
+	}
+
+	// Validate everything before anything is written
+	probe := &gitctx.Memory{}
+	if err := applyMemoryInput(probe, &in); err != nil {
+		return 0, nil, err
+	}
+
+	return result(http.StatusCreated)(s.clientFor(r).AddMemory(r.Context(), probe.Title, probe.Content, gitctx.MemoryOptions{
+		Kind:   probe.Kind,
+		Tags:   probe.Tags,
+		Shared: in.Shared,
+	}))
+}
+
+func applyMemoryInput(m *gitctx.Memory, in *memoryInput) error {
+	if in.Title != nil {
+		if strings.TrimSpace(*in.Title) == "" {
+			return errorf(http.StatusBadRequest, "title cannot be empty")
+		}
+		m.Title = *in.Title
+	}
+	if in.Content != nil {
+		m.Content = *in.Content
+	}
+	if in.Kind != nil {
+		kind, err := model.ParseKind(*in.Kind)
+		if err != nil {
+			return errorf(http.StatusBadRequest, "%v", err)
+		}
+		m.Kind = kind
+	}
+	if in.Tags != nil {
+		m.Tags = *in.Tags
+	}
+	return nil
+}
+
+// Tasks
+
+type taskInput struct {
+	Title       *string   `json:"title"`
+	Description *string   `json:"description"`
+	BlockedBy   *[]string `json:"blockedBy"`
+	Shared      bool      `json:"shared"`
+}
+
+type commentInput struct {
+	Content string `json:"content"`
+}
+
+type moveInput struct {
+	Status gitctx.TaskStatus `json:"status"`
+}
+
+type assignInput struct {
+	Agent string `json:"agent"`
+}
+
+type reviewInput struct {
+	Reviewer string `json:"reviewer"`
+}
+
+type claimInput struct {
+	Paths []string `json:"paths"`
+}
+
+func (s *Server) routeTasks(r *http.Request, parts []string) (int, interface{}, error) {
+	c := s.clientFor(r)
+	ctx := r.Context()
+
+	if len(parts) == 0 || parts[0] == "" {
+		switch r.Method {
+		case http.MethodGet:
+			return s.listTasks(r)
+		case http.MethodPost:
+			return s.createTask(r)
+		}
+		return 0, nil, errMethod
+	}
+	if len(parts) > 2 {
+		return 0, nil, errorf(http.StatusNotFound, "no such endpoint: %s", r.URL.Path)
+	}
+
+	id := parts[0]
+	if len(parts) == 1 {
+		switch r.Method {
+		case http.MethodGet:
+			return result(http.StatusOK)(c.GetTask(ctx, id))
+		case http.MethodPatch:
+			var in taskInput
+			if err := decode(r, &in); err != nil {
+				return 0, nil, err
+			}
+			if in.Title != nil && strings.TrimSpace(*in.Title) == "" {
+				return 0, nil, errorf(http.StatusBadRequest, "title cannot be empty")
+			}
+			return result(http.StatusOK)(c.UpdateTask(ctx, id, func(t *gitctx.Task) error {
+				if in.Title != nil {
+					t.Title = *in.Title
+				}
+				if in.Description != nil {
+					t.Description = *in.Description
+				}
+				if in.BlockedBy != nil {
+					t.BlockedBy = *in.BlockedBy
+				}
+				return nil
+			}))
+		case http.MethodDelete:
+			if err := c.DeleteTask(ctx, id); err != nil {
+				return 0, nil, err
+			}
+			return http.StatusNoContent, nil, nil
+		}
+		return 0, nil, errMethod
+	}
+
+	action := parts[1]
+	if action == "comments" && r.Method == http.MethodGet {
+		t, err := c.GetTask(ctx, id)
+		if err != nil {
+			return 0, nil, err
+		}
+		comments := t.Comments
+		if comments == nil {
+			comments = []gitctx.Comment{}
+		}
+		return http.StatusOK, comments, nil
+	}
+	if r.Method != http.MethodPost {
+		return 0, nil, errMethod
+	}
+
+	switch action {
+	case "claim":
+		var in claimInput
+		if r.ContentLength != 0 {
+			if err := decode(r, &in); err != nil {
+				return 0, nil, err
+			}
+		}
+		return result(http.StatusOK)(c.ClaimTaskAndLock(ctx, id, in.Paths))
+	case "drop":
+		return result(http.StatusOK)(c.DropTask(ctx, id))
+	case "done":
+		return result(http.StatusOK)(c.CompleteTask(ctx, id))
+	case "heartbeat":
+		return result(http.StatusOK)(c.HeartbeatTask(ctx, id))
+	case "move":
+		var in moveInput
+		if err := decode(r, &in); err != nil {
+			return 0, nil, err
+		}
+		w, err := c.Workflow(ctx)
+		if err != nil {
+			return 0, nil, err
+		}
+		if w.Status(in.Status) == nil {
+			return 0, nil, errorf(http.StatusBadRequest, "unknown status %q", in.Status)
+		}
+		return result(http.StatusOK)(c.MoveTask(ctx, id, in.Status))
+	case "assign":
+		var in assignInput
+		if err := decode(r, &in); err != nil {
+			return 0, nil, err
+		}
+		if strings.TrimSpace(in.Agent) == "" {
+			return 0, nil, errorf(http.StatusBadRequest, "agent is required")
+		}
+		return result(http.StatusOK)(c.AssignTask(ctx, id, in.Agent))
+	case "review":
+		var in reviewInput
+		if err := decode(r, &in); err != nil {
+			return 0, nil, err
+		}
+		if strings.TrimSpace(in.Reviewer) == "" {
+			return 0, nil, errorf(http.StatusBadRequest, "reviewer is required")
+		}
+		return result(http.StatusOK)(c.RequestReview(ctx, id, in.Reviewer))
+	case "approve", "reject":
+		var in commentInput
+		if err := decode(r, &in); err != nil {
+			return 0, nil, err
+		}
+		if strings.TrimSpace(in.Content) == "" {
+			return 0, nil, errorf(http.StatusBadRequest, "content is required")
+		}
+		if action == "approve" {
+			return result(http.StatusOK)(c.ApproveTask(ctx, id, in.Content))
+		}
+		return result(http.StatusOK)(c.RejectTask(ctx, id, in.Content))
+	case "comments":
+		var in commentInput
+		if err := decode(r, &in); err != nil {
+			return 0, nil, err
+		}
+		if strings.TrimSpace(in.Content) == "" {
+			return 0, nil, errorf(http.StatusBadRequest, "content is required")
+		}
+		t, err := c.CommentTask(ctx, id, in.Content)
+		if err != nil {
+			return 0, nil, err
+		}
+		return http.StatusCreated, t.Comments[len(t.Comments)-1], nil
+	}
+	return 0, nil, errorf(http.StatusNotFound, "no such endpoint: %s", r.URL.Path)
+}
+
+func (s *Server) listTasks(r *http.Request) (int, interface{}, error) {
+	q := r.URL.Query()
+	loc, err := location(q.Get("storage"))
+	if err != nil {
+		return 0, nil, err
+	}
+
+	list, err := s.client.ListTasks(r.Context(), gitctx.TaskFilter{
+		Location: loc,
+		Status:   gitctx.TaskStatus(q.Get("status")),
+		Owner:    q.Get("owner"),
+	})
+	if err != nil {
+		return 0, nil, err
+	}
+
+	tasks := []*gitctx.Task{}
+	return http.StatusOK, append(tasks, list...), nil
+}
+
+func (s *Server) createTask(r *http.Request) (int, interface{}, error) {
+	var in taskInput
+	if err := decode(r, &in); err != nil {
+		return 0, nil, err
+	}
+	if in.Title == nil || strings.TrimSpace(*in.Title) == "" {
+		return 0, nil, errorf(http.StatusBadRequest, "title is required")
+	}
+
+	opts := gitctx.TaskOptions{Shared: in.Shared}
+	if in.Description != nil {
+		opts.Description = *in.Description
+	}
+	if in.BlockedBy != nil {
+		opts.BlockedBy = *in.BlockedBy
+	}
+	return result(http.StatusCreated)(s.clientFor(r).AddTask(r.Context(), *in.Title, opts))
+}
+
+// Locks
+
+type lockInput struct {
+	Target string `json:"target"`
+	Mode   string `json:"mode"`
+	Reason string `json:"reason"`
+	Shared bool   `json:"shared"`
+}
+
+func (s *Server) routeLocks(r *http.Request, target string) (int, interface{}, error) {
+	c := s.clientFor(r)
+	ctx := r.Context()
+
+	if target == "" {
+		switch r.Method {
+		case http.MethodGet:
+			loc, err := location(r.URL.Query().Get("storage"))
+			if err != nil {
+				return 0, nil, err
+			}
+			locks, err := c.ListLocks(ctx, loc)
+			if err != nil {
+				return 0, nil, err
+			}
+			return http.StatusOK, append([]*gitctx.Lock{}, locks...), nil
+		case http.MethodPost:
+			var in lockInput
+			if err := decode(r, &in); err != nil {
+				return 0, nil, err
+			}
+			if in.Target == "" {
+				return 0, nil, errorf(http.StatusBadRequest, "target is required")
+			}
+			mode := gitctx.LockMode(in.Mode)
+			switch mode {
+			case "":
+				mode = gitctx.LockExclusive
+			case gitctx.LockExclusive, gitctx.LockShared:
+			default:
+				return 0, nil, errorf(http.StatusBadRequest, "mode must be exclusive or shared")
+			}
+			l, _, err := c.LockWith(ctx, in.Target, gitctx.LockOptions{
+				Mode:   mode,
+				Reason: in.Reason,
+				Shared: in.Shared,
+			})
+			return result(http.StatusCreated)(l, err)
+		}
+		return 0, nil, errMethod
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		return result(http.StatusOK)(c.GetLock(ctx, target))
+	case http.MethodDelete:
+		if _, err := c.Unlock(ctx, target); err != nil {
+			return 0, nil, err
+		}
+		return http.StatusNoContent, nil, nil
+	}
+	return 0, nil, errMethod
+}
+
+// Helpers
+
+// clientFor returns a client acting as the request's author.
+func (s *Server) clientFor(r *http.Request) *gitctx.Client {
+	if author := r.Header.Get(AuthorHeader); author != "" {
+		return s.client.WithAuthor(author)
+	}
+	return s.client
+}
+
+// result turns a client call's (value, error) into a route result.
+func result(status int) func(interface{}, error) (int, interface{}, error) {
+	return func(v interface{}, err error) (int, interface{}, error) {
+		if err != nil {
+			return 0, nil, err
+		}
+		return status, v, nil
+	}
+}
+
+// location parses a ?storage= parameter: local, shared, or all (the
+// default).
+func location(value string) (gitctx.Location, error) {
+	switch value {
+	case "local":
+		return gitctx.Local, nil
+	case "shared":
+		return gitctx.Shared, nil
+	case "", "all":
+		return gitctx.All, nil
+	}
+	return "", errorf(http.StatusBadRequest, "invalid storage %q (valid: local, shared, all)", value)
+}
+
+func decode(r *http.Request, v interface{}) error {
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return errorf(http.StatusBadRequest, "invalid JSON body: %v", err)
+	}
+	return nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	if body == nil {
+		w.WriteHeader(status)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(body)
+}
@@ -0,0 +1,121 @@
+package server_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/user/git-context/gitctx"
+	"github.com/user/git-context/internal/server"
+)
+
+// newServer serves the context of an empty repository.
+func newServer(t *testing.T) *server.Server {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+
+	dir := t.TempDir()
+	if out, err := exec.Command("git", "init", "--quiet", dir).CombinedOutput(); err != nil {
+		t.Fatalf("git init: %v: %s", err, out)
+	}
+	c, err := gitctx.Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return server.New(c)
+}
+
+// request is an API call as a local tool would make it.
+type request struct {
+	method, path, body string
+	host               string // 127.0.0.1:7373 if empty
+	author             string
+	header             map[string]string
+}
+
+// do sends req to s, and returns the response.
+func do(t *testing.T, s *server.Server, req request) *httptest.ResponseRecorder {
+	t.Helper()
+	r := httptest.NewRequest(req.method, req.path, strings.NewReader(req.body))
+	r.Host = req.host
+	if r.Host == "" {
+		r.Host = "127.0.0.1:7373"
+	}
+	if req.body != "" {
+		r.Header.Set("Content-Type", "application/json")
+	}
+	if req.author != "" {
+		r.Header.Set(server.AuthorHeader, req.author)
+	}
+	for k, v := range req.header {
+		r.Header.Set(k, v)
+	}
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, r)
+	return w
+}
+
+func expectStatus(t *testing.T, w *httptest.ResponseRecorder, want int) {
+	t.Helper()
+	if w.Code != want {
+		t.Errorf("got %d, want %d: %s", w.Code, want, w.Body.String())
+	}
+}
+
+func TestErrorStatuses(t *testing.T) {
+	s := newServer(t)
+
+	w := do(t, s, request{method: http.MethodGet, path: "/tasks/task-missing"})
+	expectStatus(t, w, http.StatusNotFound)
+
+	w = do(t, s, request{method: http.MethodPost, path: "/tasks", body: `{"title":"contended"}`, author: "alice"})
+	expectStatus(t, w, http.StatusCreated)
+	var task gitctx.Task
+	if err := json.Unmarshal(w.Body.Bytes(), &task); err != nil {
+		t.Fatal(err)
+	}
+	claim := "/tasks/" + task.ID + "/claim"
+	expectStatus(t, do(t, s, request{method: http.MethodPost, path: claim, body: "{}", author: "alice"}), http.StatusOK)
+	expectStatus(t, do(t, s, request{method: http.MethodPost, path: claim, body: "{}", author: "bob"}), http.StatusConflict)
+
+	lock := `{"target":"src/a.go"}`
+	expectStatus(t, do(t, s, request{method: http.MethodPost, path: "/locks", body: lock, author: "alice"}), http.StatusCreated)
+	expectStatus(t, do(t, s, request{method: http.MethodPost, path: "/locks", body: lock, author: "bob"}), http.StatusLocked)
+}
+
+func TestRejectsRequestsFromOtherOrigins(t *testing.T) {
+	s := newServer(t)
+	lock := `{"target":"src/a.go"}`
+
+	tests := []struct {
+		name string
+		req  request
+		want int
+	}{
+		{"foreign host", request{method: http.MethodGet, path: "/tasks", host: "evil.example"}, http.StatusForbidden},
+		{"foreign origin", request{method: http.MethodPost, path: "/locks", body: lock, header: map[string]string{"Origin": "http://evil.example"}}, http.StatusForbidden},
+		{"form post", request{method: http.MethodPost, path: "/locks", body: lock, header: map[string]string{"Content-Type": "application/x-www-form-urlencoded"}}, http.StatusUnsupportedMediaType},
+		{"text post", request{method: http.MethodPost, path: "/locks", body: lock, header: map[string]string{"Content-Type": "text/plain"}}, http.StatusUnsupportedMediaType},
+		{"same origin", request{method: http.MethodPost, path: "/locks", body: lock, header: map[string]string{"Origin": "http://127.0.0.1:7373"}}, http.StatusCreated},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expectStatus(t, do(t, s, tt.req), tt.want)
+		})
+	}
+
+	// Nothing was locked by the refused requests
+	w := do(t, s, request{method: http.MethodGet, path: "/locks"})
+	var locks []gitctx.Lock
+	if err := json.Unmarshal(w.Body.Bytes(), &locks); err != nil {
+		t.Fatal(err)
+	}
+	if len(locks) != 1 {
+		t.Errorf("%d locks, want only the same-origin one", len(locks))
+	}
+}
@@ -0,0 +1,35 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"os"
+)
+
+// Errors returned by Storage implementations. Check for them with
+// errors.Is; the returned errors name the entity involved.
+var (
+	// ErrNotFound means the memory, task, lock or template does not
+	// exist in this storage.
+	ErrNotFound = errors.New("not found")
+
+	// ErrConflict means the change clashes with the current state, such
+	// as an ID that is already taken or a concurrent update.
+	ErrConflict = errors.New("conflict")
+
+	// ErrLocked means the target is held by a live lock.
+	ErrLocked = errors.New("already locked")
+)
+
+// notFound returns ErrNotFound for an entity.
+func notFound(id string) error {
+	return fmt.Errorf("%w: %s", ErrNotFound, id)
+}
+
+// fileError turns a missing file into ErrNotFound for id.
+func fileError(err error, id string) error {
+	if errors.Is(err, os.ErrNotExist) {
+		return notFound(id)
+	}
+	return err
+}
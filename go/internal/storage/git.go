@@ -0,0 +1,223 @@
+package storage
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// zeroOID is the all-zero object ID git uses for "does not exist".
+const zeroOID = "0000000000000000000000000000000000000000"
+
+// gitRepo runs git plumbing commands against a repository.
+type gitRepo struct {
+	gitDir string
+
+	envOnce sync.Once
+	env     []string
+}
+
+// run executes git with the given stdin and returns trimmed stdout.
+func (g *gitRepo) run(stdin []byte, args ...string) (string, error) {
+	out, err := g.runRaw(stdin, args...)
+	return strings.TrimSpace(string(out)), err
+}
+
+// runRaw executes git and returns stdout untouched.
+func (g *gitRepo) runRaw(stdin []byte, args ...string) ([]byte, error) {
+	cmd := exec.Command("git", append([]string{"--git-dir", g.gitDir}, args...)...)
+	g.envOnce.Do(func() { g.env = g.identityEnv() })
+	cmd.Env = append(os.Environ(), g.env...)
+	if stdin != nil {
+		cmd.Stdin = bytes.NewReader(stdin)
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			msg = err.Error()
+		}
+		return stdout.Bytes(), fmt.Errorf("git %s: %s", args[0], msg)
+	}
+	return stdout.Bytes(), nil
+}
+
+// identityEnv supplies a committer identity when git has none configured,
+// so context commits never fail on a fresh machine.
+func (g *gitRepo) identityEnv() []string {
+	if os.Getenv("GIT_COMMITTER_EMAIL") != "" {
+		return nil
+	}
+	cmd := exec.Command("git", "--git-dir", g.gitDir, "config", "--get", "user.email")
+	if out, err := cmd.Output(); err == nil && len(bytes.TrimSpace(out)) > 0 {
+		return nil
+	}
+	return []string{
+		"GIT_AUTHOR_NAME=git-ctx", "GIT_AUTHOR_EMAIL=git-ctx@localhost",
+		"GIT_COMMITTER_NAME=git-ctx", "GIT_COMMITTER_EMAIL=git-ctx@localhost",
+	}
+}
+
+// resolveRef returns the commit a ref points to, or "" if it does not
+// exist.
+func (g *gitRepo) resolveRef(ref string) string {
+	sha, err := g.run(nil, "rev-parse", "--verify", "--quiet", ref+"^{commit}")
+	if err != nil {
+		return ""
+	}
+	return sha
+}
+
+// listRefs returns refname -> commit for all refs under prefix.
+func (g *gitRepo) listRefs(prefix string) (map[string]string, error) {
+	out, err := g.run(nil, "for-each-ref", "--format=%(refname) %(objectname)", prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	refs := make(map[string]string)
+	for _, line := range strings.Split(out, "\n") {
+		if line == "" {
+			continue
+		}
+		parts := strings.SplitN(line, " ", 2)
+		if len(parts) == 2 {
+			refs[parts[0]] = parts[1]
+		}
+	}
+	return refs, nil
+}
+
+// writeTree stores files as blobs and returns the tree holding them.
+// An empty map produces the empty tree.
+func (g *gitRepo) writeTree(files map[string][]byte) (string, error) {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var entries bytes.Buffer
+	for _, name := range names {
+		blob, err := g.run(files[name], "hash-object", "-w", "--stdin")
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(&entries, "100644 blob %s\t%s\n", blob, name)
+	}
+
+	return g.run(entries.Bytes(), "mktree")
+}
+
+// commitTree creates a commit for tree with the given parents.
+func (g *gitRepo) commitTree(tree, message string, parents ...string) (string, error) {
+	args := []string{"commit-tree", tree, "-m", message}
+	for _, p := range parents {
+		args = append(args, "-p", p)
+	}
+	return g.run(nil, args...)
+}
+
+// treeOf returns the tree of a commit.
+func (g *gitRepo) treeOf(commit string) (string, error) {
+	return g.run(nil, "rev-parse", commit+"^{tree}")
+}
+
+// updateRef moves ref to newValue if it still points at oldValue
+// ("" means the ref must not exist).
+func (g *gitRepo) updateRef(ref, newValue, oldValue, message string) error {
+	if oldValue == "" {
+		oldValue = zeroOID
+	}
+	_, err := g.run(nil, "update-ref", "-m", message, ref, newValue, oldValue)
+	if err != nil && isRaceError(err.Error()) {
+		return fmt.Errorf("%w: %s was changed concurrently", ErrConflict, ref)
+	}
+	return err
+}
+
+// isRaceError reports whether git update-ref failed because another
+// writer got to the ref first: it moved, was created, or is being
+// written right now.
+func isRaceError(msg string) bool {
+	for _, s := range []string{"but expected", "reference already exists", "File exists"} {
+		if strings.Contains(msg, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// isAncestor returns true if a is an ancestor of (or equal to) b.
+func (g *gitRepo) isAncestor(a, b string) bool {
+	_, err := g.run(nil, "merge-base", "--is-ancestor", a, b)
+	return err == nil
+}
+
+// commitTime returns the committer timestamp of a commit.
+func (g *gitRepo) commitTime(commit string) int64 {
+	out, err := g.run(nil, "show", "-s", "--format=%ct", commit)
+	if err != nil {
+		return 0
+	}
+	t, _ := strconv.ParseInt(out, 10, 64)
+	return t
+}
+
+// readFiles reads the named files of several commits in one
+// git cat-file --batch call. Missing files are absent from the result,
+// which is keyed by "<commit>:<file>".
+func (g *gitRepo) readFiles(specs []string) (map[string][]byte, error) {
+	result := make(map[string][]byte)
+	if len(specs) == 0 {
+		return result, nil
+	}
+
+	input := strings.Join(specs, "\n") + "\n"
+	out, err := g.runRaw([]byte(input), "cat-file", "--batch")
+	if err != nil {
+		return nil, err
+	}
+
+	r := bufio.NewReader(bytes.NewReader(out))
+	for _, spec := range specs {
+		header, err := r.ReadString('\n')
+		if err != nil {
+			return nil, fmt.Errorf("git cat-file: truncated output")
+		}
+		fields := strings.Fields(header)
+		if len(fields) == 2 && fields[1] == "missing" {
+			continue
+		}
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("git cat-file: unexpected header %q", header)
+		}
+
+		size, err := strconv.Atoi(fields[2])
+		if err != nil {
+			return nil, fmt.Errorf("git cat-file: unexpected header %q", header)
+		}
+		data := make([]byte, size)
+		if _, err := io.ReadFull(r, data); err != nil {
+			return nil, err
+		}
+		r.ReadByte() // trailing newline
+
+		if fields[1] == "blob" {
+			result[spec] = data
+		}
+	}
+
+	return result, nil
+}
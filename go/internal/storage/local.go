@@ -3,6 +3,7 @@ package storage
 import (
 	"crypto/sha256"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -23,7 +24,7 @@ func NewLocalStorage(gitDir string) (*LocalStorage, error) {
 	baseDir := filepath.Join(gitDir, "context")
 	
 	// Create directories
-	for _, subdir := range []string{"memory", "tasks", "locks"} {
+	for _, subdir := range []string{"memory", "tasks", "locks", "templates"} {
 		dir := filepath.Join(baseDir, subdir)
 		if err := os.MkdirAll(dir, 0755); err != nil {
 			return nil, fmt.Errorf("failed to create %s: %w", dir, err)
@@ -33,34 +34,92 @@ func NewLocalStorage(gitDir string) (*LocalStorage, error) {
 	return &LocalStorage{baseDir: baseDir}, nil
 }
 
-// Memory operations
+// memoryMeta is the on-disk form of a memory's meta.json.
+// The content is stored separately in content.md.
+type memoryMeta struct {
+	ID        string       `json:"id"`
+	Title     string       `json:"title"`
+	Kind      string       `json:"kind,omitempty"`
+	Author    string       `json:"author"`
+	Tags      []string     `json:"tags,omitempty"`
+	Links     []model.Link `json:"links,omitempty"`
+	CreatedAt string       `json:"createdAt"`
+	UpdatedAt string       `json:"updatedAt"`
+	Shared    bool         `json:"shared"`
 
-func (s *LocalStorage) WriteMemory(m *model.Memory) error {
-	dir := filepath.Join(s.baseDir, "memory", m.ID)
-	if err := os.MkdirAll(dir, 0755); err != nil {
-		return err
-	}
-	
-	// Write metadata
-	meta := struct {
-		ID        string    `json:"id"`
-		Title     string    `json:"title"`
-		Author    string    `json:"author"`
-		Tags      []string  `json:"tags,omitempty"`
-		CreatedAt string    `json:"createdAt"`
-		UpdatedAt string    `json:"updatedAt"`
-		Shared    bool      `json:"shared"`
-	}{
+	ArchivedAt   string        `json:"archivedAt,omitempty"`
+	SupersededBy string        `json:"supersededBy,omitempty"`
+	History      []model.Event `json:"history,omitempty"`
+}
+
+// encodeMemoryMeta renders a memory's meta.json.
+func encodeMemoryMeta(m *model.Memory) ([]byte, error) {
+	meta := memoryMeta{
 		ID:        m.ID,
 		Title:     m.Title,
+		Kind:      string(m.Kind),
 		Author:    m.Author,
 		Tags:      m.Tags,
+		Links:     m.Links,
 		CreatedAt: m.CreatedAt.Format("2006-01-02T15:04:05Z"),
 		UpdatedAt: m.UpdatedAt.Format("2006-01-02T15:04:05Z"),
 		Shared:    m.Shared,
+		
+		SupersededBy: m.SupersededBy,
+		History:      m.History,
+	}
+	if m.ArchivedAt != nil {
+		meta.ArchivedAt = m.ArchivedAt.Format("2006-01-02T15:04:05Z")
+	}
+	
+	return json.MarshalIndent(meta, "", "  ")
+}
+
+// decodeMemory builds a memory from meta.json and content.md.
+func decodeMemory(metaBytes, content []byte) (*model.Memory, error) {
+	var meta memoryMeta
+	if err := json.Unmarshal(metaBytes, &meta); err != nil {
+		return nil, err
+	}
+	
+	// Parse times
+	createdAt, _ := parseTime(meta.CreatedAt)
+	updatedAt, _ := parseTime(meta.UpdatedAt)
+	
+	m := &model.Memory{
+		ID:        meta.ID,
+		Title:     meta.Title,
+		Kind:      model.MemoryKind(meta.Kind),
+		Content:   string(content),
+		Author:    meta.Author,
+		Tags:      meta.Tags,
+		Links:     meta.Links,
+		CreatedAt: createdAt,
+		UpdatedAt: updatedAt,
+		Shared:    meta.Shared,
+		
+		SupersededBy: meta.SupersededBy,
+		History:      meta.History,
+	}
+	if meta.ArchivedAt != "" {
+		if archivedAt, err := parseTime(meta.ArchivedAt); err == nil {
+			m.ArchivedAt = &archivedAt
+		}
+	}
+	
+	return m, nil
+}
+
+// Memory operations
+
+func (s *LocalStorage) WriteMemory(m *model.Memory) error {
+	dir := filepath.Join(s.baseDir, "memory", m.ID)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
 	}
 	
-	metaBytes, err := json.MarshalIndent(meta, "", "  ")
+	// Write metadata
+	metaBytes, err := encodeMemoryMeta(m)
 	if err != nil {
 		return err
 	}
@@ -79,43 +138,16 @@ func (s *LocalStorage) ReadMemory(id string) (*model.Memory, error) {
 	// Read metadata
 	metaBytes, err := os.ReadFile(filepath.Join(dir, "meta.json"))
 	if err != nil {
-		return nil, err
-	}
-	
-	var meta struct {
-		ID        string   `json:"id"`
-		Title     string   `json:"title"`
-		Author    string   `json:"author"`
-		Tags      []string `json:"tags,omitempty"`
-		CreatedAt string   `json:"createdAt"`
-		UpdatedAt string   `json:"updatedAt"`
-		Shared    bool     `json:"shared"`
-	}
-	
-	if err := json.Unmarshal(metaBytes, &meta); err != nil {
-		return nil, err
+		return nil, fileError(err, id)
 	}
 	
 	// Read content
 	content, err := os.ReadFile(filepath.Join(dir, "content.md"))
 	if err != nil {
-		return nil, err
+		return nil, fileError(err, id)
 	}
 	
-	// Parse times
-	createdAt, _ := parseTime(meta.CreatedAt)
-	updatedAt, _ := parseTime(meta.UpdatedAt)
-	
-	return &model.Memory{
-		ID:        meta.ID,
-		Title:     meta.Title,
-		Content:   string(content),
-		Author:    meta.Author,
-		Tags:      meta.Tags,
-		CreatedAt: createdAt,
-		UpdatedAt: updatedAt,
-		Shared:    meta.Shared,
-	}, nil
+	return decodeMemory(metaBytes, content)
 }
 
 func (s *LocalStorage) ListMemories() ([]*model.Memory, error) {
@@ -143,6 +175,9 @@ func (s *LocalStorage) ListMemories() ([]*model.Memory, error) {
 
 func (s *LocalStorage) DeleteMemory(id string) error {
 	dir := filepath.Join(s.baseDir, "memory", id)
+	if _, err := os.Stat(filepath.Join(dir, "meta.json")); err != nil {
+		return fileError(err, id)
+	}
 	return os.RemoveAll(dir)
 }
 
@@ -182,7 +217,7 @@ func (s *LocalStorage) ReadTask(id string) (*model.Task, error) {
 	path := filepath.Join(s.baseDir, "tasks", id+".json")
 	data, err := os.ReadFile(path)
 	if err != nil {
-		return nil, err
+		return nil, fileError(err, id)
 	}
 	
 	var t model.Task
@@ -217,22 +252,29 @@ func (s *LocalStorage) ListTasks() ([]*model.Task, error) {
 	return tasks, nil
 }
 
+// UpdateTask holds <id>.json.lock while it reads the task and applies
+// fn; see replaceFile.
 func (s *LocalStorage) UpdateTask(id string, fn func(*model.Task) error) error {
-	t, err := s.ReadTask(id)
-	if err != nil {
-		return err
-	}
-	
-	if err := fn(t); err != nil {
-		return err
+	path := filepath.Join(s.baseDir, "tasks", id+".json")
+	if _, err := os.Stat(path); err != nil {
+		return fileError(err, id)
 	}
 	
-	return s.WriteTask(t)
+	return replaceFile(path, id, func() ([]byte, error) {
+		t, err := s.ReadTask(id)
+		if err != nil {
+			return nil, err
+		}
+		if err := fn(t); err != nil {
+			return nil, err
+		}
+		return json.MarshalIndent(t, "", "  ")
+	})
 }
 
 func (s *LocalStorage) DeleteTask(id string) error {
 	path := filepath.Join(s.baseDir, "tasks", id+".json")
-	return os.Remove(path)
+	return fileError(os.Remove(path), id)
 }
 
 // Lock operations
@@ -258,7 +300,7 @@ func (s *LocalStorage) ReadLock(target string) (*model.Lock, error) {
 	
 	data, err := os.ReadFile(path)
 	if err != nil {
-		return nil, err
+		return nil, fileError(err, target)
 	}
 	
 	var l model.Lock
@@ -301,7 +343,235 @@ func (s *LocalStorage) ListLocks() ([]*model.Lock, error) {
 func (s *LocalStorage) DeleteLock(target string) error {
 	hash := hashTarget(target)
 	path := filepath.Join(s.baseDir, "locks", hash+".json")
-	return os.Remove(path)
+	return fileError(os.Remove(path), target)
+}
+
+// Lock files guard a lock while UpdateLock changes it.
+const (
+	// lockFileWait is how long UpdateLock waits for another process's
+	// lock file before giving up.
+	lockFileWait = 5 * time.Second
+	// lockFileStale is the age at which a lock file is taken to be left
+	// by a crashed process and is removed.
+	lockFileStale = 30 * time.Second
+)
+
+// UpdateLock holds <hash>.json.lock while it reads the lock and
+// decides; see replaceFile.
+func (s *LocalStorage) UpdateLock(target string, fn func(*model.Lock) (*model.Lock, error)) error {
+	dir := filepath.Join(s.baseDir, "locks")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	path := filepath.Join(dir, hashTarget(target)+".json")
+	
+	return replaceFile(path, target, func() ([]byte, error) {
+		current, err := s.ReadLock(target)
+		if errors.Is(err, ErrNotFound) {
+			current = nil
+		} else if err != nil {
+			return nil, err
+		}
+		
+		next, err := fn(current)
+		if err != nil {
+			return nil, err
+		}
+		if next == nil {
+			if current == nil {
+				return nil, nil
+			}
+			return nil, os.Remove(path)
+		}
+		return json.MarshalIndent(next, "", "  ")
+	})
+}
+
+// replaceFile holds path's lock file, created with O_EXCL as git does
+// for its own lock files, while write reads path and decides. The new
+// content write returns is written to the lock file, which is then
+// renamed over path, so readers never see it half written. If write
+// returns nil, path is left as write left it.
+func replaceFile(path, name string, write func() ([]byte, error)) error {
+	f, err := createLockFile(path + ".lock")
+	if err != nil {
+		return fmt.Errorf("failed to lock %s: %w", name, err)
+	}
+	done := false
+	defer func() {
+		if !done {
+			f.Close()
+			os.Remove(f.Name())
+		}
+	}()
+	
+	data, err := write()
+	if err != nil || data == nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(f.Name(), path); err != nil {
+		return err
+	}
+	done = true
+	return nil
+}
+
+// createLockFile creates path exclusively, waiting while another process
+// holds it.
+func createLockFile(path string) (*os.File, error) {
+	deadline := time.Now().Add(lockFileWait)
+	for {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if !os.IsExist(err) {
+			return f, err
+		}
+		if info, err := os.Stat(path); err == nil && time.Since(info.ModTime()) > lockFileStale {
+			os.Remove(path)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("%w: %s is held by another process", ErrConflict, filepath.Base(path))
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// Lock journal operations. Each target's events are appended, one JSON
+// object per line, to lockevents/<target hash>.jsonl. Appends hold
+// <hash>.jsonl.lock, as UpdateLock does; once the file reaches
+// model.MaxLockEvents it is rewritten without the oldest events.
+
+func (s *LocalStorage) AppendLockEvent(e *model.LockEvent) error {
+	dir := filepath.Join(s.baseDir, "lockevents")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	path := filepath.Join(dir, hashTarget(e.Target)+".jsonl")
+	
+	return replaceFile(path, "the journal of "+e.Target, func() ([]byte, error) {
+		existing, err := os.ReadFile(path)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		events := decodeLockEvents(existing)
+		if len(events) >= model.MaxLockEvents {
+			return encodeLockEvents(append(events, e))
+		}
+		
+		data, err := json.Marshal(e)
+		if err != nil {
+			return nil, err
+		}
+		out, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := out.Write(append(data, '\n')); err != nil {
+			out.Close()
+			return nil, err
+		}
+		return nil, out.Close()
+	})
+}
+
+func (s *LocalStorage) ListLockEvents(target string) ([]*model.LockEvent, error) {
+	dir := filepath.Join(s.baseDir, "lockevents")
+	pattern := filepath.Join(dir, "*.jsonl")
+	if target != "" {
+		pattern = filepath.Join(dir, hashTarget(target)+".jsonl")
+	}
+	files, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, err
+	}
+	
+	var events []*model.LockEvent
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			continue
+		}
+		events = append(events, decodeLockEvents(data)...)
+	}
+	model.SortLockEvents(events)
+	return events, nil
+}
+
+// Template operations
+
+func (s *LocalStorage) WriteTemplate(kind, content string) error {
+	dir := filepath.Join(s.baseDir, "templates")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	
+	return os.WriteFile(filepath.Join(dir, kind+".md"), []byte(content), 0644)
+}
+
+func (s *LocalStorage) ReadTemplate(kind string) (string, error) {
+	data, err := os.ReadFile(filepath.Join(s.baseDir, "templates", kind+".md"))
+	if err != nil {
+		return "", fileError(err, kind)
+	}
+	return string(data), nil
+}
+
+func (s *LocalStorage) ListTemplates() ([]string, error) {
+	dir := filepath.Join(s.baseDir, "templates")
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	
+	var kinds []string
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".md") {
+			kinds = append(kinds, strings.TrimSuffix(entry.Name(), ".md"))
+		}
+	}
+	
+	return kinds, nil
+}
+
+func (s *LocalStorage) DeleteTemplate(kind string) error {
+	return fileError(os.Remove(filepath.Join(s.baseDir, "templates", kind+".md")), kind)
+}
+
+// Workflow operations
+
+func (s *LocalStorage) WriteWorkflow(w *model.Workflow) error {
+	data, err := json.MarshalIndent(w, "", "  ")
+	if err != nil {
+		return err
+	}
+	
+	return os.WriteFile(filepath.Join(s.baseDir, "workflow.json"), data, 0644)
+}
+
+func (s *LocalStorage) ReadWorkflow() (*model.Workflow, error) {
+	data, err := os.ReadFile(filepath.Join(s.baseDir, "workflow.json"))
+	if err != nil {
+		return nil, fileError(err, "workflow")
+	}
+	
+	var w model.Workflow
+	if err := json.Unmarshal(data, &w); err != nil {
+		return nil, fmt.Errorf("failed to parse workflow: %w", err)
+	}
+	return &w, nil
+}
+
+func (s *LocalStorage) DeleteWorkflow() error {
+	return fileError(os.Remove(filepath.Join(s.baseDir, "workflow.json")), "workflow")
 }
 
 // Helpers
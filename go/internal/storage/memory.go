@@ -0,0 +1,352 @@
+package storage
+
+import (
+	"encoding/json"
+	"sort"
+	"sync"
+
+	"github.com/user/git-context/internal/model"
+)
+
+// InMemoryStorage keeps data in process memory. It is safe for
+// concurrent use and is meant for tests and embedding; nothing is
+// persisted.
+//
+// Values are copied on the way in and out, so callers can modify what
+// they read without changing what is stored, as with the other storages.
+type InMemoryStorage struct {
+	mu        sync.RWMutex
+	memories  map[string][]byte
+	tasks     map[string][]byte
+	locks     map[string][]byte
+	templates map[string]string
+	workflow  []byte
+	journal   map[string][]byte // each target's lock events, as a journal file
+}
+
+// NewInMemoryStorage creates an empty in-memory storage.
+func NewInMemoryStorage() *InMemoryStorage {
+	return &InMemoryStorage{
+		memories:  make(map[string][]byte),
+		journal:   make(map[string][]byte),
+		tasks:     make(map[string][]byte),
+		locks:     make(map[string][]byte),
+		templates: make(map[string]string),
+	}
+}
+
+// put stores a copy of v under key.
+func (s *InMemoryStorage) put(entries map[string][]byte, key string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entries[key] = data
+	return nil
+}
+
+// get decodes a copy of the value under key into v.
+func (s *InMemoryStorage) get(entries map[string][]byte, key string, v interface{}) error {
+	s.mu.RLock()
+	data, ok := entries[key]
+	s.mu.RUnlock()
+
+	if !ok {
+		return notFound(key)
+	}
+	return json.Unmarshal(data, v)
+}
+
+// del removes key.
+func (s *InMemoryStorage) del(entries map[string][]byte, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := entries[key]; !ok {
+		return notFound(key)
+	}
+	delete(entries, key)
+	return nil
+}
+
+// keys returns the sorted keys of entries.
+func (s *InMemoryStorage) keys(entries map[string][]byte) []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	keys := make([]string, 0, len(entries))
+	for k := range entries {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Memory operations
+
+func (s *InMemoryStorage) WriteMemory(m *model.Memory) error {
+	return s.put(s.memories, m.ID, m)
+}
+
+func (s *InMemoryStorage) ReadMemory(id string) (*model.Memory, error) {
+	var m model.Memory
+	if err := s.get(s.memories, id, &m); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+func (s *InMemoryStorage) ListMemories() ([]*model.Memory, error) {
+	var memories []*model.Memory
+	for _, id := range s.keys(s.memories) {
+		if m, err := s.ReadMemory(id); err == nil {
+			memories = append(memories, m)
+		}
+	}
+	return memories, nil
+}
+
+func (s *InMemoryStorage) DeleteMemory(id string) error {
+	return s.del(s.memories, id)
+}
+
+func (s *InMemoryStorage) SearchMemories(query string) ([]*model.Memory, error) {
+	memories, err := s.ListMemories()
+	if err != nil {
+		return nil, err
+	}
+
+	var results []*model.Memory
+	for _, m := range memories {
+		if m.MatchesSearch(query) {
+			results = append(results, m)
+		}
+	}
+	return results, nil
+}
+
+// Task operations
+
+func (s *InMemoryStorage) WriteTask(t *model.Task) error {
+	return s.put(s.tasks, t.ID, t)
+}
+
+func (s *InMemoryStorage) ReadTask(id string) (*model.Task, error) {
+	var t model.Task
+	if err := s.get(s.tasks, id, &t); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (s *InMemoryStorage) ListTasks() ([]*model.Task, error) {
+	var tasks []*model.Task
+	for _, id := range s.keys(s.tasks) {
+		if t, err := s.ReadTask(id); err == nil {
+			tasks = append(tasks, t)
+		}
+	}
+	return tasks, nil
+}
+
+func (s *InMemoryStorage) UpdateTask(id string, fn func(*model.Task) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, ok := s.tasks[id]
+	if !ok {
+		return notFound(id)
+	}
+	var t model.Task
+	if err := json.Unmarshal(data, &t); err != nil {
+		return err
+	}
+
+	if err := fn(&t); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(&t)
+	if err != nil {
+		return err
+	}
+	s.tasks[id] = data
+	return nil
+}
+
+func (s *InMemoryStorage) DeleteTask(id string) error {
+	return s.del(s.tasks, id)
+}
+
+// Lock operations
+
+func (s *InMemoryStorage) WriteLock(l *model.Lock) error {
+	return s.put(s.locks, l.Target, l)
+}
+
+func (s *InMemoryStorage) ReadLock(target string) (*model.Lock, error) {
+	var l model.Lock
+	if err := s.get(s.locks, target, &l); err != nil {
+		return nil, err
+	}
+	return &l, nil
+}
+
+func (s *InMemoryStorage) ListLocks() ([]*model.Lock, error) {
+	var locks []*model.Lock
+	for _, target := range s.keys(s.locks) {
+		if l, err := s.ReadLock(target); err == nil {
+			locks = append(locks, l)
+		}
+	}
+	return locks, nil
+}
+
+func (s *InMemoryStorage) DeleteLock(target string) error {
+	return s.del(s.locks, target)
+}
+
+// UpdateLock holds the storage's lock while fn runs, so fn must not
+// call the storage.
+func (s *InMemoryStorage) UpdateLock(target string, fn func(*model.Lock) (*model.Lock, error)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var current *model.Lock
+	if data, ok := s.locks[target]; ok {
+		current = &model.Lock{}
+		if err := json.Unmarshal(data, current); err != nil {
+			return err
+		}
+	}
+
+	next, err := fn(current)
+	if err != nil {
+		return err
+	}
+	if next == nil {
+		delete(s.locks, target)
+		return nil
+	}
+	data, err := json.Marshal(next)
+	if err != nil {
+		return err
+	}
+	s.locks[target] = data
+	return nil
+}
+
+// Lock journal operations
+
+func (s *InMemoryStorage) AppendLockEvent(e *model.LockEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	events := decodeLockEvents(s.journal[e.Target])
+	data, err := encodeLockEvents(append(events, e))
+	if err != nil {
+		return err
+	}
+	s.journal[e.Target] = data
+	return nil
+}
+
+func (s *InMemoryStorage) ListLockEvents(target string) ([]*model.LockEvent, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var events []*model.LockEvent
+	for t, data := range s.journal {
+		if target == "" || t == target {
+			events = append(events, decodeLockEvents(data)...)
+		}
+	}
+	model.SortLockEvents(events)
+	return events, nil
+}
+
+// Template operations
+
+func (s *InMemoryStorage) WriteTemplate(kind, content string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.templates[kind] = content
+	return nil
+}
+
+func (s *InMemoryStorage) ReadTemplate(kind string) (string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	content, ok := s.templates[kind]
+	if !ok {
+		return "", notFound(kind)
+	}
+	return content, nil
+}
+
+func (s *InMemoryStorage) ListTemplates() ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var kinds []string
+	for kind := range s.templates {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	return kinds, nil
+}
+
+func (s *InMemoryStorage) DeleteTemplate(kind string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.templates[kind]; !ok {
+		return notFound(kind)
+	}
+	delete(s.templates, kind)
+	return nil
+}
+
+// Workflow operations
+
+func (s *InMemoryStorage) WriteWorkflow(w *model.Workflow) error {
+	data, err := json.Marshal(w)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.workflow = data
+	return nil
+}
+
+func (s *InMemoryStorage) ReadWorkflow() (*model.Workflow, error) {
+	s.mu.RLock()
+	data := s.workflow
+	s.mu.RUnlock()
+
+	if data == nil {
+		return nil, notFound("workflow")
+	}
+	var w model.Workflow
+	if err := json.Unmarshal(data, &w); err != nil {
+		return nil, err
+	}
+	return &w, nil
+}
+
+func (s *InMemoryStorage) DeleteWorkflow() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.workflow == nil {
+		return notFound("workflow")
+	}
+	s.workflow = nil
+	return nil
+}
@@ -1,97 +1,545 @@
 package storage
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+	"time"
+
 	"github.com/user/git-context/internal/model"
 )
 
+// Ref namespaces for shared data. Each entity is a ref pointing at a
+// commit whose tree holds the entity's files, so every change is kept in
+// history and syncs with plain git push/fetch.
+const (
+	refPrefix         = "refs/context/"
+	memoryRefPrefix   = refPrefix + "memory/"
+	taskRefPrefix     = refPrefix + "tasks/"
+	lockRefPrefix     = refPrefix + "locks/"
+	lockJournalPrefix = refPrefix + "lockjournal/"
+	templateRefPrefix = refPrefix + "templates/"
+	workflowRef       = refPrefix + "workflow/tasks"
+)
+
 // SharedStorage stores data in refs/context/ as git objects.
 // This storage syncs with push/pull.
+//
+// Deleting an entity commits an empty tree (a tombstone) instead of
+// removing the ref, so deletions travel through push/pull and merge like
+// any other change.
 type SharedStorage struct {
-	repoPath string
+	git *gitRepo
 }
 
 // NewSharedStorage creates a new shared storage instance.
 func NewSharedStorage(gitDir string) (*SharedStorage, error) {
-	// For now, shared storage uses the same file-based approach
-	// but stores in a different location. Full git refs implementation
-	// will come in Phase 3.
-	return &SharedStorage{repoPath: gitDir}, nil
+	return &SharedStorage{git: &gitRepo{gitDir: gitDir}}, nil
+}
+
+// write commits files as the new state of ref. Nothing is committed if
+// the content is unchanged.
+func (s *SharedStorage) write(ref string, files map[string][]byte, message string) error {
+	return s.writeOver(ref, s.git.resolveRef(ref), files, message)
+}
+
+// writeOver commits files as the new state of ref on top of parent, the
+// commit the change was based on ("" for a new ref). If ref has moved
+// since, it fails with ErrConflict.
+func (s *SharedStorage) writeOver(ref, parent string, files map[string][]byte, message string) error {
+	tree, err := s.git.writeTree(files)
+	if err != nil {
+		return err
+	}
+
+	var parents []string
+	if parent != "" {
+		if parentTree, err := s.git.treeOf(parent); err == nil && parentTree == tree {
+			return nil
+		}
+		parents = append(parents, parent)
+	}
+
+	commit, err := s.git.commitTree(tree, message, parents...)
+	if err != nil {
+		return err
+	}
+
+	return s.git.updateRef(ref, commit, parent, "git-ctx: "+message)
+}
+
+// read returns the named files of the entity at ref. A missing ref or a
+// tombstone is ErrNotFound for id.
+func (s *SharedStorage) read(ref, id string, names ...string) (map[string][]byte, error) {
+	commit := s.git.resolveRef(ref)
+	if commit == "" {
+		return nil, notFound(id)
+	}
+
+	specs := make([]string, len(names))
+	for i, name := range names {
+		specs[i] = commit + ":" + name
+	}
+	blobs, err := s.git.readFiles(specs)
+	if err != nil {
+		return nil, err
+	}
+
+	files := make(map[string][]byte)
+	for i, name := range names {
+		if data, ok := blobs[specs[i]]; ok {
+			files[name] = data
+		}
+	}
+	if len(files) == 0 {
+		return nil, notFound(id)
+	}
+	return files, nil
+}
+
+// readAll returns the named files of every live entity under prefix,
+// keyed by the entity's name within the prefix.
+func (s *SharedStorage) readAll(prefix string, names ...string) (map[string]map[string][]byte, []string, error) {
+	refs, err := s.git.listRefs(prefix)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var specs []string
+	for _, commit := range refs {
+		for _, name := range names {
+			specs = append(specs, commit+":"+name)
+		}
+	}
+	blobs, err := s.git.readFiles(specs)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	result := make(map[string]map[string][]byte)
+	var keys []string
+	for ref, commit := range refs {
+		files := make(map[string][]byte)
+		for _, name := range names {
+			if data, ok := blobs[commit+":"+name]; ok {
+				files[name] = data
+			}
+		}
+		if len(files) == 0 {
+			continue // tombstone
+		}
+		key := strings.TrimPrefix(ref, prefix)
+		result[key] = files
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return result, keys, nil
+}
+
+// exists returns true if ref holds a live (non-tombstone) entity.
+func (s *SharedStorage) exists(ref string) bool {
+	commit := s.git.resolveRef(ref)
+	if commit == "" {
+		return false
+	}
+	entries, err := s.git.run(nil, "ls-tree", commit)
+	return err == nil && entries != ""
+}
+
+// remove commits a tombstone for ref.
+func (s *SharedStorage) remove(ref, id, message string) error {
+	if !s.exists(ref) {
+		return notFound(id)
+	}
+	return s.write(ref, nil, message)
 }
 
-// Memory operations - delegate to local for now, will use git refs later
+// Memory operations
 
 func (s *SharedStorage) WriteMemory(m *model.Memory) error {
-	// TODO: Implement git refs storage
-	return nil
+	meta, err := encodeMemoryMeta(m)
+	if err != nil {
+		return err
+	}
+	files := map[string][]byte{
+		"meta.json":  meta,
+		"content.md": []byte(m.Content),
+	}
+	return s.write(memoryRefPrefix+m.ID, files, "memory "+m.ID+": "+m.Title)
 }
 
 func (s *SharedStorage) ReadMemory(id string) (*model.Memory, error) {
-	// TODO: Implement git refs storage
-	return nil, nil
+	files, err := s.read(memoryRefPrefix+id, id, "meta.json", "content.md")
+	if err != nil {
+		return nil, err
+	}
+	return decodeMemory(files["meta.json"], files["content.md"])
 }
 
 func (s *SharedStorage) ListMemories() ([]*model.Memory, error) {
-	// TODO: Implement git refs storage
-	return nil, nil
+	all, keys, err := s.readAll(memoryRefPrefix, "meta.json", "content.md")
+	if err != nil {
+		return nil, err
+	}
+
+	var memories []*model.Memory
+	for _, id := range keys {
+		m, err := decodeMemory(all[id]["meta.json"], all[id]["content.md"])
+		if err == nil {
+			memories = append(memories, m)
+		}
+	}
+
+	return memories, nil
 }
 
 func (s *SharedStorage) DeleteMemory(id string) error {
-	// TODO: Implement git refs storage
-	return nil
+	return s.remove(memoryRefPrefix+id, id, "delete memory "+id)
 }
 
 func (s *SharedStorage) SearchMemories(query string) ([]*model.Memory, error) {
-	// TODO: Implement git refs storage
-	return nil, nil
+	memories, err := s.ListMemories()
+	if err != nil {
+		return nil, err
+	}
+
+	var results []*model.Memory
+	for _, m := range memories {
+		if m.MatchesSearch(query) {
+			results = append(results, m)
+		}
+	}
+
+	return results, nil
 }
 
 // Task operations
 
 func (s *SharedStorage) WriteTask(t *model.Task) error {
-	// TODO: Implement git refs storage
-	return nil
+	data, err := json.MarshalIndent(t, "", "  ")
+	if err != nil {
+		return err
+	}
+	return s.write(taskRefPrefix+t.ID, map[string][]byte{"task.json": data}, "task "+t.ID+": "+t.Title)
 }
 
 func (s *SharedStorage) ReadTask(id string) (*model.Task, error) {
-	// TODO: Implement git refs storage
-	return nil, nil
+	files, err := s.read(taskRefPrefix+id, id, "task.json")
+	if err != nil {
+		return nil, err
+	}
+
+	var t model.Task
+	if err := json.Unmarshal(files["task.json"], &t); err != nil {
+		return nil, err
+	}
+	return &t, nil
 }
 
 func (s *SharedStorage) ListTasks() ([]*model.Task, error) {
-	// TODO: Implement git refs storage
-	return nil, nil
+	all, keys, err := s.readAll(taskRefPrefix, "task.json")
+	if err != nil {
+		return nil, err
+	}
+
+	var tasks []*model.Task
+	for _, id := range keys {
+		var t model.Task
+		if err := json.Unmarshal(all[id]["task.json"], &t); err == nil {
+			tasks = append(tasks, &t)
+		}
+	}
+
+	return tasks, nil
 }
 
+// UpdateTask commits the change with an old-value check on the task's
+// ref, as UpdateLock does, so two agents claiming a task at once cannot
+// both succeed.
 func (s *SharedStorage) UpdateTask(id string, fn func(*model.Task) error) error {
-	// TODO: Implement git refs storage
-	return nil
+	return s.update(taskRefPrefix+id, func(parent string) (map[string][]byte, string, error) {
+		data, err := s.readAt(parent, "task.json")
+		if err != nil {
+			return nil, "", err
+		}
+		if data == nil {
+			return nil, "", notFound(id)
+		}
+		var t model.Task
+		if err := json.Unmarshal(data, &t); err != nil {
+			return nil, "", err
+		}
+
+		if err := fn(&t); err != nil {
+			return nil, "", err
+		}
+
+		data, err = json.MarshalIndent(&t, "", "  ")
+		if err != nil {
+			return nil, "", err
+		}
+		return map[string][]byte{"task.json": data}, "task " + t.ID + ": " + t.Title, nil
+	})
 }
 
 func (s *SharedStorage) DeleteTask(id string) error {
-	// TODO: Implement git refs storage
-	return nil
+	return s.remove(taskRefPrefix+id, id, "delete task "+id)
 }
 
 // Lock operations
 
 func (s *SharedStorage) WriteLock(l *model.Lock) error {
-	// TODO: Implement git refs storage
-	return nil
+	data, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return err
+	}
+	return s.write(lockRefPrefix+hashTarget(l.Target), map[string][]byte{"lock.json": data}, "lock "+l.Target)
 }
 
 func (s *SharedStorage) ReadLock(target string) (*model.Lock, error) {
-	// TODO: Implement git refs storage
-	return nil, nil
+	files, err := s.read(lockRefPrefix+hashTarget(target), target, "lock.json")
+	if err != nil {
+		return nil, err
+	}
+
+	var l model.Lock
+	if err := json.Unmarshal(files["lock.json"], &l); err != nil {
+		return nil, err
+	}
+	return &l, nil
 }
 
 func (s *SharedStorage) ListLocks() ([]*model.Lock, error) {
-	// TODO: Implement git refs storage
-	return nil, nil
+	all, keys, err := s.readAll(lockRefPrefix, "lock.json")
+	if err != nil {
+		return nil, err
+	}
+
+	var locks []*model.Lock
+	for _, key := range keys {
+		var l model.Lock
+		if err := json.Unmarshal(all[key]["lock.json"], &l); err == nil {
+			locks = append(locks, &l)
+		}
+	}
+
+	return locks, nil
 }
 
 func (s *SharedStorage) DeleteLock(target string) error {
-	// TODO: Implement git refs storage
-	return nil
+	return s.remove(lockRefPrefix+hashTarget(target), target, "unlock "+target)
+}
+
+// maxLockAttempts bounds how often update starts over when other
+// writers keep changing the ref.
+const maxLockAttempts = 50
+
+// errUnchanged tells update that there is nothing to write.
+var errUnchanged = errors.New("unchanged")
+
+// update commits what change makes of the ref's current commit ("" if
+// there is none), with an old-value check on the ref. If another writer
+// moves the ref meanwhile, change runs again on the new commit.
+func (s *SharedStorage) update(ref string, change func(parent string) (map[string][]byte, string, error)) error {
+	for attempt := 0; attempt < maxLockAttempts; attempt++ {
+		parent := s.git.resolveRef(ref)
+		files, message, err := change(parent)
+		if errors.Is(err, errUnchanged) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		err = s.writeOver(ref, parent, files, message)
+		if !errors.Is(err, ErrConflict) {
+			return err
+		}
+		time.Sleep(time.Duration(rand.Intn(10)+1) * time.Millisecond)
+	}
+	return fmt.Errorf("%w: %s kept changing", ErrConflict, ref)
+}
+
+// readAt returns the named file in commit, or nil if commit is "" or
+// has no such file.
+func (s *SharedStorage) readAt(commit, name string) ([]byte, error) {
+	if commit == "" {
+		return nil, nil
+	}
+	spec := commit + ":" + name
+	blobs, err := s.git.readFiles([]string{spec})
+	if err != nil {
+		return nil, err
+	}
+	return blobs[spec], nil
+}
+
+// UpdateLock commits the change with an old-value check on the lock's
+// ref, so a concurrent writer makes it start over instead of being
+// overwritten.
+func (s *SharedStorage) UpdateLock(target string, fn func(*model.Lock) (*model.Lock, error)) error {
+	return s.update(lockRefPrefix+hashTarget(target), func(parent string) (map[string][]byte, string, error) {
+		data, err := s.readAt(parent, "lock.json")
+		if err != nil {
+			return nil, "", err
+		}
+		var current *model.Lock
+		if data != nil {
+			current = &model.Lock{}
+			if err := json.Unmarshal(data, current); err != nil {
+				return nil, "", err
+			}
+		}
+
+		next, err := fn(current)
+		if err != nil {
+			return nil, "", err
+		}
+		if next == nil && current == nil {
+			return nil, "", errUnchanged
+		}
+		if next == nil {
+			return nil, "unlock " + target, nil // a tombstone
+		}
+		data, err = json.MarshalIndent(next, "", "  ")
+		if err != nil {
+			return nil, "", err
+		}
+		return map[string][]byte{"lock.json": data}, "lock " + target, nil
+	})
+}
+
+// Lock journal operations. Each target's journal is one ref under
+// lockjournal/<target hash>, holding its events one JSON object per
+// line, oldest first. Appending commits on top of the ref, and pulling
+// merges diverged journals by taking the union of their events.
+
+// lockJournalFile is the file holding a target's journal.
+const lockJournalFile = "events.jsonl"
+
+func (s *SharedStorage) AppendLockEvent(e *model.LockEvent) error {
+	return s.update(lockJournalPrefix+hashTarget(e.Target), func(parent string) (map[string][]byte, string, error) {
+		data, err := s.readAt(parent, lockJournalFile)
+		if err != nil {
+			return nil, "", err
+		}
+		events := decodeLockEvents(data)
+		for _, old := range events {
+			if old.ID == e.ID {
+				return nil, "", fmt.Errorf("%w: lock event %s exists", ErrConflict, e.ID)
+			}
+		}
+
+		data, err = encodeLockEvents(append(events, e))
+		if err != nil {
+			return nil, "", err
+		}
+		return map[string][]byte{lockJournalFile: data}, string(e.Type) + " " + e.Target, nil
+	})
+}
+
+func (s *SharedStorage) ListLockEvents(target string) ([]*model.LockEvent, error) {
+	var events []*model.LockEvent
+	if target != "" {
+		data, err := s.readAt(s.git.resolveRef(lockJournalPrefix+hashTarget(target)), lockJournalFile)
+		if err != nil {
+			return nil, err
+		}
+		events = decodeLockEvents(data)
+	} else {
+		all, keys, err := s.readAll(lockJournalPrefix, lockJournalFile)
+		if err != nil {
+			return nil, err
+		}
+		for _, key := range keys {
+			events = append(events, decodeLockEvents(all[key][lockJournalFile])...)
+		}
+	}
+	model.SortLockEvents(events)
+	return events, nil
+}
+
+// encodeLockEvents writes one target's events as a journal file, oldest
+// first, dropping any beyond model.MaxLockEvents.
+func encodeLockEvents(events []*model.LockEvent) ([]byte, error) {
+	var buf strings.Builder
+	for _, e := range model.TrimLockEvents(events) {
+		data, err := json.Marshal(e)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(data)
+		buf.WriteByte('\n')
+	}
+	return []byte(buf.String()), nil
 }
 
+// decodeLockEvents reads a journal file, skipping lines that do not
+// parse.
+func decodeLockEvents(data []byte) []*model.LockEvent {
+	var events []*model.LockEvent
+	for _, line := range strings.Split(string(data), "\n") {
+		var e model.LockEvent
+		if line != "" && json.Unmarshal([]byte(line), &e) == nil {
+			events = append(events, &e)
+		}
+	}
+	return events
+}
+
+// Template operations
+
+func (s *SharedStorage) WriteTemplate(kind, content string) error {
+	return s.write(templateRefPrefix+kind, map[string][]byte{"template.md": []byte(content)}, "template "+kind)
+}
+
+func (s *SharedStorage) ReadTemplate(kind string) (string, error) {
+	files, err := s.read(templateRefPrefix+kind, kind, "template.md")
+	if err != nil {
+		return "", err
+	}
+	return string(files["template.md"]), nil
+}
+
+func (s *SharedStorage) ListTemplates() ([]string, error) {
+	_, keys, err := s.readAll(templateRefPrefix, "template.md")
+	return keys, err
+}
+
+func (s *SharedStorage) DeleteTemplate(kind string) error {
+	return s.remove(templateRefPrefix+kind, kind, "reset template "+kind)
+}
+
+// Workflow operations
 
+func (s *SharedStorage) WriteWorkflow(w *model.Workflow) error {
+	data, err := json.MarshalIndent(w, "", "  ")
+	if err != nil {
+		return err
+	}
+	return s.write(workflowRef, map[string][]byte{"workflow.json": data}, "workflow")
+}
+
+func (s *SharedStorage) ReadWorkflow() (*model.Workflow, error) {
+	files, err := s.read(workflowRef, "workflow", "workflow.json")
+	if err != nil {
+		return nil, err
+	}
+	return decodeWorkflow(files["workflow.json"])
+}
+
+func (s *SharedStorage) DeleteWorkflow() error {
+	return s.remove(workflowRef, "workflow", "reset workflow")
+}
+
+func decodeWorkflow(data []byte) (*model.Workflow, error) {
+	var w model.Workflow
+	if err := json.Unmarshal(data, &w); err != nil {
+		return nil, fmt.Errorf("failed to parse workflow: %w", err)
+	}
+	return &w, nil
+}
@@ -0,0 +1,155 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Namespaces are the kinds of shared entities, in display order.
+var Namespaces = []string{"memory", "tasks", "locks", "lockjournal", "templates", "workflow"}
+
+// EntryChange is one shared entry that differs between the local refs
+// and the remote-tracking refs.
+type EntryChange struct {
+	Name    string `json:"name"`              // ref name within the namespace
+	Title   string `json:"title"`             // memory or task title, lock target, template kind or workflow
+	New     bool   `json:"new,omitempty"`     // the other side does not have it at all
+	Deleted bool   `json:"deleted,omitempty"` // the change is a deletion
+}
+
+// NamespaceStatus compares one namespace with its remote-tracking refs.
+type NamespaceStatus struct {
+	Namespace string        `json:"namespace"`
+	Ahead     int           `json:"ahead"`  // commits not yet pushed
+	Behind    int           `json:"behind"` // commits fetched but not yet merged
+	Outgoing  []EntryChange `json:"outgoing"`
+	Incoming  []EntryChange `json:"incoming"`
+}
+
+// SyncStatus compares the local shared context with a remote's, as of
+// the last fetch.
+func (s *SharedStorage) SyncStatus(remote string) ([]NamespaceStatus, error) {
+	var result []NamespaceStatus
+
+	for _, ns := range Namespaces {
+		localPrefix := refPrefix + ns + "/"
+		trackingPrefix := TrackingPrefix(remote) + ns + "/"
+
+		local, err := s.git.listRefs(localPrefix)
+		if err != nil {
+			return nil, err
+		}
+		tracking, err := s.git.listRefs(trackingPrefix)
+		if err != nil {
+			return nil, err
+		}
+
+		status := NamespaceStatus{Namespace: ns}
+		status.Ahead = s.countCommits(local, tracking)
+		status.Behind = s.countCommits(tracking, local)
+
+		theirs := make(map[string]string)
+		for ref, commit := range tracking {
+			theirs[strings.TrimPrefix(ref, trackingPrefix)] = commit
+		}
+		ours := make(map[string]string)
+		for ref, commit := range local {
+			ours[strings.TrimPrefix(ref, localPrefix)] = commit
+		}
+
+		for name, commit := range ours {
+			their, ok := theirs[name]
+			if ok && s.git.isAncestor(commit, their) {
+				continue
+			}
+			status.Outgoing = append(status.Outgoing, s.describeChange(ns, name, commit, !ok))
+		}
+		for name, commit := range theirs {
+			our, ok := ours[name]
+			if ok && s.git.isAncestor(commit, our) {
+				continue
+			}
+			status.Incoming = append(status.Incoming, s.describeChange(ns, name, commit, !ok))
+		}
+
+		sortChanges(status.Outgoing)
+		sortChanges(status.Incoming)
+		result = append(result, status)
+	}
+
+	return result, nil
+}
+
+// countCommits counts commits reachable from the refs in from but not
+// from any ref in not.
+func (s *SharedStorage) countCommits(from, not map[string]string) int {
+	if len(from) == 0 {
+		return 0
+	}
+
+	args := []string{"rev-list", "--count"}
+	for _, commit := range from {
+		args = append(args, commit)
+	}
+	if len(not) > 0 {
+		args = append(args, "--not")
+		for _, commit := range not {
+			args = append(args, commit)
+		}
+	}
+
+	out, err := s.git.run(nil, args...)
+	if err != nil {
+		return 0
+	}
+	n, _ := strconv.Atoi(out)
+	return n
+}
+
+// describeChange reads enough of the entry at commit to show it.
+func (s *SharedStorage) describeChange(ns, name, commit string, isNew bool) EntryChange {
+	change := EntryChange{Name: name, Title: name, New: isNew}
+
+	file := map[string]string{
+		"memory":      "meta.json",
+		"tasks":       "task.json",
+		"locks":       "lock.json",
+		"lockjournal": lockJournalFile,
+		"templates":   "template.md",
+		"workflow":    "workflow.json",
+	}[ns]
+
+	blobs, err := s.git.readFiles([]string{commit + ":" + file})
+	if err != nil {
+		return change
+	}
+	data, ok := blobs[commit+":"+file]
+	if !ok {
+		change.Deleted = true
+		return change
+	}
+
+	var fields struct {
+		Title  string `json:"title"`
+		Target string `json:"target"`
+	}
+	// Only the first value: a lock journal holds one event per line, all
+	// for the same target
+	if json.NewDecoder(bytes.NewReader(data)).Decode(&fields) == nil {
+		if fields.Title != "" {
+			change.Title = fields.Title
+		} else if fields.Target != "" {
+			change.Title = fields.Target
+		}
+	}
+	return change
+}
+
+func sortChanges(changes []EntryChange) {
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Name < changes[j].Name
+	})
+}
@@ -16,6 +16,10 @@ type Storage interface {
 	WriteTask(t *model.Task) error
 	ReadTask(id string) (*model.Task, error)
 	ListTasks() ([]*model.Task, error)
+	// UpdateTask changes a task atomically: fn gets the current task and
+	// changes it in place. An error from fn aborts without writing. As
+	// with UpdateLock, fn may run again if another writer changes the
+	// task meanwhile.
 	UpdateTask(id string, fn func(*model.Task) error) error
 	DeleteTask(id string) error
 
@@ -24,6 +28,28 @@ type Storage interface {
 	ReadLock(target string) (*model.Lock, error)
 	ListLocks() ([]*model.Lock, error)
 	DeleteLock(target string) error
+	// UpdateLock changes the lock on target atomically: fn gets the
+	// current lock, nil if there is none, and returns the new one, or nil
+	// to remove it. An error from fn aborts without writing. If another
+	// writer changes the lock meanwhile, fn runs again on the new state,
+	// so it must only decide, not act.
+	UpdateLock(target string, fn func(*model.Lock) (*model.Lock, error)) error
+
+	// Lock journal (append-only, keeping the newest model.MaxLockEvents
+	// per target; target "" lists every target's events, oldest first)
+	AppendLockEvent(e *model.LockEvent) error
+	ListLockEvents(target string) ([]*model.LockEvent, error)
+
+	// Template operations (editor templates keyed by memory kind)
+	WriteTemplate(kind, content string) error
+	ReadTemplate(kind string) (string, error)
+	ListTemplates() ([]string, error)
+	DeleteTemplate(kind string) error
+
+	// Workflow operations (the task workflow; there is at most one)
+	WriteWorkflow(w *model.Workflow) error
+	ReadWorkflow() (*model.Workflow, error)
+	DeleteWorkflow() error
 }
 
 // MultiStorage combines local and shared storage.
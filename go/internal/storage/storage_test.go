@@ -0,0 +1,50 @@
+package storage_test
+
+import (
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/user/git-context/internal/storage"
+	"github.com/user/git-context/internal/storage/storagetest"
+)
+
+func TestLocalStorage(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) storage.Storage {
+		s, err := storage.NewLocalStorage(newGitDir(t))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return s
+	})
+}
+
+func TestSharedStorage(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) storage.Storage {
+		s, err := storage.NewSharedStorage(newGitDir(t))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return s
+	})
+}
+
+func TestInMemoryStorage(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) storage.Storage {
+		return storage.NewInMemoryStorage()
+	})
+}
+
+// newGitDir creates an empty repository and returns its git directory.
+func newGitDir(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+
+	dir := t.TempDir()
+	if out, err := exec.Command("git", "init", "--quiet", dir).CombinedOutput(); err != nil {
+		t.Fatalf("git init: %v: %s", err, out)
+	}
+	return filepath.Join(dir, ".git")
+}
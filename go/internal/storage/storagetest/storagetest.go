@@ -0,0 +1,739 @@
+// Package storagetest holds the contract every storage.Storage must meet.
+// Backends run the same suite, so local, shared and in-memory storage
+// behave the same way to the commands built on them:
+//
+//	func TestMyStorage(t *testing.T) {
+//		storagetest.Run(t, func(t *testing.T) storage.Storage {
+//			return newMyStorage(t)
+//		})
+//	}
+package storagetest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/user/git-context/internal/model"
+	"github.com/user/git-context/internal/storage"
+)
+
+// Factory returns a new, empty storage for one test.
+type Factory func(t *testing.T) storage.Storage
+
+// Run runs the conformance suite against storages made by newStorage.
+func Run(t *testing.T, newStorage Factory) {
+	tests := []struct {
+		name string
+		fn   func(t *testing.T, s storage.Storage)
+	}{
+		{"EmptyLists", testEmptyLists},
+		{"MemoryRoundTrip", testMemoryRoundTrip},
+		{"MemoryOverwrite", testMemoryOverwrite},
+		{"MemoryList", testMemoryList},
+		{"MemoryDelete", testMemoryDelete},
+		{"MemoryNotFound", testMemoryNotFound},
+		{"MemorySearch", testMemorySearch},
+		{"MemoryCopy", testMemoryCopy},
+		{"TaskRoundTrip", testTaskRoundTrip},
+		{"TaskList", testTaskList},
+		{"TaskUpdate", testTaskUpdate},
+		{"TaskUpdateError", testTaskUpdateError},
+		{"TaskUpdateConcurrent", testTaskUpdateConcurrent},
+		{"TaskDelete", testTaskDelete},
+		{"TaskNotFound", testTaskNotFound},
+		{"LockRoundTrip", testLockRoundTrip},
+		{"LockReplace", testLockReplace},
+		{"LockList", testLockList},
+		{"LockDelete", testLockDelete},
+		{"LockNotFound", testLockNotFound},
+		{"LockUpdate", testLockUpdate},
+		{"LockUpdateError", testLockUpdateError},
+		{"LockUpdateConcurrent", testLockUpdateConcurrent},
+		{"LockJournal", testLockJournal},
+		{"LockJournalCap", testLockJournalCap},
+		{"Templates", testTemplates},
+		{"Workflow", testWorkflow},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			tt.fn(t, newStorage(t))
+		})
+	}
+}
+
+// base is a fixed time with second precision, the finest every backend
+// keeps.
+var base = time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+func at(minutes int) time.Time {
+	return base.Add(time.Duration(minutes) * time.Minute)
+}
+
+func newMemory(id, title, content string) *model.Memory {
+	archived := at(30)
+	return &model.Memory{
+		ID:           id,
+		Title:        title,
+		Kind:         model.KindDecision,
+		Content:      content,
+		Author:       "alice",
+		Tags:         []string{"auth", "api"},
+		Links:        []model.Link{{Type: model.LinkImplements, Target: "task-0001"}},
+		CreatedAt:    at(0),
+		UpdatedAt:    at(10),
+		ArchivedAt:   &archived,
+		SupersededBy: "bbbb0000",
+		History:      []model.Event{{Type: model.EventEdited, Author: "bob", At: at(10)}},
+	}
+}
+
+func newTask(id, title string) *model.Task {
+	done := at(20)
+	return &model.Task{
+		ID:          id,
+		Title:       title,
+		Description: "Details",
+		Status:      model.TaskDone,
+		Owner:       "alice",
+		CreatedBy:   "bob",
+		CreatedAt:   at(0),
+		UpdatedAt:   at(20),
+		DoneAt:      &done,
+		BlockedBy:   []string{"task-0002"},
+		Blocks:      []string{"task-0003"},
+		Comments:    []model.Comment{{Author: "bob", Content: "Looks good", CreatedAt: at(15)}},
+		Links:       []model.Link{{Type: model.LinkCommit, Target: "0123456789abcdef0123456789abcdef01234567"}},
+		History: []model.Event{
+			{Type: model.EventClaimed, Author: "alice", At: at(5)},
+			{Type: model.EventDone, Author: "alice", At: at(20)},
+		},
+	}
+}
+
+func newLock(target, by string) *model.Lock {
+	return &model.Lock{
+		Target:    target,
+		LockedBy:  by,
+		LockedAt:  at(0),
+		ExpiresAt: at(120),
+	}
+}
+
+// assertSame fails unless want and got encode to the same JSON, which
+// is how every backend persists them.
+func assertSame(t *testing.T, want, got interface{}) {
+	t.Helper()
+	w, err := json.MarshalIndent(want, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	g, err := json.MarshalIndent(got, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(w) != string(g) {
+		t.Errorf("mismatch\nwant: %s\ngot:  %s", w, g)
+	}
+}
+
+func assertNotFound(t *testing.T, what string, err error) {
+	t.Helper()
+	if !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("%s: got error %v, want ErrNotFound", what, err)
+	}
+}
+
+func must(t *testing.T, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func memoryIDs(memories []*model.Memory) []string {
+	ids := []string{}
+	for _, m := range memories {
+		ids = append(ids, m.ID)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func taskIDs(tasks []*model.Task) []string {
+	ids := []string{}
+	for _, t := range tasks {
+		ids = append(ids, t.ID)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func lockTargets(locks []*model.Lock) []string {
+	targets := []string{}
+	for _, l := range locks {
+		targets = append(targets, l.Target)
+	}
+	sort.Strings(targets)
+	return targets
+}
+
+func testEmptyLists(t *testing.T, s storage.Storage) {
+	memories, err := s.ListMemories()
+	must(t, err)
+	tasks, err := s.ListTasks()
+	must(t, err)
+	locks, err := s.ListLocks()
+	must(t, err)
+	templates, err := s.ListTemplates()
+	must(t, err)
+
+	if len(memories)+len(tasks)+len(locks)+len(templates) != 0 {
+		t.Errorf("new storage is not empty: %d memories, %d tasks, %d locks, %d templates",
+			len(memories), len(tasks), len(locks), len(templates))
+	}
+}
+
+func testMemoryRoundTrip(t *testing.T, s storage.Storage) {
+	m := newMemory("aaaa0001", "Use JWT", "Stateless auth.\n\nSecond paragraph.")
+	must(t, s.WriteMemory(m))
+
+	got, err := s.ReadMemory(m.ID)
+	must(t, err)
+	assertSame(t, m, got)
+}
+
+func testMemoryOverwrite(t *testing.T, s storage.Storage) {
+	m := newMemory("aaaa0001", "Use JWT", "v1")
+	must(t, s.WriteMemory(m))
+
+	m.Title = "Use PASETO"
+	m.Content = "v2"
+	m.Tags = nil
+	m.ArchivedAt = nil
+	must(t, s.WriteMemory(m))
+
+	got, err := s.ReadMemory(m.ID)
+	must(t, err)
+	assertSame(t, m, got)
+
+	all, err := s.ListMemories()
+	must(t, err)
+	assertSame(t, []string{m.ID}, memoryIDs(all))
+}
+
+func testMemoryList(t *testing.T, s storage.Storage) {
+	for _, id := range []string{"aaaa0002", "aaaa0001", "aaaa0003"} {
+		must(t, s.WriteMemory(newMemory(id, "Entry "+id, "content")))
+	}
+
+	all, err := s.ListMemories()
+	must(t, err)
+	assertSame(t, []string{"aaaa0001", "aaaa0002", "aaaa0003"}, memoryIDs(all))
+
+	for _, m := range all {
+		assertSame(t, newMemory(m.ID, "Entry "+m.ID, "content"), m)
+	}
+}
+
+func testMemoryDelete(t *testing.T, s storage.Storage) {
+	must(t, s.WriteMemory(newMemory("aaaa0001", "Keep", "")))
+	must(t, s.WriteMemory(newMemory("aaaa0002", "Remove", "")))
+
+	must(t, s.DeleteMemory("aaaa0002"))
+
+	_, err := s.ReadMemory("aaaa0002")
+	assertNotFound(t, "read after delete", err)
+	assertNotFound(t, "second delete", s.DeleteMemory("aaaa0002"))
+
+	all, err := s.ListMemories()
+	must(t, err)
+	assertSame(t, []string{"aaaa0001"}, memoryIDs(all))
+
+	// The ID can be used again
+	must(t, s.WriteMemory(newMemory("aaaa0002", "Back", "")))
+	got, err := s.ReadMemory("aaaa0002")
+	must(t, err)
+	if got.Title != "Back" {
+		t.Errorf("title after rewrite = %q, want Back", got.Title)
+	}
+}
+
+func testMemoryNotFound(t *testing.T, s storage.Storage) {
+	m, err := s.ReadMemory("ffff0000")
+	assertNotFound(t, "read", err)
+	if m != nil {
+		t.Errorf("read returned %+v with the error", m)
+	}
+	assertNotFound(t, "delete", s.DeleteMemory("ffff0000"))
+}
+
+func testMemorySearch(t *testing.T, s storage.Storage) {
+	must(t, s.WriteMemory(newMemory("aaaa0001", "Use JWT", "Tokens are signed")))
+	must(t, s.WriteMemory(newMemory("aaaa0002", "Database", "Postgres with JWT claims")))
+	must(t, s.WriteMemory(newMemory("aaaa0003", "Logging", "Structured logs")))
+
+	found, err := s.SearchMemories("jwt")
+	must(t, err)
+	assertSame(t, []string{"aaaa0001", "aaaa0002"}, memoryIDs(found))
+
+	found, err = s.SearchMemories("nothing matches this")
+	must(t, err)
+	if len(found) != 0 {
+		t.Errorf("search found %v, want nothing", memoryIDs(found))
+	}
+}
+
+func testMemoryCopy(t *testing.T, s storage.Storage) {
+	m := newMemory("aaaa0001", "Original", "content")
+	must(t, s.WriteMemory(m))
+
+	// Changing values after writing or reading must not change storage
+	m.Title = "Changed after write"
+	got, err := s.ReadMemory("aaaa0001")
+	must(t, err)
+	got.Title = "Changed after read"
+	got.Tags[0] = "changed"
+
+	again, err := s.ReadMemory("aaaa0001")
+	must(t, err)
+	assertSame(t, newMemory("aaaa0001", "Original", "content"), again)
+}
+
+func testTaskRoundTrip(t *testing.T, s storage.Storage) {
+	task := newTask("task-0001", "Implement auth")
+	must(t, s.WriteTask(task))
+
+	got, err := s.ReadTask(task.ID)
+	must(t, err)
+	assertSame(t, task, got)
+}
+
+func testTaskList(t *testing.T, s storage.Storage) {
+	for _, id := range []string{"task-0002", "task-0001"} {
+		must(t, s.WriteTask(newTask(id, "Task "+id)))
+	}
+
+	all, err := s.ListTasks()
+	must(t, err)
+	assertSame(t, []string{"task-0001", "task-0002"}, taskIDs(all))
+
+	for _, task := range all {
+		assertSame(t, newTask(task.ID, "Task "+task.ID), task)
+	}
+}
+
+func testTaskUpdate(t *testing.T, s storage.Storage) {
+	task := newTask("task-0001", "Implement auth")
+	task.Status, task.Owner, task.DoneAt = model.TaskOpen, "", nil
+	must(t, s.WriteTask(task))
+
+	must(t, s.UpdateTask(task.ID, func(t *model.Task) error {
+		t.Status = model.TaskClaimed
+		t.Owner = "carol"
+		t.Comments = append(t.Comments, model.Comment{Author: "carol", Content: "Mine", CreatedAt: at(40)})
+		return nil
+	}))
+
+	got, err := s.ReadTask(task.ID)
+	must(t, err)
+	task.Status = model.TaskClaimed
+	task.Owner = "carol"
+	task.Comments = append(task.Comments, model.Comment{Author: "carol", Content: "Mine", CreatedAt: at(40)})
+	assertSame(t, task, got)
+
+	err = s.UpdateTask("task-ffff", func(*model.Task) error {
+		t.Error("update function called for a missing task")
+		return nil
+	})
+	assertNotFound(t, "update missing", err)
+}
+
+func testTaskUpdateError(t *testing.T, s storage.Storage) {
+	task := newTask("task-0001", "Implement auth")
+	must(t, s.WriteTask(task))
+
+	errStop := errors.New("stop")
+	err := s.UpdateTask(task.ID, func(t *model.Task) error {
+		t.Title = "Should not be saved"
+		return errStop
+	})
+	if !errors.Is(err, errStop) {
+		t.Errorf("update returned %v, want the function's error", err)
+	}
+
+	got, err := s.ReadTask(task.ID)
+	must(t, err)
+	assertSame(t, task, got)
+}
+
+func testTaskUpdateConcurrent(t *testing.T, s storage.Storage) {
+	const writers = 8
+	task := newTask("task-0001", "Implement auth")
+	task.Comments = nil
+	must(t, s.WriteTask(task))
+
+	// Every writer's comment survives: none overwrites another's
+	var wg sync.WaitGroup
+	errs := make(chan error, writers)
+	for i := 0; i < writers; i++ {
+		agent := fmt.Sprintf("agent-%d", i)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- s.UpdateTask(task.ID, func(t *model.Task) error {
+				t.Comments = append(t.Comments, model.Comment{Author: agent, Content: "mine", CreatedAt: at(1)})
+				return nil
+			})
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		must(t, err)
+	}
+
+	got, err := s.ReadTask(task.ID)
+	must(t, err)
+	var authors []string
+	for _, c := range got.Comments {
+		authors = append(authors, c.Author)
+	}
+	sort.Strings(authors)
+	want := make([]string, writers)
+	for i := range want {
+		want[i] = fmt.Sprintf("agent-%d", i)
+	}
+	assertSame(t, want, authors)
+}
+
+func testTaskDelete(t *testing.T, s storage.Storage) {
+	must(t, s.WriteTask(newTask("task-0001", "Keep")))
+	must(t, s.WriteTask(newTask("task-0002", "Remove")))
+
+	must(t, s.DeleteTask("task-0002"))
+
+	_, err := s.ReadTask("task-0002")
+	assertNotFound(t, "read after delete", err)
+	assertNotFound(t, "second delete", s.DeleteTask("task-0002"))
+
+	all, err := s.ListTasks()
+	must(t, err)
+	assertSame(t, []string{"task-0001"}, taskIDs(all))
+}
+
+func testTaskNotFound(t *testing.T, s storage.Storage) {
+	task, err := s.ReadTask("task-ffff")
+	assertNotFound(t, "read", err)
+	if task != nil {
+		t.Errorf("read returned %+v with the error", task)
+	}
+	assertNotFound(t, "delete", s.DeleteTask("task-ffff"))
+}
+
+func testLockRoundTrip(t *testing.T, s storage.Storage) {
+	for _, target := range []string{"task-0001", "src/auth/login.go", "path with spaces/ünïcode.txt"} {
+		l := newLock(target, "alice")
+		must(t, s.WriteLock(l))
+
+		got, err := s.ReadLock(target)
+		must(t, err)
+		assertSame(t, l, got)
+	}
+}
+
+func testLockReplace(t *testing.T, s storage.Storage) {
+	must(t, s.WriteLock(newLock("src/main.go", "alice")))
+
+	// Writing a lock on the same target replaces it, holders and queue
+	// and all
+	l := newLock("src/main.go", "bob")
+	l.LockedAt, l.ExpiresAt = at(200), at(320)
+	l.Mode, l.Holders = model.LockShared, []string{"bob", "erin"}
+	l.Queue = []model.Waiter{
+		{Agent: "carol", Mode: model.LockExclusive, QueuedAt: at(210), SeenAt: at(230)},
+		{Agent: "dave", Mode: model.LockShared, QueuedAt: at(220), SeenAt: at(230)},
+	}
+	must(t, s.WriteLock(l))
+
+	got, err := s.ReadLock("src/main.go")
+	must(t, err)
+	assertSame(t, l, got)
+
+	all, err := s.ListLocks()
+	must(t, err)
+	assertSame(t, []string{"src/main.go"}, lockTargets(all))
+}
+
+func testLockList(t *testing.T, s storage.Storage) {
+	expired := newLock("old.go", "alice")
+	expired.ExpiresAt = base.Add(-time.Hour)
+
+	must(t, s.WriteLock(newLock("a.go", "alice")))
+	must(t, s.WriteLock(newLock("dir/b.go", "bob")))
+	must(t, s.WriteLock(expired))
+
+	// Expiry is for callers to judge; storage lists every lock
+	all, err := s.ListLocks()
+	must(t, err)
+	assertSame(t, []string{"a.go", "dir/b.go", "old.go"}, lockTargets(all))
+}
+
+func testLockDelete(t *testing.T, s storage.Storage) {
+	must(t, s.WriteLock(newLock("a.go", "alice")))
+	must(t, s.WriteLock(newLock("b.go", "alice")))
+
+	must(t, s.DeleteLock("a.go"))
+
+	_, err := s.ReadLock("a.go")
+	assertNotFound(t, "read after delete", err)
+	assertNotFound(t, "second delete", s.DeleteLock("a.go"))
+
+	all, err := s.ListLocks()
+	must(t, err)
+	assertSame(t, []string{"b.go"}, lockTargets(all))
+
+	// The target can be locked again
+	must(t, s.WriteLock(newLock("a.go", "bob")))
+	got, err := s.ReadLock("a.go")
+	must(t, err)
+	if got.LockedBy != "bob" {
+		t.Errorf("lock after relock held by %q, want bob", got.LockedBy)
+	}
+}
+
+func testLockNotFound(t *testing.T, s storage.Storage) {
+	l, err := s.ReadLock("nothing/here.go")
+	assertNotFound(t, "read", err)
+	if l != nil {
+		t.Errorf("read returned %+v with the error", l)
+	}
+	assertNotFound(t, "delete", s.DeleteLock("nothing/here.go"))
+}
+
+func testLockUpdate(t *testing.T, s storage.Storage) {
+	// Creating: fn sees no lock
+	must(t, s.UpdateLock("a.go", func(l *model.Lock) (*model.Lock, error) {
+		if l != nil {
+			t.Errorf("update of a new target got %+v, want nil", l)
+		}
+		return newLock("a.go", "alice"), nil
+	}))
+	got, err := s.ReadLock("a.go")
+	must(t, err)
+	assertSame(t, newLock("a.go", "alice"), got)
+
+	// Changing: fn sees the stored lock
+	must(t, s.UpdateLock("a.go", func(l *model.Lock) (*model.Lock, error) {
+		assertSame(t, newLock("a.go", "alice"), l)
+		l.Queue = []model.Waiter{{Agent: "bob", Mode: model.LockExclusive, QueuedAt: at(10), SeenAt: at(10)}}
+		return l, nil
+	}))
+	got, err = s.ReadLock("a.go")
+	must(t, err)
+	if len(got.Queue) != 1 || got.Queue[0].Agent != "bob" {
+		t.Errorf("queue after update = %+v, want bob", got.Queue)
+	}
+
+	// Removing: fn returns nil
+	must(t, s.UpdateLock("a.go", func(l *model.Lock) (*model.Lock, error) {
+		return nil, nil
+	}))
+	_, err = s.ReadLock("a.go")
+	assertNotFound(t, "read after removing update", err)
+	all, err := s.ListLocks()
+	must(t, err)
+	assertSame(t, []string{}, lockTargets(all))
+
+	// Removing nothing is not an error
+	must(t, s.UpdateLock("a.go", func(l *model.Lock) (*model.Lock, error) {
+		return nil, nil
+	}))
+}
+
+func testLockUpdateError(t *testing.T, s storage.Storage) {
+	must(t, s.WriteLock(newLock("a.go", "alice")))
+
+	errStop := errors.New("stop")
+	err := s.UpdateLock("a.go", func(l *model.Lock) (*model.Lock, error) {
+		l.LockedBy = "mallory"
+		return l, errStop
+	})
+	if !errors.Is(err, errStop) {
+		t.Errorf("update returned %v, want the function's error", err)
+	}
+
+	got, err := s.ReadLock("a.go")
+	must(t, err)
+	assertSame(t, newLock("a.go", "alice"), got)
+}
+
+// testLockUpdateConcurrent has writers race to join a lock's queue. Each
+// must see the others' changes, so none is lost.
+func testLockUpdateConcurrent(t *testing.T, s storage.Storage) {
+	const writers = 8
+	must(t, s.WriteLock(newLock("a.go", "alice")))
+
+	var wg sync.WaitGroup
+	errs := make(chan error, writers)
+	for i := 0; i < writers; i++ {
+		agent := fmt.Sprintf("agent-%d", i)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- s.UpdateLock("a.go", func(l *model.Lock) (*model.Lock, error) {
+				l.Queue = append(l.Queue, model.Waiter{Agent: agent, Mode: model.LockExclusive, QueuedAt: at(1), SeenAt: at(1)})
+				return l, nil
+			})
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		must(t, err)
+	}
+
+	got, err := s.ReadLock("a.go")
+	must(t, err)
+	var agents []string
+	for _, w := range got.Queue {
+		agents = append(agents, w.Agent)
+	}
+	sort.Strings(agents)
+	want := make([]string, writers)
+	for i := range want {
+		want[i] = fmt.Sprintf("agent-%d", i)
+	}
+	assertSame(t, want, agents)
+}
+
+func newLockEvent(id, target string, typ model.EventType, by string, minutes int) *model.LockEvent {
+	return &model.LockEvent{ID: id, Target: target, Type: typ, Author: by, At: at(minutes)}
+}
+
+func eventIDs(events []*model.LockEvent) []string {
+	var ids []string
+	for _, e := range events {
+		ids = append(ids, e.ID)
+	}
+	return ids
+}
+
+func testLockJournal(t *testing.T, s storage.Storage) {
+	events, err := s.ListLockEvents("")
+	must(t, err)
+	if len(events) != 0 {
+		t.Fatalf("new storage has %d lock events", len(events))
+	}
+
+	broken := newLockEvent("lev-0003", "src/auth/", model.EventBroken, "bob", 30)
+	broken.Mode, broken.Holder, broken.Reason = model.LockShared, "alice", "agent crashed"
+
+	// Appended out of order; listed oldest first
+	must(t, s.AppendLockEvent(newLockEvent("lev-0002", "src/auth/", model.EventRenewed, "alice", 20)))
+	must(t, s.AppendLockEvent(newLockEvent("lev-0001", "src/auth/", model.EventAcquired, "alice", 10)))
+	must(t, s.AppendLockEvent(newLockEvent("lev-0004", "go.mod", model.EventAcquired, "carol", 15)))
+	must(t, s.AppendLockEvent(broken))
+
+	events, err = s.ListLockEvents("src/auth/")
+	must(t, err)
+	assertSame(t, []string{"lev-0001", "lev-0002", "lev-0003"}, eventIDs(events))
+	assertSame(t, broken, events[2])
+
+	all, err := s.ListLockEvents("")
+	must(t, err)
+	assertSame(t, []string{"lev-0001", "lev-0004", "lev-0002", "lev-0003"}, eventIDs(all))
+
+	// The journal outlives the lock
+	must(t, s.WriteLock(newLock("go.mod", "carol")))
+	must(t, s.DeleteLock("go.mod"))
+	events, err = s.ListLockEvents("go.mod")
+	must(t, err)
+	assertSame(t, []string{"lev-0004"}, eventIDs(events))
+
+	events, err = s.ListLockEvents("nothing/here.go")
+	must(t, err)
+	if len(events) != 0 {
+		t.Errorf("unlocked target has %d events", len(events))
+	}
+}
+
+func testLockJournalCap(t *testing.T, s storage.Storage) {
+	old := model.MaxLockEvents
+	model.MaxLockEvents = 3
+	defer func() { model.MaxLockEvents = old }()
+
+	for i := 1; i <= 5; i++ {
+		must(t, s.AppendLockEvent(newLockEvent(fmt.Sprintf("lev-%04d", i), "src/auth/", model.EventRenewed, "alice", i)))
+	}
+	must(t, s.AppendLockEvent(newLockEvent("lev-0100", "go.mod", model.EventAcquired, "carol", 0)))
+
+	// Only the newest are kept, and only the full target loses any
+	events, err := s.ListLockEvents("src/auth/")
+	must(t, err)
+	assertSame(t, []string{"lev-0003", "lev-0004", "lev-0005"}, eventIDs(events))
+	events, err = s.ListLockEvents("go.mod")
+	must(t, err)
+	assertSame(t, []string{"lev-0100"}, eventIDs(events))
+}
+
+func testTemplates(t *testing.T, s storage.Storage) {
+	_, err := s.ReadTemplate("decision")
+	assertNotFound(t, "read missing", err)
+	assertNotFound(t, "delete missing", s.DeleteTemplate("decision"))
+
+	must(t, s.WriteTemplate("decision", "## Context\n\n## Decision\n"))
+	must(t, s.WriteTemplate("plan", "## Steps\n"))
+	must(t, s.WriteTemplate("plan", "## Goal\n\n## Steps\n"))
+
+	got, err := s.ReadTemplate("plan")
+	must(t, err)
+	if got != "## Goal\n\n## Steps\n" {
+		t.Errorf("plan template = %q", got)
+	}
+
+	kinds, err := s.ListTemplates()
+	must(t, err)
+	sort.Strings(kinds)
+	assertSame(t, []string{"decision", "plan"}, kinds)
+
+	must(t, s.DeleteTemplate("decision"))
+	_, err = s.ReadTemplate("decision")
+	assertNotFound(t, "read after delete", err)
+
+	kinds, err = s.ListTemplates()
+	must(t, err)
+	assertSame(t, []string{"plan"}, kinds)
+}
+
+func testWorkflow(t *testing.T, s storage.Storage) {
+	_, err := s.ReadWorkflow()
+	assertNotFound(t, "read missing", err)
+	assertNotFound(t, "delete missing", s.DeleteWorkflow())
+
+	w := model.DefaultWorkflow()
+	w.Statuses[1].Next = append(w.Statuses[1].Next, "review")
+	w.Statuses = append(w.Statuses, model.WorkflowStatus{Name: "review", Next: []model.TaskStatus{model.TaskDone}})
+	must(t, s.WriteWorkflow(model.DefaultWorkflow()))
+	must(t, s.WriteWorkflow(w))
+
+	got, err := s.ReadWorkflow()
+	must(t, err)
+	assertSame(t, w, got)
+
+	// Reads are copies
+	got.Statuses[0].Name = "changed"
+	again, err := s.ReadWorkflow()
+	must(t, err)
+	assertSame(t, w, again)
+
+	must(t, s.DeleteWorkflow())
+	_, err = s.ReadWorkflow()
+	assertNotFound(t, "read after delete", err)
+}
@@ -0,0 +1,453 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/user/git-context/internal/model"
+)
+
+// DefaultRemote is the remote used by push and pull when none is given.
+const DefaultRemote = "origin"
+
+// TrackingPrefix returns where the remote's context refs are fetched to.
+func TrackingPrefix(remote string) string {
+	return "refs/remotes/" + remote + "/context/"
+}
+
+// MergeResult summarizes what a merge changed in the local refs.
+type MergeResult struct {
+	Added   []string // refs that did not exist locally
+	Updated []string // fast-forwarded
+	Merged  []string // diverged and resolved
+	Kept    []string // local was already ahead
+
+	// Invalid refs were left alone because their content is unusable,
+	// such as a workflow that fails validation.
+	Invalid map[string]error
+}
+
+// Changed returns the number of local refs that moved.
+func (r *MergeResult) Changed() int {
+	return len(r.Added) + len(r.Updated) + len(r.Merged)
+}
+
+// PushResult summarizes a push.
+type PushResult struct {
+	Pushed   []string
+	Rejected []string // remote has changes we don't: pull first
+}
+
+// Fetch downloads the remote's context refs into its tracking namespace
+// without touching local refs.
+func (s *SharedStorage) Fetch(remote string) error {
+	refspec := "+" + refPrefix + "*:" + TrackingPrefix(remote) + "*"
+	_, err := s.git.run(nil, "fetch", "--quiet", "--no-tags", remote, refspec)
+	return err
+}
+
+// Pull fetches from remote and merges its context into the local refs.
+func (s *SharedStorage) Pull(remote string) (*MergeResult, error) {
+	if err := s.Fetch(remote); err != nil {
+		return nil, err
+	}
+
+	tracking, err := s.git.listRefs(TrackingPrefix(remote))
+	if err != nil {
+		return nil, err
+	}
+
+	theirs := make(map[string]string)
+	for ref, commit := range tracking {
+		theirs[refPrefix+strings.TrimPrefix(ref, TrackingPrefix(remote))] = commit
+	}
+
+	return s.Merge(theirs, "pull "+remote)
+}
+
+// Push sends local context refs to remote. Refs where the remote has
+// changes we have not merged are rejected rather than overwritten.
+func (s *SharedStorage) Push(remote string) (*PushResult, error) {
+	local, err := s.git.listRefs(refPrefix)
+	if err != nil {
+		return nil, err
+	}
+	result := &PushResult{}
+	if len(local) == 0 {
+		return result, nil
+	}
+
+	out, pushErr := s.git.run(nil, "push", "--porcelain", remote, refPrefix+"*:"+refPrefix+"*")
+
+	// Porcelain lines: <flag>\t<from>:<to>\t<summary>
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Split(line, "\t")
+		if len(fields) < 2 || len(fields[0]) != 1 {
+			continue
+		}
+		refs := strings.SplitN(fields[1], ":", 2)
+		if len(refs) != 2 {
+			continue
+		}
+		ref := refs[1]
+
+		switch fields[0] {
+		case "!":
+			result.Rejected = append(result.Rejected, ref)
+		case "=":
+			// Up to date
+		default:
+			result.Pushed = append(result.Pushed, ref)
+		}
+
+		// Keep tracking refs in step with what the remote now has
+		if fields[0] != "!" {
+			if commit, ok := local[ref]; ok {
+				tracking := TrackingPrefix(remote) + strings.TrimPrefix(ref, refPrefix)
+				s.git.run(nil, "update-ref", tracking, commit)
+			}
+		}
+	}
+
+	if pushErr != nil && len(result.Rejected) == 0 {
+		return nil, pushErr
+	}
+	return result, nil
+}
+
+// Merge brings the given refs (refname -> commit) into the local refs.
+// The commits must already be in the object database.
+//
+// A ref that only exists on their side is added, and one side that
+// contains the other is fast-forwarded. When both sides changed, the
+// side with the newer commit wins and a merge commit records both
+// histories; for tasks, comments and links from both sides are kept,
+// and for locks, the holder who acquired first wins.
+func (s *SharedStorage) Merge(theirs map[string]string, reason string) (*MergeResult, error) {
+	result := &MergeResult{}
+
+	refs := make([]string, 0, len(theirs))
+	for ref := range theirs {
+		refs = append(refs, ref)
+	}
+	sort.Strings(refs)
+
+	for _, ref := range refs {
+		their := theirs[ref]
+		ours := s.git.resolveRef(ref)
+
+		if ours == their {
+			continue
+		}
+		if ref == workflowRef {
+			if err := s.checkWorkflow(their); err != nil {
+				if result.Invalid == nil {
+					result.Invalid = make(map[string]error)
+				}
+				result.Invalid[ref] = err
+				continue
+			}
+		}
+
+		switch {
+		case ours == "":
+			if err := s.git.updateRef(ref, their, "", "git-ctx: "+reason); err != nil {
+				return result, err
+			}
+			result.Added = append(result.Added, ref)
+		case s.git.isAncestor(their, ours):
+			result.Kept = append(result.Kept, ref)
+		case s.git.isAncestor(ours, their):
+			if err := s.git.updateRef(ref, their, ours, "git-ctx: "+reason); err != nil {
+				return result, err
+			}
+			result.Updated = append(result.Updated, ref)
+		default:
+			if err := s.mergeDiverged(ref, ours, their, reason); err != nil {
+				return result, fmt.Errorf("failed to merge %s: %w", ref, err)
+			}
+			result.Merged = append(result.Merged, ref)
+		}
+	}
+
+	return result, nil
+}
+
+// checkWorkflow validates the workflow at commit. A tombstone is valid:
+// it restores the default workflow.
+func (s *SharedStorage) checkWorkflow(commit string) error {
+	blobs, err := s.git.readFiles([]string{commit + ":workflow.json"})
+	if err != nil {
+		return err
+	}
+	data, ok := blobs[commit+":workflow.json"]
+	if !ok {
+		return nil
+	}
+	w, err := decodeWorkflow(data)
+	if err != nil {
+		return err
+	}
+	return w.Validate()
+}
+
+// mergeDiverged resolves a ref changed on both sides.
+func (s *SharedStorage) mergeDiverged(ref, ours, their, reason string) error {
+	winner, loser := ours, their
+	ourTime, theirTime := s.git.commitTime(ours), s.git.commitTime(their)
+	if theirTime > ourTime || (theirTime == ourTime && their > ours) {
+		winner, loser = their, ours
+	}
+
+	tree, err := s.git.treeOf(winner)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case strings.HasPrefix(ref, taskRefPrefix):
+		tree, err = s.mergeTaskTrees(winner, loser, tree)
+	case strings.HasPrefix(ref, lockRefPrefix):
+		tree, err = s.mergeLockTrees(winner, loser, tree)
+	case strings.HasPrefix(ref, lockJournalPrefix):
+		tree, err = s.mergeJournalTrees(winner, loser)
+	}
+	if err != nil {
+		return err
+	}
+
+	commit, err := s.git.commitTree(tree, "merge "+strings.TrimPrefix(ref, refPrefix)+" ("+reason+")", ours, their)
+	if err != nil {
+		return err
+	}
+	return s.git.updateRef(ref, commit, ours, "git-ctx: "+reason)
+}
+
+// mergeTaskTrees keeps the winning task but carries over comments,
+// links and history events that only exist on the losing side. If
+// either side is a tombstone, the winner's tree is used as is.
+func (s *SharedStorage) mergeTaskTrees(winner, loser, winnerTree string) (string, error) {
+	blobs, err := s.git.readFiles([]string{winner + ":task.json", loser + ":task.json"})
+	if err != nil {
+		return "", err
+	}
+	winData, ok1 := blobs[winner+":task.json"]
+	loseData, ok2 := blobs[loser+":task.json"]
+	if !ok1 || !ok2 {
+		return winnerTree, nil
+	}
+
+	var win, lose model.Task
+	if err := json.Unmarshal(winData, &win); err != nil {
+		return winnerTree, nil
+	}
+	if err := json.Unmarshal(loseData, &lose); err != nil {
+		return winnerTree, nil
+	}
+
+	for _, c := range lose.Comments {
+		if !hasComment(win.Comments, c) {
+			win.Comments = append(win.Comments, c)
+		}
+	}
+	sort.SliceStable(win.Comments, func(i, j int) bool {
+		return win.Comments[i].CreatedAt.Before(win.Comments[j].CreatedAt)
+	})
+	for _, l := range lose.Links {
+		win.Links, _ = model.AddLink(win.Links, l)
+	}
+	for _, e := range lose.History {
+		if !hasEvent(win.History, e) {
+			win.History = append(win.History, e)
+		}
+	}
+	sort.SliceStable(win.History, func(i, j int) bool {
+		return win.History[i].At.Before(win.History[j].At)
+	})
+
+	data, err := json.MarshalIndent(&win, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return s.git.writeTree(map[string][]byte{"task.json": data})
+}
+
+// mergeLockTrees keeps the lock whose live holder acquired it first,
+// not the newest change, so a lock taken in a stale clone cannot replace
+// one already pushed. Waiters from both sides are kept in the order they
+// queued. If neither side is live, the winner's tree is used as is.
+func (s *SharedStorage) mergeLockTrees(winner, loser, winnerTree string) (string, error) {
+	blobs, err := s.git.readFiles([]string{winner + ":lock.json", loser + ":lock.json"})
+	if err != nil {
+		return "", err
+	}
+	win, lose := decodeLock(blobs[winner+":lock.json"]), decodeLock(blobs[loser+":lock.json"])
+	live := func(l *model.Lock) bool { return l != nil && !l.IsExpired() }
+
+	keep, other := win, lose
+	switch {
+	case live(win) && live(lose):
+		if lose.LockedAt.Before(win.LockedAt) {
+			keep, other = lose, win
+		}
+	case live(lose):
+		keep, other = lose, win
+	case !live(win):
+		return winnerTree, nil
+	}
+	if other != nil {
+		keep.Absorb(other)
+	}
+
+	data, err := json.MarshalIndent(keep, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return s.git.writeTree(map[string][]byte{"lock.json": data})
+}
+
+// decodeLock reads a lock.json, or returns nil for a tombstone or one
+// that does not parse.
+func decodeLock(data []byte) *model.Lock {
+	if data == nil {
+		return nil
+	}
+	var l model.Lock
+	if json.Unmarshal(data, &l) != nil {
+		return nil
+	}
+	return &l
+}
+
+// mergeJournalTrees takes the union of both sides' lock events, by ID,
+// so events appended in either clone are kept.
+func (s *SharedStorage) mergeJournalTrees(winner, loser string) (string, error) {
+	blobs, err := s.git.readFiles([]string{winner + ":" + lockJournalFile, loser + ":" + lockJournalFile})
+	if err != nil {
+		return "", err
+	}
+	events := decodeLockEvents(blobs[winner+":"+lockJournalFile])
+	seen := make(map[string]bool)
+	for _, e := range events {
+		seen[e.ID] = true
+	}
+	for _, e := range decodeLockEvents(blobs[loser+":"+lockJournalFile]) {
+		if !seen[e.ID] {
+			events = append(events, e)
+		}
+	}
+
+	data, err := encodeLockEvents(events)
+	if err != nil {
+		return "", err
+	}
+	return s.git.writeTree(map[string][]byte{lockJournalFile: data})
+}
+
+func hasComment(comments []model.Comment, c model.Comment) bool {
+	for _, existing := range comments {
+		if existing.Author == c.Author && existing.Content == c.Content && existing.CreatedAt.Equal(c.CreatedAt) {
+			return true
+		}
+	}
+	return false
+}
+
+// CreateBundle writes the context refs to a git bundle file. If since
+// names an earlier bundle, commits it already contains are left out so
+// only newer changes are packaged. Returns the number of refs included;
+// zero means nothing changed and no file was written.
+func (s *SharedStorage) CreateBundle(file, since string) (int, error) {
+	local, err := s.git.listRefs(refPrefix)
+	if err != nil {
+		return 0, err
+	}
+
+	var exclude []string
+	if since != "" {
+		heads, err := s.BundleHeads(since)
+		if err != nil {
+			return 0, err
+		}
+		for _, commit := range heads {
+			// Commits we never had cannot be excluded
+			if s.git.resolveRef(commit) != "" {
+				exclude = append(exclude, "^"+commit)
+			}
+		}
+	}
+
+	// Only refs that moved since the old bundle are worth sending
+	var refs []string
+	for ref, commit := range local {
+		changed := true
+		for _, ex := range exclude {
+			if s.git.isAncestor(commit, ex[1:]) {
+				changed = false
+				break
+			}
+		}
+		if changed {
+			refs = append(refs, ref)
+		}
+	}
+	if len(refs) == 0 {
+		return 0, nil
+	}
+	sort.Strings(refs)
+
+	args := append([]string{"bundle", "create", "--quiet", file}, refs...)
+	if _, err := s.git.run(nil, append(args, exclude...)...); err != nil {
+		return 0, err
+	}
+	return len(refs), nil
+}
+
+// BundleHeads returns refname -> commit for the context refs in a bundle.
+func (s *SharedStorage) BundleHeads(file string) (map[string]string, error) {
+	out, err := s.git.run(nil, "bundle", "list-heads", file)
+	if err != nil {
+		return nil, err
+	}
+
+	heads := make(map[string]string)
+	for _, line := range strings.Split(out, "\n") {
+		parts := strings.SplitN(line, " ", 2)
+		if len(parts) == 2 && strings.HasPrefix(parts[1], refPrefix) {
+			heads[parts[1]] = parts[0]
+		}
+	}
+	return heads, nil
+}
+
+// ApplyBundle verifies a bundle, unpacks its objects and merges its
+// context refs the same way Pull does.
+func (s *SharedStorage) ApplyBundle(file string) (*MergeResult, error) {
+	if _, err := s.git.run(nil, "bundle", "verify", file); err != nil {
+		if strings.Contains(err.Error(), "prerequisite") {
+			return nil, fmt.Errorf("bundle was created with --since; apply the earlier bundle first: %w", err)
+		}
+		return nil, fmt.Errorf("invalid bundle: %w", err)
+	}
+	if _, err := s.git.run(nil, "bundle", "unbundle", file); err != nil {
+		return nil, err
+	}
+
+	heads, err := s.BundleHeads(file)
+	if err != nil {
+		return nil, err
+	}
+	return s.Merge(heads, "bundle "+filepath.Base(file))
+}
+
+func hasEvent(events []model.Event, e model.Event) bool {
+	for _, existing := range events {
+		if existing.Type == e.Type && existing.Author == e.Author && existing.At.Equal(e.At) {
+			return true
+		}
+	}
+	return false
+}
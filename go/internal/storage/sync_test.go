@@ -0,0 +1,281 @@
+package storage_test
+
+import (
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/user/git-context/internal/model"
+	"github.com/user/git-context/internal/storage"
+)
+
+// newClones creates a bare remote and two repositories that have it as
+// origin, and returns shared storage for each clone.
+func newClones(t *testing.T) (*storage.SharedStorage, *storage.SharedStorage) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+
+	dir := t.TempDir()
+	remote := filepath.Join(dir, "remote.git")
+	gitCmd(t, "init", "--quiet", "--bare", remote)
+
+	clone := func(name string) *storage.SharedStorage {
+		work := filepath.Join(dir, name)
+		gitCmd(t, "init", "--quiet", work)
+		gitDir := filepath.Join(work, ".git")
+		gitCmd(t, "--git-dir", gitDir, "remote", "add", storage.DefaultRemote, remote)
+
+		s, err := storage.NewSharedStorage(gitDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return s
+	}
+	return clone("a"), clone("b")
+}
+
+func gitCmd(t *testing.T, args ...string) {
+	t.Helper()
+	if out, err := exec.Command("git", args...).CombinedOutput(); err != nil {
+		t.Fatalf("git %v: %v: %s", args, err, out)
+	}
+}
+
+func push(t *testing.T, s *storage.SharedStorage) *storage.PushResult {
+	t.Helper()
+	res, err := s.Push(storage.DefaultRemote)
+	if err != nil {
+		t.Fatalf("Push: %v", err)
+	}
+	return res
+}
+
+func pull(t *testing.T, s *storage.SharedStorage) *storage.MergeResult {
+	t.Helper()
+	res, err := s.Pull(storage.DefaultRemote)
+	if err != nil {
+		t.Fatalf("Pull: %v", err)
+	}
+	return res
+}
+
+func comment(t *testing.T, s *storage.SharedStorage, id, content string) {
+	t.Helper()
+	err := s.UpdateTask(id, func(task *model.Task) error {
+		task.AddComment("tester", content)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("UpdateTask: %v", err)
+	}
+}
+
+func TestPushPull(t *testing.T) {
+	a, b := newClones(t)
+
+	task := model.NewTask("sync me", "", "alice", true)
+	if err := a.WriteTask(task); err != nil {
+		t.Fatal(err)
+	}
+
+	if res := push(t, a); len(res.Pushed) != 1 || len(res.Rejected) != 0 {
+		t.Fatalf("push = %+v, want one pushed ref", res)
+	}
+	if res := pull(t, b); len(res.Added) != 1 {
+		t.Fatalf("pull = %+v, want one added ref", res)
+	}
+
+	got, err := b.ReadTask(task.ID)
+	if err != nil {
+		t.Fatalf("ReadTask after pull: %v", err)
+	}
+	if got.Title != task.Title {
+		t.Errorf("Title = %q, want %q", got.Title, task.Title)
+	}
+
+	// A change on one side fast-forwards the other
+	comment(t, a, task.ID, "from a")
+	push(t, a)
+	if res := pull(t, b); len(res.Updated) != 1 {
+		t.Fatalf("pull = %+v, want one fast-forwarded ref", res)
+	}
+	if res := pull(t, b); res.Changed() != 0 {
+		t.Errorf("second pull changed %d refs, want 0", res.Changed())
+	}
+}
+
+func TestPushRejectsUnpulledChanges(t *testing.T) {
+	a, b := newClones(t)
+
+	task := model.NewTask("contended", "", "alice", true)
+	if err := a.WriteTask(task); err != nil {
+		t.Fatal(err)
+	}
+	push(t, a)
+	pull(t, b)
+
+	comment(t, a, task.ID, "from a")
+	comment(t, b, task.ID, "from b")
+	push(t, a)
+
+	res := push(t, b)
+	if len(res.Rejected) != 1 || len(res.Pushed) != 0 {
+		t.Fatalf("push = %+v, want one rejected ref", res)
+	}
+
+	// The remote still has a's change
+	pull(t, a)
+	got, err := a.ReadTask(task.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Comments) != 1 || got.Comments[0].Content != "from a" {
+		t.Errorf("Comments = %+v, want only a's comment", got.Comments)
+	}
+}
+
+func TestPullMergesDivergedTasks(t *testing.T) {
+	a, b := newClones(t)
+
+	task := model.NewTask("contended", "", "alice", true)
+	if err := a.WriteTask(task); err != nil {
+		t.Fatal(err)
+	}
+	push(t, a)
+	pull(t, b)
+
+	comment(t, a, task.ID, "from a")
+	comment(t, b, task.ID, "from b")
+	push(t, a)
+
+	if res := pull(t, b); len(res.Merged) != 1 {
+		t.Fatalf("pull = %+v, want one merged ref", res)
+	}
+	got, err := b.ReadTask(task.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Comments) != 2 {
+		t.Fatalf("Comments = %+v, want comments from both sides", got.Comments)
+	}
+
+	// The merge contains the remote side, so it pushes cleanly
+	if res := push(t, b); len(res.Rejected) != 0 {
+		t.Fatalf("push after merge = %+v, want no rejections", res)
+	}
+	if res := pull(t, a); len(res.Updated) != 1 {
+		t.Fatalf("pull = %+v, want the merge fast-forwarded", res)
+	}
+}
+
+func TestPullCarriesDeletions(t *testing.T) {
+	a, b := newClones(t)
+
+	task := model.NewTask("short lived", "", "alice", true)
+	if err := a.WriteTask(task); err != nil {
+		t.Fatal(err)
+	}
+	push(t, a)
+	pull(t, b)
+
+	if err := a.DeleteTask(task.ID); err != nil {
+		t.Fatal(err)
+	}
+	push(t, a)
+	pull(t, b)
+
+	if _, err := b.ReadTask(task.ID); err == nil {
+		t.Error("ReadTask after pulling a deletion succeeded, want error")
+	}
+}
+
+func TestPullMergesDivergedLockJournals(t *testing.T) {
+	a, b := newClones(t)
+
+	// Both clones journal the same target without having synced
+	first := model.NewLockEvent("src/auth/", model.EventAcquired, "alice")
+	second := model.NewLockEvent("src/auth/", model.EventAcquired, "bob")
+	if err := a.AppendLockEvent(first); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.AppendLockEvent(second); err != nil {
+		t.Fatal(err)
+	}
+	push(t, a)
+
+	if res := pull(t, b); len(res.Merged) != 1 {
+		t.Fatalf("pull = %+v, want one merged ref", res)
+	}
+	events, err := b.ListLockEvents("src/auth/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 2 {
+		t.Fatalf("events = %+v, want the events from both sides", events)
+	}
+
+	push(t, b)
+	pull(t, a)
+	events, err = a.ListLockEvents("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 2 {
+		t.Fatalf("events = %+v, want the merged journal", events)
+	}
+}
+
+func TestPullKeepsTheFirstLockHolder(t *testing.T) {
+	a, b := newClones(t)
+	now := time.Now().UTC()
+
+	// Alice takes the lock and pushes; Bob takes it later in a clone
+	// that has not pulled, with someone queued on each side
+	alice := model.NewLock("src/auth/", "alice")
+	alice.LockedAt = now.Add(-time.Minute)
+	alice.Queue = []model.Waiter{{Agent: "carol", QueuedAt: now.Add(-30 * time.Second), SeenAt: now}}
+	if err := a.WriteLock(alice); err != nil {
+		t.Fatal(err)
+	}
+	push(t, a)
+
+	bob := model.NewLock("src/auth/", "bob")
+	bob.Queue = []model.Waiter{{Agent: "dave", QueuedAt: now.Add(-45 * time.Second), SeenAt: now}}
+	if err := b.WriteLock(bob); err != nil {
+		t.Fatal(err)
+	}
+	if res := push(t, b); len(res.Rejected) != 1 {
+		t.Fatalf("push = %+v, want the lock rejected", res)
+	}
+
+	if res := pull(t, b); len(res.Merged) != 1 {
+		t.Fatalf("pull = %+v, want one merged ref", res)
+	}
+	got, err := b.ReadLock("src/auth/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.LockedBy != "alice" {
+		t.Errorf("LockedBy = %s after pull, want alice, who acquired first", got.LockedBy)
+	}
+	var queue []string
+	for _, w := range got.Queue {
+		queue = append(queue, w.Agent)
+	}
+	if fmt.Sprint(queue) != "[dave carol]" {
+		t.Errorf("Queue = %v, want both sides' waiters in the order they queued", queue)
+	}
+
+	// The merge pushes cleanly, and the first clone keeps its holder
+	if res := push(t, b); len(res.Rejected) != 0 {
+		t.Fatalf("push after merge = %+v, want no rejections", res)
+	}
+	pull(t, a)
+	if got, err := a.ReadLock("src/auth/"); err != nil || got.LockedBy != "alice" {
+		t.Errorf("ReadLock in the first clone = %+v, %v; want alice's lock", got, err)
+	}
+}
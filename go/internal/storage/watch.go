@@ -0,0 +1,306 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/user/git-context/internal/model"
+)
+
+// Change actions reported by a Watcher. Tasks and locks report what
+// happened to them where it can be told from the new state.
+const (
+	ActionCreated   = "created"
+	ActionUpdated   = "updated"
+	ActionDeleted   = "deleted"
+	ActionClaimed   = "claimed"
+	ActionDropped   = "dropped"
+	ActionDone      = "done"
+	ActionCommented = "commented"
+	ActionMoved     = "moved"
+	ActionAcquired  = "acquired"
+	ActionReleased  = "released"
+)
+
+// Change is one change to the context seen by a Watcher. Exactly one of
+// Memory, Task and Lock is set to the entity's state after the change
+// (before it, for deletions).
+type Change struct {
+	Time    time.Time     `json:"time"`
+	Entity  string        `json:"entity"` // memory, task or lock
+	Action  string        `json:"action"`
+	ID      string        `json:"id"` // lock target for locks
+	Storage string        `json:"storage"`
+	Author  string        `json:"author,omitempty"`
+	Memory  *model.Memory `json:"memory,omitempty"`
+	Task    *model.Task   `json:"task,omitempty"`
+	Lock    *model.Lock   `json:"lock,omitempty"`
+}
+
+// fingerprinter is implemented by storages that can cheaply tell
+// whether anything changed since the last look.
+type fingerprinter interface {
+	fingerprint() (string, error)
+}
+
+// fingerprint lists every file with its size and modification time.
+func (s *LocalStorage) fingerprint() (string, error) {
+	var b strings.Builder
+	err := filepath.Walk(s.baseDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			fmt.Fprintf(&b, "%s %d %d\n", path, info.Size(), info.ModTime().UnixNano())
+		}
+		return nil
+	})
+	return b.String(), err
+}
+
+// fingerprint lists the context refs; every change moves one of them.
+func (s *SharedStorage) fingerprint() (string, error) {
+	return s.git.run(nil, "for-each-ref", "--format=%(refname) %(objectname)", refPrefix)
+}
+
+// snapshot is the state of one storage, keyed by entity and ID.
+type snapshot struct {
+	memories map[string]*model.Memory
+	tasks    map[string]*model.Task
+	locks    map[string]*model.Lock
+}
+
+func takeSnapshot(s Storage) (*snapshot, error) {
+	snap := &snapshot{
+		memories: make(map[string]*model.Memory),
+		tasks:    make(map[string]*model.Task),
+		locks:    make(map[string]*model.Lock),
+	}
+
+	memories, err := s.ListMemories()
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range memories {
+		snap.memories[m.ID] = m
+	}
+
+	tasks, err := s.ListTasks()
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range tasks {
+		snap.tasks[t.ID] = t
+	}
+
+	locks, err := s.ListLocks()
+	if err != nil {
+		return nil, err
+	}
+	for _, l := range locks {
+		snap.locks[l.Target] = l
+	}
+
+	return snap, nil
+}
+
+// watched is one storage being watched.
+type watched struct {
+	name        string
+	storage     Storage
+	fingerprint string
+	snap        *snapshot
+}
+
+// Watcher reports changes to local and shared context between polls,
+// whether made by this process, another git-ctx, or a pull.
+type Watcher struct {
+	sides []*watched
+}
+
+// NewWatcher creates a watcher and records the current state as the
+// baseline for the first Poll.
+func NewWatcher(ms *MultiStorage) (*Watcher, error) {
+	w := &Watcher{sides: []*watched{
+		{name: "local", storage: ms.Local},
+		{name: "shared", storage: ms.Shared},
+	}}
+	if _, err := w.Poll(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// Poll returns the changes since the previous poll.
+func (w *Watcher) Poll() ([]Change, error) {
+	var changes []Change
+	now := time.Now().UTC()
+
+	for _, side := range w.sides {
+		// Skip the full read when the storage can tell nothing changed
+		fp, known := "", false
+		if f, ok := side.storage.(fingerprinter); ok {
+			var err error
+			fp, err = f.fingerprint()
+			known = err == nil
+		}
+		if known && side.snap != nil && fp == side.fingerprint {
+			continue
+		}
+
+		snap, err := takeSnapshot(side.storage)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", side.name, err)
+		}
+		if side.snap != nil {
+			changes = append(changes, diffSnapshots(side.snap, snap, side.name, now)...)
+		}
+		side.snap = snap
+		side.fingerprint = fp
+	}
+
+	return changes, nil
+}
+
+// diffSnapshots reports what changed from old to cur.
+func diffSnapshots(old, cur *snapshot, storageName string, now time.Time) []Change {
+	var changes []Change
+	add := func(c Change) {
+		c.Time = now
+		c.Storage = storageName
+		changes = append(changes, c)
+	}
+
+	for _, id := range unionKeys(old.memoryIDs(), cur.memoryIDs()) {
+		before, after := old.memories[id], cur.memories[id]
+		switch {
+		case before == nil:
+			add(Change{Entity: model.EntityMemory, Action: ActionCreated, ID: id, Author: after.Author, Memory: after})
+		case after == nil:
+			add(Change{Entity: model.EntityMemory, Action: ActionDeleted, ID: id, Memory: before})
+		case !sameJSON(before, after):
+			add(Change{Entity: model.EntityMemory, Action: ActionUpdated, ID: id, Author: lastAuthor(after.History, ""), Memory: after})
+		}
+	}
+
+	for _, id := range unionKeys(old.taskIDs(), cur.taskIDs()) {
+		before, after := old.tasks[id], cur.tasks[id]
+		switch {
+		case before == nil:
+			add(Change{Entity: model.EntityTask, Action: ActionCreated, ID: id, Author: after.CreatedBy, Task: after})
+		case after == nil:
+			add(Change{Entity: model.EntityTask, Action: ActionDeleted, ID: id, Task: before})
+		case !sameJSON(before, after):
+			for _, c := range taskChanges(before, after) {
+				c.Entity, c.ID, c.Task = model.EntityTask, id, after
+				add(c)
+			}
+		}
+	}
+
+	for _, target := range unionKeys(old.lockTargets(), cur.lockTargets()) {
+		before, after := old.locks[target], cur.locks[target]
+		switch {
+		case before == nil || (after != nil && !after.LockedAt.Equal(before.LockedAt)):
+			add(Change{Entity: model.EntityLock, Action: ActionAcquired, ID: target, Author: after.LockedBy, Lock: after})
+		case after == nil:
+			add(Change{Entity: model.EntityLock, Action: ActionReleased, ID: target, Author: before.LockedBy, Lock: before})
+		case !sameJSON(before, after):
+			add(Change{Entity: model.EntityLock, Action: ActionUpdated, ID: target, Author: after.LockedBy, Lock: after})
+		}
+	}
+
+	return changes
+}
+
+// taskChanges describes how a task changed: status transitions and new
+// comments, or a plain update if neither happened.
+func taskChanges(before, after *model.Task) []Change {
+	var changes []Change
+
+	if before.Status != after.Status || before.Owner != after.Owner {
+		switch after.Status {
+		case model.TaskClaimed:
+			changes = append(changes, Change{Action: ActionClaimed, Author: after.Owner})
+		case model.TaskDone:
+			changes = append(changes, Change{Action: ActionDone, Author: lastAuthor(after.History, "")})
+		case model.TaskOpen:
+			changes = append(changes, Change{Action: ActionDropped, Author: lastAuthor(after.History, "")})
+		default:
+			changes = append(changes, Change{Action: ActionMoved, Author: lastAuthor(after.History, "")})
+		}
+	}
+
+	for _, c := range after.Comments {
+		if !hasComment(before.Comments, c) {
+			changes = append(changes, Change{Action: ActionCommented, Author: c.Author})
+		}
+	}
+
+	if len(changes) == 0 {
+		changes = append(changes, Change{Action: ActionUpdated, Author: lastAuthor(after.History, "")})
+	}
+	return changes
+}
+
+// lastAuthor returns the author of the latest event of the given type
+// (any type if empty).
+func lastAuthor(history []model.Event, typ model.EventType) string {
+	for i := len(history) - 1; i >= 0; i-- {
+		if typ == "" || history[i].Type == typ {
+			return history[i].Author
+		}
+	}
+	return ""
+}
+
+func sameJSON(a, b interface{}) bool {
+	da, errA := json.Marshal(a)
+	db, errB := json.Marshal(b)
+	return errA == nil && errB == nil && string(da) == string(db)
+}
+
+// unionKeys returns the sorted, distinct keys of several key lists.
+func unionKeys(lists ...[]string) []string {
+	seen := make(map[string]bool)
+	var keys []string
+	for _, list := range lists {
+		for _, k := range list {
+			if !seen[k] {
+				seen[k] = true
+				keys = append(keys, k)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (s *snapshot) memoryIDs() []string {
+	var ids []string
+	for id := range s.memories {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+func (s *snapshot) taskIDs() []string {
+	var ids []string
+	for id := range s.tasks {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+func (s *snapshot) lockTargets() []string {
+	var targets []string
+	for target := range s.locks {
+		targets = append(targets, target)
+	}
+	return targets
+}
@@ -0,0 +1,90 @@
+package tui
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	numberedItem = regexp.MustCompile(`^(\d+[.)]) +(.*)$`)
+	inlineMarks  = strings.NewReplacer("**", "", "__", "", "`", "")
+)
+
+// renderMarkdown renders markdown as styled lines of at most width
+// columns: headings are bold, list items and quotes are indented, code
+// blocks are kept verbatim, and inline emphasis marks are dropped.
+func renderMarkdown(text string, width int) []string {
+	var lines []string
+	inCode := false
+
+	for _, raw := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		trimmed := strings.TrimSpace(raw)
+
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			inCode = !inCode
+			continue
+		}
+		if inCode {
+			lines = append(lines, styled(fit("  "+strings.TrimRight(raw, " \t"), width), cyan))
+			continue
+		}
+
+		switch {
+		case trimmed == "":
+			lines = append(lines, "")
+
+		case strings.HasPrefix(trimmed, "#"):
+			level := len(trimmed) - len(strings.TrimLeft(trimmed, "#"))
+			heading := inlineMarks.Replace(strings.TrimSpace(trimmed[level:]))
+			for _, l := range wrap(heading, width) {
+				if level == 1 {
+					lines = append(lines, styled(l, bold, underline))
+				} else {
+					lines = append(lines, styled(l, bold))
+				}
+			}
+
+		case trimmed == "---" || trimmed == "***" || trimmed == "___":
+			lines = append(lines, styled(strings.Repeat("─", width), dim))
+
+		case strings.HasPrefix(trimmed, "- ") || strings.HasPrefix(trimmed, "* ") || strings.HasPrefix(trimmed, "+ "):
+			indent := strings.Repeat(" ", leadingSpaces(raw))
+			lines = append(lines, hanging(indent+"• ", inlineMarks.Replace(trimmed[2:]), width)...)
+
+		case numberedItem.MatchString(trimmed):
+			m := numberedItem.FindStringSubmatch(trimmed)
+			indent := strings.Repeat(" ", leadingSpaces(raw))
+			lines = append(lines, hanging(indent+m[1]+" ", inlineMarks.Replace(m[2]), width)...)
+
+		case strings.HasPrefix(trimmed, ">"):
+			quote := inlineMarks.Replace(strings.TrimSpace(strings.TrimPrefix(trimmed, ">")))
+			for _, l := range wrap(quote, width-2) {
+				lines = append(lines, styled("│ "+l, dim))
+			}
+
+		default:
+			lines = append(lines, wrap(inlineMarks.Replace(trimmed), width)...)
+		}
+	}
+
+	return lines
+}
+
+// hanging wraps text after a marker, indenting continuation lines to
+// line up with the text.
+func hanging(marker, text string, width int) []string {
+	pad := strings.Repeat(" ", len([]rune(marker)))
+	var lines []string
+	for i, l := range wrap(text, width-len(pad)) {
+		if i == 0 {
+			lines = append(lines, marker+l)
+		} else {
+			lines = append(lines, pad+l)
+		}
+	}
+	return lines
+}
+
+func leadingSpaces(s string) int {
+	return len(s) - len(strings.TrimLeft(s, " "))
+}
@@ -0,0 +1,10 @@
+//go:build windows
+// +build windows
+
+package tui
+
+import "os"
+
+// notifyResize does nothing on Windows, which has no resize signal; the
+// size is checked again on every redraw.
+func notifyResize(c chan<- os.Signal) {}
@@ -0,0 +1,165 @@
+package tui
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/term"
+)
+
+// Key names for keys that are not printable characters. Printable keys
+// are reported as themselves.
+const (
+	keyUp        = "up"
+	keyDown      = "down"
+	keyLeft      = "left"
+	keyRight     = "right"
+	keyPageUp    = "pgup"
+	keyPageDown  = "pgdn"
+	keyHome      = "home"
+	keyEnd       = "end"
+	keyEnter     = "enter"
+	keyEscape    = "esc"
+	keyTab       = "tab"
+	keyBackTab   = "backtab"
+	keyBackspace = "backspace"
+	keyCtrlC     = "ctrl-c"
+)
+
+// ANSI sequences used to draw.
+const (
+	altScreenOn  = "\x1b[?1049h"
+	altScreenOff = "\x1b[?1049l"
+	cursorHide   = "\x1b[?25l"
+	cursorShow   = "\x1b[?25h"
+	cursorHome   = "\x1b[H"
+	clearLine    = "\x1b[K"
+)
+
+// terminal is the raw-mode terminal the UI draws on.
+type terminal struct {
+	in    *os.File
+	out   io.Writer
+	state *term.State
+}
+
+// openTerminal switches the terminal to raw mode on the alternate
+// screen. Call close to restore it.
+func openTerminal(in, out *os.File) (*terminal, error) {
+	if !term.IsTerminal(int(in.Fd())) || !term.IsTerminal(int(out.Fd())) {
+		return nil, fmt.Errorf("ui needs an interactive terminal")
+	}
+
+	state, err := term.MakeRaw(int(in.Fd()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to enter raw mode: %w", err)
+	}
+
+	fmt.Fprint(out, altScreenOn+cursorHide)
+	return &terminal{in: in, out: out, state: state}, nil
+}
+
+func (t *terminal) close() {
+	fmt.Fprint(t.out, cursorShow+altScreenOff)
+	term.Restore(int(t.in.Fd()), t.state)
+}
+
+// size returns the terminal's width and height.
+func (t *terminal) size() (int, int) {
+	w, h, err := term.GetSize(int(t.in.Fd()))
+	if err != nil || w <= 0 || h <= 0 {
+		return 80, 24
+	}
+	return w, h
+}
+
+// draw replaces the screen with lines.
+func (t *terminal) draw(lines []string) {
+	var b strings.Builder
+	b.WriteString(cursorHome)
+	for i, line := range lines {
+		if i > 0 {
+			b.WriteString("\r\n")
+		}
+		b.WriteString(line)
+		b.WriteString(reset + clearLine)
+	}
+	io.WriteString(t.out, b.String())
+}
+
+// readKeys sends keys read from the terminal until it fails.
+func (t *terminal) readKeys(keys chan<- string) {
+	buf := make([]byte, 256)
+	for {
+		n, err := t.in.Read(buf)
+		if err != nil {
+			close(keys)
+			return
+		}
+		for _, k := range parseKeys(buf[:n]) {
+			keys <- k
+		}
+	}
+}
+
+// escapeKeys maps the escape sequences of common terminals to keys.
+var escapeKeys = map[string]string{
+	"[A": keyUp, "[B": keyDown, "[C": keyRight, "[D": keyLeft,
+	"OA": keyUp, "OB": keyDown, "OC": keyRight, "OD": keyLeft,
+	"[5~": keyPageUp, "[6~": keyPageDown,
+	"[H": keyHome, "[F": keyEnd, "[1~": keyHome, "[4~": keyEnd,
+	"OH": keyHome, "OF": keyEnd,
+	"[Z": keyBackTab,
+}
+
+// parseKeys splits one read from the terminal into keys.
+func parseKeys(data []byte) []string {
+	var keys []string
+	for len(data) > 0 {
+		switch c := data[0]; {
+		case c == 0x1b:
+			if seq, n := escapeSequence(data[1:]); n > 0 {
+				if k, ok := escapeKeys[seq]; ok {
+					keys = append(keys, k)
+				}
+				data = data[1+n:]
+				continue
+			}
+			keys = append(keys, keyEscape)
+		case c == '\r' || c == '\n':
+			keys = append(keys, keyEnter)
+		case c == '\t':
+			keys = append(keys, keyTab)
+		case c == 0x7f || c == 0x08:
+			keys = append(keys, keyBackspace)
+		case c == 0x03:
+			keys = append(keys, keyCtrlC)
+		case c < 0x20:
+			// Other control keys are not used
+		default:
+			r, size := utf8.DecodeRune(data)
+			keys = append(keys, string(r))
+			data = data[size:]
+			continue
+		}
+		data = data[1:]
+	}
+	return keys
+}
+
+// escapeSequence returns the CSI or SS3 sequence at the start of data
+// (after ESC) and its length, or 0 if there is none.
+func escapeSequence(data []byte) (string, int) {
+	if len(data) < 2 || (data[0] != '[' && data[0] != 'O') {
+		return "", 0
+	}
+	for i := 1; i < len(data); i++ {
+		if c := data[i]; c >= 0x40 && c <= 0x7e {
+			return string(data[:i+1]), i + 1
+		}
+	}
+	return "", 0
+}
@@ -0,0 +1,117 @@
+package tui
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// SGR styles. Text is fitted to its width before it is styled, so
+// widths are always counted on plain text.
+const (
+	reset     = "\x1b[0m"
+	bold      = "\x1b[1m"
+	dim       = "\x1b[2m"
+	underline = "\x1b[4m"
+	reverse   = "\x1b[7m"
+	red       = "\x1b[31m"
+	green     = "\x1b[32m"
+	yellow    = "\x1b[33m"
+	cyan      = "\x1b[36m"
+)
+
+// styled wraps s in styles.
+func styled(s string, styles ...string) string {
+	if len(styles) == 0 {
+		return s
+	}
+	return strings.Join(styles, "") + s + reset
+}
+
+// fit truncates s to w columns, marking the cut with an ellipsis, and
+// pads it with spaces to exactly w.
+func fit(s string, w int) string {
+	if w <= 0 {
+		return ""
+	}
+	n := utf8.RuneCountInString(s)
+	if n > w {
+		r := []rune(s)
+		return string(r[:w-1]) + "…"
+	}
+	return s + strings.Repeat(" ", w-n)
+}
+
+// oneLine collapses whitespace, including newlines, to single spaces.
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// wrap breaks s into lines of at most w columns at spaces. Words longer
+// than a line are split.
+func wrap(s string, w int) []string {
+	if w <= 0 {
+		return nil
+	}
+
+	var lines []string
+	var line []rune
+	for _, word := range strings.Fields(s) {
+		r := []rune(word)
+		for len(r) > w {
+			if len(line) > 0 {
+				lines = append(lines, string(line))
+				line = nil
+			}
+			lines = append(lines, string(r[:w]))
+			r = r[w:]
+		}
+		switch {
+		case len(line) == 0:
+			line = r
+		case len(line)+1+len(r) <= w:
+			line = append(append(line, ' '), r...)
+		default:
+			lines = append(lines, string(line))
+			line = r
+		}
+	}
+	if len(line) > 0 {
+		lines = append(lines, string(line))
+	}
+	if len(lines) == 0 {
+		lines = []string{""}
+	}
+	return lines
+}
+
+// window returns the offset of a view of size rows over n items that
+// keeps selected visible, moving offset as little as possible.
+func window(selected, offset, rows, n int) int {
+	if rows <= 0 || n <= rows {
+		return 0
+	}
+	if selected < offset {
+		offset = selected
+	}
+	if selected >= offset+rows {
+		offset = selected - rows + 1
+	}
+	if offset > n-rows {
+		offset = n - rows
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	return offset
+}
+
+// clamp limits v to [0, n-1], or 0 if n is 0.
+func clamp(v, n int) int {
+	if v >= n {
+		v = n - 1
+	}
+	if v < 0 {
+		v = 0
+	}
+	return v
+}
@@ -0,0 +1,500 @@
+// Package tui implements git ctx ui, a full-screen terminal interface
+// for browsing memories, working a task board and watching locks.
+package tui
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/user/git-context/gitctx"
+	"github.com/user/git-context/internal/model"
+)
+
+// view is one of the UI's tabs.
+type view int
+
+const (
+	viewMemories view = iota
+	viewTasks
+	viewLocks
+)
+
+var viewNames = []string{"Memories", "Tasks", "Locks"}
+
+// lockRow is a lock with where it is stored.
+type lockRow struct {
+	lock   *model.Lock
+	shared bool
+}
+
+// prompt is a line of input being typed at the bottom of the screen.
+type prompt struct {
+	label  string
+	text   []rune
+	submit func(text string) error
+}
+
+// App is the state of the UI.
+type App struct {
+	client  *gitctx.Client
+	term    *terminal
+	watcher *gitctx.Watcher
+
+	// Interval is how often storage is checked for changes.
+	Interval time.Duration
+
+	view    view
+	width   int
+	height  int
+	prompt  *prompt
+	message string
+	failed  bool
+
+	// Memories
+	memories []*model.Memory
+	filter   string
+	memSel   int
+	memTop   int
+	preview  int // preview scroll offset
+
+	// Tasks
+	workflow *model.Workflow
+	statuses []model.TaskStatus // board columns: the workflow's statuses
+	columns  [][]*model.Task
+	tasks    map[string]*model.Task
+	col      int
+	rows     []int
+	tops     []int
+	detail   bool
+	scroll   int // detail scroll offset
+
+	// Locks
+	locks   []lockRow
+	lockSel int
+	lockTop int
+}
+
+// New creates the UI for a client's repository.
+func New(client *gitctx.Client) *App {
+	return &App{
+		client:   client,
+		Interval: time.Second,
+	}
+}
+
+// Run shows the UI until the user quits.
+func (a *App) Run() error {
+	watcher, err := a.client.Watch()
+	if err != nil {
+		return err
+	}
+	a.watcher = watcher
+	if err := a.reload(); err != nil {
+		return err
+	}
+
+	t, err := openTerminal(os.Stdin, os.Stdout)
+	if err != nil {
+		return err
+	}
+	defer t.close()
+	a.term = t
+
+	keys := make(chan string, 16)
+	go t.readKeys(keys)
+
+	resize := make(chan os.Signal, 1)
+	notifyResize(resize)
+
+	ticker := time.NewTicker(a.Interval)
+	defer ticker.Stop()
+
+	for {
+		a.draw()
+
+		select {
+		case k, ok := <-keys:
+			if !ok || a.handleKey(k) {
+				return nil
+			}
+		case <-resize:
+		case <-ticker.C:
+			changes, err := a.watcher.Poll()
+			if err != nil {
+				a.setError(err)
+			} else if len(changes) > 0 {
+				a.refresh()
+			}
+		}
+	}
+}
+
+// reload reads everything from storage.
+func (a *App) reload() error {
+	ctx := context.Background()
+	workflow, err := a.client.Workflow(ctx)
+	if err != nil {
+		return err
+	}
+
+	memories, err := a.client.ListMemories(ctx, gitctx.ListOptions{})
+	if err != nil {
+		return err
+	}
+	tasks, err := a.client.ListTasks(ctx, gitctx.TaskFilter{})
+	if err != nil {
+		return err
+	}
+	var locks []lockRow
+	for _, loc := range []gitctx.Location{gitctx.Local, gitctx.Shared} {
+		ls, err := a.client.ListLocks(ctx, loc)
+		if err != nil {
+			return err
+		}
+		for _, l := range ls {
+			locks = append(locks, lockRow{l, loc == gitctx.Shared})
+		}
+	}
+
+	sort.SliceStable(memories, func(i, j int) bool {
+		return memories[i].UpdatedAt.After(memories[j].UpdatedAt)
+	})
+	sort.SliceStable(tasks, func(i, j int) bool {
+		return tasks[i].UpdatedAt.After(tasks[j].UpdatedAt)
+	})
+	sort.SliceStable(locks, func(i, j int) bool {
+		return locks[i].lock.Target < locks[j].lock.Target
+	})
+
+	// A column per workflow status, then one for any status a task has
+	// that the workflow no longer does
+	statuses := workflow.Names()
+	for _, t := range tasks {
+		if workflow.Status(t.Status) == nil && !hasStatus(statuses, t.Status) {
+			statuses = append(statuses, t.Status)
+		}
+	}
+
+	a.memories = memories
+	a.locks = locks
+	a.workflow = workflow
+	a.statuses = statuses
+	a.tasks = make(map[string]*model.Task)
+	a.columns = make([][]*model.Task, len(statuses))
+	for _, t := range tasks {
+		a.tasks[t.ID] = t
+		for i, status := range statuses {
+			if t.Status == status {
+				a.columns[i] = append(a.columns[i], t)
+			}
+		}
+	}
+	for len(a.rows) < len(statuses) {
+		a.rows, a.tops = append(a.rows, 0), append(a.tops, 0)
+	}
+	a.col = clamp(a.col, len(statuses))
+	return nil
+}
+
+func hasStatus(statuses []model.TaskStatus, status model.TaskStatus) bool {
+	for _, s := range statuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// refresh reloads, keeping the selection on the same entries where they
+// still exist.
+func (a *App) refresh() {
+	mem, task, lock := a.selectedMemory(), a.selectedTask(), a.selectedLock()
+
+	if err := a.reload(); err != nil {
+		a.setError(err)
+		return
+	}
+
+	if mem != nil {
+		for i, m := range a.visibleMemories() {
+			if m.ID == mem.ID {
+				a.memSel = i
+			}
+		}
+	}
+	if task != nil {
+		for c, column := range a.columns {
+			for r, t := range column {
+				if t.ID == task.ID {
+					a.col, a.rows[c] = c, r
+				}
+			}
+		}
+	}
+	if lock != nil {
+		for i, l := range a.locks {
+			if l.lock.Target == lock.lock.Target {
+				a.lockSel = i
+			}
+		}
+	}
+}
+
+// visibleMemories returns the memories matching the filter.
+func (a *App) visibleMemories() []*model.Memory {
+	if a.filter == "" {
+		return a.memories
+	}
+	var matches []*model.Memory
+	for _, m := range a.memories {
+		if m.MatchesSearch(a.filter) {
+			matches = append(matches, m)
+		}
+	}
+	return matches
+}
+
+func (a *App) selectedMemory() *model.Memory {
+	memories := a.visibleMemories()
+	if len(memories) == 0 {
+		return nil
+	}
+	return memories[clamp(a.memSel, len(memories))]
+}
+
+func (a *App) selectedTask() *model.Task {
+	if a.col >= len(a.columns) || len(a.columns[a.col]) == 0 {
+		return nil
+	}
+	column := a.columns[a.col]
+	return column[clamp(a.rows[a.col], len(column))]
+}
+
+func (a *App) selectedLock() *lockRow {
+	if len(a.locks) == 0 {
+		return nil
+	}
+	return &a.locks[clamp(a.lockSel, len(a.locks))]
+}
+
+func (a *App) setMessage(format string, args ...interface{}) {
+	a.message, a.failed = fmt.Sprintf(format, args...), false
+}
+
+func (a *App) setError(err error) {
+	a.message, a.failed = err.Error(), true
+}
+
+// act runs a change through the client and reports the outcome.
+func (a *App) act(err error, format string, args ...interface{}) {
+	if err != nil {
+		a.setError(err)
+		return
+	}
+	a.setMessage(format, args...)
+	a.refresh()
+}
+
+// handleKey applies a key press and reports whether to quit.
+func (a *App) handleKey(k string) bool {
+	if a.prompt != nil {
+		a.handlePromptKey(k)
+		return false
+	}
+
+	a.message = ""
+	switch k {
+	case "q", keyCtrlC:
+		return true
+	case "1":
+		a.view = viewMemories
+	case "2":
+		a.view = viewTasks
+	case "3":
+		a.view = viewLocks
+	case keyTab:
+		a.view = (a.view + 1) % view(len(viewNames))
+		a.detail = false
+	case keyBackTab:
+		a.view = (a.view + view(len(viewNames)) - 1) % view(len(viewNames))
+		a.detail = false
+	case "r":
+		a.refresh()
+		a.setMessage("Refreshed")
+	default:
+		switch a.view {
+		case viewMemories:
+			a.memoryKey(k)
+		case viewTasks:
+			a.taskKey(k)
+		case viewLocks:
+			a.lockKey(k)
+		}
+	}
+	return false
+}
+
+func (a *App) handlePromptKey(k string) {
+	p := a.prompt
+	switch k {
+	case keyEscape, keyCtrlC:
+		a.prompt = nil
+	case keyEnter:
+		a.prompt = nil
+		if err := p.submit(string(p.text)); err != nil {
+			a.setError(err)
+		}
+	case keyBackspace:
+		if len(p.text) > 0 {
+			p.text = p.text[:len(p.text)-1]
+		}
+	default:
+		if r := []rune(k); len(r) == 1 {
+			p.text = append(p.text, r[0])
+		}
+	}
+}
+
+// moveKey returns how far a navigation key moves a selection, given the
+// page size.
+func moveKey(k string, page int) (int, bool) {
+	switch k {
+	case keyUp, "k":
+		return -1, true
+	case keyDown, "j":
+		return 1, true
+	case keyPageUp:
+		return -page, true
+	case keyPageDown:
+		return page, true
+	case keyHome, "g":
+		return -1 << 30, true
+	case keyEnd, "G":
+		return 1 << 30, true
+	}
+	return 0, false
+}
+
+func (a *App) memoryKey(k string) {
+	memories := a.visibleMemories()
+	if d, ok := moveKey(k, a.bodyHeight()); ok {
+		a.memSel = clamp(a.memSel+d, len(memories))
+		a.preview = 0
+		return
+	}
+
+	switch k {
+	case "J", " ":
+		a.preview += a.bodyHeight() / 2
+	case "K":
+		a.preview -= a.bodyHeight() / 2
+		if a.preview < 0 {
+			a.preview = 0
+		}
+	case "/":
+		a.prompt = &prompt{label: "Filter: ", text: []rune(a.filter), submit: func(text string) error {
+			a.filter = text
+			a.memSel, a.memTop, a.preview = 0, 0, 0
+			return nil
+		}}
+	case keyEscape:
+		a.filter = ""
+		a.memSel, a.memTop, a.preview = 0, 0, 0
+	}
+}
+
+func (a *App) taskKey(k string) {
+	ctx := context.Background()
+
+	if a.detail {
+		switch k {
+		case keyEscape, keyEnter:
+			a.detail = false
+			return
+		case keyUp, "k":
+			if a.scroll > 0 {
+				a.scroll--
+			}
+			return
+		case keyDown, "j":
+			a.scroll++
+			return
+		}
+	}
+
+	if d, ok := moveKey(k, a.bodyHeight()/2); ok && !a.detail {
+		a.rows[a.col] = clamp(a.rows[a.col]+d, len(a.columns[a.col]))
+		return
+	}
+
+	switch k {
+	case keyLeft, "h":
+		if a.col > 0 {
+			a.col--
+		}
+		a.detail = false
+	case keyRight, "l":
+		if a.col < len(a.statuses)-1 {
+			a.col++
+		}
+		a.detail = false
+	case keyEnter:
+		if a.selectedTask() != nil {
+			a.detail, a.scroll = true, 0
+		}
+	}
+
+	t := a.selectedTask()
+	if t == nil {
+		return
+	}
+	switch k {
+	case "c":
+		_, err := a.client.ClaimTask(ctx, t.ID)
+		a.act(err, "Claimed: %s", t.ID)
+	case "d":
+		_, err := a.client.CompleteTask(ctx, t.ID)
+		a.act(err, "Done: %s", t.ID)
+	case "x":
+		_, err := a.client.DropTask(ctx, t.ID)
+		a.act(err, "Dropped: %s", t.ID)
+	case "M":
+		id := t.ID
+		a.prompt = &prompt{label: "Move " + id + " to: ", submit: func(text string) error {
+			if text == "" {
+				return nil
+			}
+			_, err := a.client.MoveTask(ctx, id, model.TaskStatus(text))
+			a.act(err, "Moved %s to %s", id, text)
+			return nil
+		}}
+	case "m":
+		id := t.ID
+		a.prompt = &prompt{label: "Comment on " + id + ": ", submit: func(text string) error {
+			if text == "" {
+				return nil
+			}
+			_, err := a.client.CommentTask(ctx, id, text)
+			a.act(err, "Commented on %s", id)
+			return nil
+		}}
+	}
+}
+
+func (a *App) lockKey(k string) {
+	if d, ok := moveKey(k, a.bodyHeight()); ok {
+		a.lockSel = clamp(a.lockSel+d, len(a.locks))
+		return
+	}
+
+	if k == "u" {
+		if row := a.selectedLock(); row != nil {
+			target := row.lock.Target
+			_, err := a.client.Unlock(context.Background(), target)
+			a.act(err, "Unlocked: %s", target)
+		}
+	}
+}
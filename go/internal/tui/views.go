@@ -0,0 +1,378 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/user/git-context/internal/model"
+)
+
+// draw renders the whole screen: tabs, the current view, the status
+// line and the key help.
+func (a *App) draw() {
+	a.width, a.height = a.term.size()
+	body := a.bodyHeight()
+
+	var lines []string
+	switch a.view {
+	case viewMemories:
+		lines = a.drawMemories(body)
+	case viewTasks:
+		if a.detail && a.selectedTask() != nil {
+			lines = a.drawTaskDetail(body)
+		} else {
+			lines = a.drawBoard(body)
+		}
+	case viewLocks:
+		lines = a.drawLocks(body)
+	}
+	for len(lines) < body {
+		lines = append(lines, "")
+	}
+
+	screen := append([]string{a.drawTabs()}, lines[:body]...)
+	screen = append(screen, a.drawStatus(), a.drawHelp())
+	a.term.draw(screen)
+}
+
+// bodyHeight is the number of lines the current view can use.
+func (a *App) bodyHeight() int {
+	if h := a.height - 3; h > 0 {
+		return h
+	}
+	return 1
+}
+
+func (a *App) drawTabs() string {
+	var b strings.Builder
+	b.WriteString(styled(" git-ctx ", bold))
+	for i, name := range viewNames {
+		tab := fmt.Sprintf(" %d %s ", i+1, name)
+		if view(i) == a.view {
+			b.WriteString(styled(tab, reverse))
+		} else {
+			b.WriteString(tab)
+		}
+	}
+	b.WriteString(styled("  as "+a.client.Author(), dim))
+	return b.String()
+}
+
+func (a *App) drawStatus() string {
+	switch {
+	case a.prompt != nil:
+		return styled(a.prompt.label, bold) + string(a.prompt.text) + "█"
+	case a.message != "" && a.failed:
+		return styled(fit(a.message, a.width), red)
+	case a.message != "":
+		return styled(fit(a.message, a.width), green)
+	case a.view == viewMemories && a.filter != "":
+		return styled(fmt.Sprintf("Filter: %s (esc to clear)", a.filter), dim)
+	}
+	return ""
+}
+
+func (a *App) drawHelp() string {
+	var help string
+	switch {
+	case a.prompt != nil:
+		help = "enter submit  esc cancel"
+	case a.view == viewMemories:
+		help = "↑↓ select  J/K scroll preview  / filter  tab switch view  r refresh  q quit"
+	case a.view == viewTasks && a.detail:
+		help = "↑↓ scroll  esc back  c claim  d done  x drop  M move  m comment  q quit"
+	case a.view == viewTasks:
+		help = "←→↑↓ select  enter details  c claim  d done  x drop  M move  m comment  tab switch view  q quit"
+	case a.view == viewLocks:
+		help = "↑↓ select  u unlock  tab switch view  r refresh  q quit"
+	}
+	return styled(fit(help, a.width), dim)
+}
+
+// drawMemories shows the memory list beside a preview of the selected
+// memory.
+func (a *App) drawMemories(body int) []string {
+	memories := a.visibleMemories()
+	if len(memories) == 0 {
+		if a.filter != "" {
+			return []string{styled(" No memories match "+a.filter, dim)}
+		}
+		return []string{styled(" No memories. Add one with: git ctx add", dim)}
+	}
+
+	a.memSel = clamp(a.memSel, len(memories))
+	a.memTop = window(a.memSel, a.memTop, body, len(memories))
+
+	listW := a.width / 3
+	if listW < 24 {
+		listW = 24
+	}
+	if listW > 50 {
+		listW = 50
+	}
+	previewW := a.width - listW - 3
+	if previewW < 20 {
+		listW, previewW = a.width, 0
+	}
+
+	kindW := 10
+	list := make([]string, body)
+	for i := range list {
+		n := a.memTop + i
+		if n >= len(memories) {
+			list[i] = fit("", listW)
+			continue
+		}
+		m := memories[n]
+		kind := string(m.Kind)
+		if kind == "" {
+			kind = string(model.KindNote)
+		}
+		title, tag := fit(" "+oneLine(m.Title), listW-kindW), fit(kind, kindW)
+		if n == a.memSel {
+			list[i] = styled(title+tag, reverse)
+		} else {
+			list[i] = title + styled(tag, dim)
+		}
+	}
+	if previewW == 0 {
+		return list
+	}
+
+	preview := a.memoryPreview(memories[a.memSel], previewW)
+	if last := len(preview) - body; a.preview > last {
+		a.preview = last
+	}
+	if a.preview < 0 {
+		a.preview = 0
+	}
+	preview = preview[a.preview:]
+
+	lines := make([]string, body)
+	for i := range lines {
+		lines[i] = list[i] + styled(" │ ", dim)
+		if i < len(preview) {
+			lines[i] += preview[i]
+		}
+	}
+	return lines
+}
+
+func (a *App) memoryPreview(m *model.Memory, width int) []string {
+	var lines []string
+	for _, l := range wrap(m.Title, width) {
+		lines = append(lines, styled(l, bold))
+	}
+
+	meta := []string{m.ID, m.Author, m.UpdatedAt.Local().Format("2006-01-02 15:04"), storageName(m.Shared)}
+	if m.Kind != "" {
+		meta = append([]string{string(m.Kind)}, meta...)
+	}
+	lines = append(lines, styled(fit(strings.Join(meta, " · "), width), dim))
+	if len(m.Tags) > 0 {
+		lines = append(lines, styled(fit("#"+strings.Join(m.Tags, " #"), width), cyan))
+	}
+	lines = append(lines, "")
+	lines = append(lines, renderMarkdown(m.Content, width)...)
+
+	if len(m.Links) > 0 {
+		lines = append(lines, "", styled("Links", bold))
+		for _, l := range m.Links {
+			lines = append(lines, fit(fmt.Sprintf("  %s %s", l.Type, l.Target), width))
+		}
+	}
+	return lines
+}
+
+// cardHeight is the number of lines per task card, including the gap.
+const cardHeight = 3
+
+// drawBoard shows tasks as a kanban board with a column per status.
+func (a *App) drawBoard(body int) []string {
+	n := len(a.statuses)
+	colW := (a.width - (n - 1)) / n
+	cards := (body - 2) / cardHeight
+	if cards < 1 {
+		cards = 1
+	}
+
+	columns := make([][]string, n)
+	for c, status := range a.statuses {
+		tasks := a.columns[c]
+		a.rows[c] = clamp(a.rows[c], len(tasks))
+		a.tops[c] = window(a.rows[c], a.tops[c], cards, len(tasks))
+
+		header := fit(fmt.Sprintf(" %s (%d)", strings.ToUpper(string(status)), len(tasks)), colW)
+		if c == a.col {
+			header = styled(header, bold, underline)
+		} else {
+			header = styled(header, bold)
+		}
+		lines := []string{header, fit("", colW)}
+
+		if len(tasks) == 0 {
+			lines = append(lines, styled(fit("  (none)", colW), dim))
+		}
+		for i := a.tops[c]; i < len(tasks) && i < a.tops[c]+cards; i++ {
+			lines = append(lines, a.card(tasks[i], colW, c == a.col && i == a.rows[c])...)
+		}
+		for len(lines) < body {
+			lines = append(lines, fit("", colW))
+		}
+		columns[c] = lines
+	}
+
+	lines := make([]string, body)
+	for i := range lines {
+		row := make([]string, n)
+		for c := range columns {
+			row[c] = columns[c][i]
+		}
+		lines[i] = strings.Join(row, styled("│", dim))
+	}
+	return lines
+}
+
+// card renders one task on the board.
+func (a *App) card(t *model.Task, width int, selected bool) []string {
+	title := fit(" "+oneLine(t.Title), width)
+
+	info := []string{t.ID}
+	if t.Owner != "" {
+		info = append(info, t.Owner)
+	}
+	if t.Shared {
+		info = append(info, "shared")
+	}
+	if t.IsStale() {
+		info = append(info, "stale")
+	}
+	if len(t.Comments) > 0 {
+		info = append(info, fmt.Sprintf("%d comments", len(t.Comments)))
+	}
+	blocked := !a.workflow.IsDone(t.Status) && t.IsBlocked(a.tasks, a.workflow)
+	if blocked {
+		info = append(info, "blocked")
+	}
+	sub := fit(" "+strings.Join(info, " · "), width)
+
+	switch {
+	case selected:
+		return []string{styled(title, reverse), styled(sub, reverse, dim), fit("", width)}
+	case blocked:
+		return []string{styled(title, yellow), styled(sub, dim), fit("", width)}
+	}
+	return []string{title, styled(sub, dim), fit("", width)}
+}
+
+// drawTaskDetail shows the selected task in full.
+func (a *App) drawTaskDetail(body int) []string {
+	t := a.selectedTask()
+	width := a.width - 2
+
+	var lines []string
+	for _, l := range wrap(t.Title, width) {
+		lines = append(lines, styled(l, bold))
+	}
+	meta := []string{t.ID, string(t.Status)}
+	if t.Owner != "" {
+		meta = append(meta, "owner "+t.Owner)
+	}
+	if t.Reviewer != "" {
+		meta = append(meta, "reviewer "+t.Reviewer)
+	}
+	meta = append(meta, "created by "+t.CreatedBy, storageName(t.Shared))
+	lines = append(lines, styled(fit(strings.Join(meta, " · "), width), dim))
+
+	if len(t.BlockedBy) > 0 {
+		lines = append(lines, styled(fit("Blocked by: "+strings.Join(t.BlockedBy, ", "), width), yellow))
+	}
+	if t.Description != "" {
+		lines = append(lines, "")
+		lines = append(lines, renderMarkdown(t.Description, width)...)
+	}
+
+	if len(t.Comments) > 0 {
+		lines = append(lines, "", styled(fmt.Sprintf("Comments (%d)", len(t.Comments)), bold))
+		for _, c := range t.Comments {
+			lines = append(lines, styled(fmt.Sprintf("%s · %s", c.Author, c.CreatedAt.Local().Format("2006-01-02 15:04")), dim))
+			for _, l := range wrap(c.Content, width-2) {
+				lines = append(lines, "  "+l)
+			}
+		}
+	}
+
+	if last := len(lines) - body; a.scroll > last {
+		a.scroll = last
+	}
+	if a.scroll < 0 {
+		a.scroll = 0
+	}
+
+	var out []string
+	for _, l := range lines[a.scroll:] {
+		out = append(out, " "+l)
+	}
+	return out
+}
+
+// drawLocks shows the live locks.
+func (a *App) drawLocks(body int) []string {
+	if len(a.locks) == 0 {
+		return []string{styled(" No active locks", dim)}
+	}
+
+	holderW, storageW, expiresW := 16, 8, 12
+	targetW := a.width - holderW - storageW - expiresW - 1
+	if targetW < 10 {
+		targetW = 10
+	}
+
+	header := " " + fit("TARGET", targetW) + fit("HOLDER", holderW) + fit("STORAGE", storageW) + fit("EXPIRES", expiresW)
+	lines := []string{styled(header, bold)}
+
+	rows := body - 1
+	a.lockSel = clamp(a.lockSel, len(a.locks))
+	a.lockTop = window(a.lockSel, a.lockTop, rows, len(a.locks))
+
+	for i := a.lockTop; i < len(a.locks) && i < a.lockTop+rows; i++ {
+		l := a.locks[i].lock
+		expires := "in " + untilString(time.Until(l.ExpiresAt))
+
+		holders := strings.Join(l.HeldBy(), ", ")
+		if l.IsShared() {
+			holders = "r: " + holders
+		}
+		holder := fit(holders, holderW)
+		row := " " + fit(l.Target, targetW) + holder + fit(storageName(a.locks[i].shared), storageW) + fit(expires, expiresW)
+		switch {
+		case i == a.lockSel:
+			row = styled(row, reverse)
+		case l.IsOwnedBy(a.client.Author()):
+			row = styled(row, green)
+		}
+		lines = append(lines, row)
+	}
+	return lines
+}
+
+// untilString formats a duration to the minute, such as 4h or 1h23m.
+func untilString(d time.Duration) string {
+	d = d.Round(time.Minute)
+	if d < time.Minute {
+		return "<1m"
+	}
+	s := strings.TrimSuffix(d.String(), "0s")
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
+	}
+	return s
+}
+
+func storageName(shared bool) string {
+	if shared {
+		return "shared"
+	}
+	return "local"
+}